@@ -3,9 +3,9 @@ package actions
 
 import (
 	"errors"
-	"github.com/gorilla/websocket"
 	"github.com/hewiefreeman/GopherGameServer/core"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"time"
 )
 
 // CustomClientAction is an action that you can handle on the server from
@@ -26,7 +26,9 @@ type Client struct {
 
 	user   *core.User
 	connID string
-	socket *websocket.Conn
+	socket  core.ClientConn
+	logger  helpers.Logger
+	metrics *helpers.Metrics
 
 	responded bool
 }
@@ -38,11 +40,20 @@ type ClientError struct {
 	id      int
 }
 
-var (
-	customClientActions map[string]CustomClientAction = make(map[string]CustomClientAction)
+// Instance holds the CustomClientActions of a single server. The package-level New() function adds
+// actions to the default Instance, which is the one used by the server when started with gopher.Start().
+// Servers made with gopher.NewServer() get their own Instance, which you can retrieve with *Server.Actions().
+type Instance struct {
+	customClientActions map[string]CustomClientAction
+	logger              helpers.Logger
+	metrics             *helpers.Metrics
+
+	serverStarted bool
+	serverPaused  bool
+}
 
-	serverStarted = false
-	serverPaused  = false
+var (
+	defaultInstance *Instance = NewInstance()
 )
 
 // Default `ClientError`s
@@ -65,6 +76,16 @@ const (
 	DataTypeNil           // nil data type
 )
 
+// NewInstance makes a new Instance with no CustomClientActions.
+func NewInstance() *Instance {
+	return &Instance{customClientActions: make(map[string]CustomClientAction), logger: helpers.DefaultLogger()}
+}
+
+// Default gets the default Instance, which the package-level New() function works on.
+func Default() *Instance {
+	return defaultInstance
+}
+
 // New creates a new `CustomClientAction` with the corresponding parameters:
 //
 // - actionType (string): The type of action
@@ -89,10 +110,15 @@ const (
 //
 // Note: This function can only be called BEFORE starting the server.
 func New(actionType string, dataType int, callback func(interface{}, *Client)) error {
-	if serverStarted {
+	return defaultInstance.New(actionType, dataType, callback)
+}
+
+// New creates a new `CustomClientAction` on the Instance. Works the same as the package-level New() function.
+func (a *Instance) New(actionType string, dataType int, callback func(interface{}, *Client)) error {
+	if a.serverStarted {
 		return errors.New("Cannot make a new CustomClientAction once the server has started")
 	}
-	customClientActions[actionType] = CustomClientAction{
+	a.customClientActions[actionType] = CustomClientAction{
 		dataType: dataType,
 		callback: callback,
 	}
@@ -113,21 +139,31 @@ func NoError() ClientError {
 //   SEND A CustomClientAction RESPONSE TO THE Client   ///////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
+// HandleCustomClientAction handles your custom client actions on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Your CustomClientAction callbacks are called
+// from this function. This could spawn errors and/or memory leaks.
+func HandleCustomClientAction(action string, data interface{}, user *core.User, conn core.ClientConn, connID string) {
+	defaultInstance.HandleCustomClientAction(action, data, user, conn, connID)
+}
+
 // HandleCustomClientAction handles your custom client actions.
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Your CustomClientAction callbacks are called
 // from this function. This could spawn errors and/or memory leaks.
-func HandleCustomClientAction(action string, data interface{}, user *core.User, conn *websocket.Conn, connID string) {
-	client := Client{user: user, action: action, socket: conn, connID: connID, responded: false}
+func (a *Instance) HandleCustomClientAction(action string, data interface{}, user *core.User, conn core.ClientConn, connID string) {
+	client := Client{user: user, action: action, socket: conn, connID: connID, logger: a.logger, metrics: a.metrics, responded: false}
 	// CHECK IF ACTION EXISTS
-	if customAction, ok := customClientActions[action]; ok {
+	if customAction, ok := a.customClientActions[action]; ok {
 		// CHECK IF THE TYPE OF data MATCHES THE TYPE action SPECIFIES
 		if !typesMatch(data, customAction.dataType) {
 			client.Respond(nil, NewError("Mismatched data type", ErrorMismatchedTypes))
 			return
 		}
 		//EXECUTE CALLBACK
+		start := time.Now()
 		customAction.callback(data, &client)
+		a.metrics.Observe(helpers.MetricCustomActionDuration, time.Since(start).Seconds(), "action", action)
 	} else {
 		client.Respond(nil, NewError("Unrecognized action", ErrorUnrecognizedAction))
 	}
@@ -222,7 +258,11 @@ func (c *Client) Respond(response interface{}, err ClientError) {
 		r[helpers.ServerActionCustomClientActionResponse]["r"] = response
 	}
 	//SEND MESSAGE TO CLIENT
-	(*c).socket.WriteJSON(r)
+	if writeErr := (*c).socket.Send(r); writeErr != nil {
+		(*c).logger.Warn("Error sending CustomClientAction response", "action", (*c).action, "error", writeErr)
+		return
+	}
+	(*c).metrics.Inc(helpers.MetricMessagesSent, "type", helpers.ServerActionCustomClientActionResponse)
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -252,9 +292,22 @@ func (c *Client) Action() string {
 
 // SetServerStarted is for Gopher Game Server internal mechanics only.
 func SetServerStarted(val bool) {
-	if !serverStarted {
-		serverStarted = val
-	}
+	defaultInstance.SetServerStarted(val)
+}
+
+// SetServerStarted is for Gopher Game Server internal mechanics only.
+func (a *Instance) SetServerStarted(val bool) {
+	a.serverStarted = val
+}
+
+// SetLogger is for Gopher Game Server internal mechanics only.
+func (a *Instance) SetLogger(l helpers.Logger) {
+	a.logger = l
+}
+
+// SetMetrics is for Gopher Game Server internal mechanics only.
+func (a *Instance) SetMetrics(m *helpers.Metrics) {
+	a.metrics = m
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -263,14 +316,24 @@ func SetServerStarted(val bool) {
 
 // Pause is only for internal Gopher Game Server mechanics.
 func Pause() {
-	if !serverPaused {
-		serverPaused = true
+	defaultInstance.Pause()
+}
+
+// Pause is only for internal Gopher Game Server mechanics.
+func (a *Instance) Pause() {
+	if !a.serverPaused {
+		a.serverPaused = true
 	}
 }
 
 // Resume is only for internal Gopher Game Server mechanics.
 func Resume() {
-	if serverPaused {
-		serverPaused = false
+	defaultInstance.Resume()
+}
+
+// Resume is only for internal Gopher Game Server mechanics.
+func (a *Instance) Resume() {
+	if a.serverPaused {
+		a.serverPaused = false
 	}
 }
@@ -0,0 +1,599 @@
+package gopher
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// HOW LONG AN ADMIN SESSION TOKEN STAYS VALID
+	adminSessionDuration time.Duration = time.Hour
+
+	// FAILED ADMIN LOGINS ALLOWED FROM ONE ADDRESS BEFORE IT'S LOCKED OUT
+	adminMaxLoginAttempts int = 5
+
+	// HOW LONG AN ADDRESS IS LOCKED OUT AFTER TOO MANY FAILED ADMIN LOGINS
+	adminLoginLockout time.Duration = time.Minute
+)
+
+type adminSessions struct {
+	tokens   map[string]time.Time          // token -> expiry
+	attempts map[string]*adminLoginAttempt // remote address -> failed logins
+	mux      sync.Mutex
+}
+
+type adminLoginAttempt struct {
+	failures    int       // FAILED LOGINS SINCE THE LAST LOCKOUT. FORGOTTEN AFTER adminLoginLockout WITHOUT A FAILURE
+	last        time.Time // LAST FAILED LOGIN
+	lockedUntil time.Time
+}
+
+type adminUser struct {
+	Name        string            `json:"name"`
+	Guest       bool              `json:"guest"`
+	Status      int               `json:"status"`
+	DatabaseID  int               `json:"databaseID"`
+	Connections []adminConnection `json:"connections,omitempty"`
+	Friends     []adminFriend     `json:"friends,omitempty"`
+}
+
+type adminConnection struct {
+	ID   string                 `json:"id"`
+	Room string                 `json:"room"`
+	Vars map[string]interface{} `json:"vars"`
+}
+
+type adminFriend struct {
+	Name   string `json:"name"`
+	Status int    `json:"status"`
+}
+
+type adminRoom struct {
+	Name     string                 `json:"name"`
+	Type     string                 `json:"type"`
+	Private  bool                   `json:"private"`
+	Owner    string                 `json:"owner"`
+	MaxUsers int                    `json:"maxUsers"`
+	NumUsers int                    `json:"numUsers"`
+	Users    []string               `json:"users,omitempty"`
+	Invites  []string               `json:"invites,omitempty"`
+	Vars     map[string]interface{} `json:"vars,omitempty"`
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Admin API   /////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// AdminHandler gets an http.Handler for the Server's admin REST API. The handler is served on AdminAPIPath (default '/admin') of every
+// Listener when EnableAdminAPI is set in ServerSettings. You can also serve it from your own HTTP server, with the API path
+// removed by http.StripPrefix(). IMPORTANT: Only serve the admin API over TLS, or on a private network.
+//
+// Start by sending AdminLogin and AdminPassword to `POST /login` as JSON (ex: {"login": "admin", "password": "password"}). The
+// response contains a session token, which must be sent with every other request in an "Authorization: Bearer <token>" header.
+// Five failed logins from one address, each within a minute of the last, lock that address out for a minute. All requests and
+// responses are JSON. Requests made by a browser must come from HostName or HostAlias, with the same scheme and port, whether or
+// not OriginOnly is set.
+//
+//	POST   /login                  Log in, and get a session token
+//	POST   /logout                 End the session
+//	GET    /server                 Server state, version, and User, guest, Room and connection counts
+//	POST   /server/pause           Pause the server
+//	POST   /server/resume          Resume the server
+//	POST   /server/save            Save the server's state (needs EnableRecovery)
+//	POST   /server/reload          Reload the server's settings, responding with the ReloadReport
+//	POST   /server/drain           Drain then shut down the server. Takes {"seconds": int}
+//	POST   /server/shutdown        Shut the server down
+//	GET    /users                  List all Users
+//	GET    /users/{name}           A User's connections, Room, variables and friends
+//	POST   /users/{name}/kick      Kick a User
+//	POST   /users/{name}/message   Send a data message to all of a User's connections. Takes {"message": any}
+//	GET    /rooms                  List all Rooms
+//	POST   /rooms                  Make a Room. Takes {"name": string, "type": string, "private": bool, "maxUsers": int, "owner": string}
+//	GET    /rooms/{name}           A Room's Users, invite list and variables
+//	DELETE /rooms/{name}           Delete a Room
+//	POST   /rooms/{name}/vars      Set a Room's variables. Takes a JSON object of variable names and values
+//	GET    /snapshots              List the recovery snapshots, newest first, with their Room counts (needs EnableRecovery)
+//	GET    /snapshots/{id}/diff    What restoring a snapshot would change: Rooms added, removed, and changed with their differences
+//	POST   /snapshots/{id}/restore Restore a snapshot into the running server. Takes {"replace": bool}, or merges into the live Rooms by default
+//	GET    /console                Remote console websocket (see below)
+//
+// The remote console accepts the same commands as the command-line macros (ex: "getroom lobby", "kick bob", "drain 60"). Send each
+// command as {"id": any, "c": string}, and the result comes back as {"id": any, "ok": bool, "result": any, "error": string} with the
+// same id. While connected, the server's log lines are streamed as {"log": {"time": string, "level": string, "msg": string, "fields": object}}.
+// Add "?log=debug", "warn", "error", or "none" to the console's URL to change which log levels are streamed (default is info). The session
+// token can also be given as "?token=<token>", for clients that can't set headers on websockets.
+func (s *Server) AdminHandler() http.Handler {
+	return http.HandlerFunc(s.adminRouter)
+}
+
+// AdminHandler gets the admin REST API http.Handler for the default server. See *Server.AdminHandler() for more details.
+func AdminHandler() http.Handler {
+	return defaultServer.AdminHandler()
+}
+
+func (settings *ServerSettings) adminAPIPath() string {
+	if settings.AdminAPIPath == "" {
+		return "/admin"
+	}
+	return strings.TrimSuffix(settings.AdminAPIPath, "/")
+}
+
+func (s *Server) handleAdminAPI(mux *http.ServeMux) {
+	path := s.getSettings().adminAPIPath()
+	mux.Handle(path+"/", http.StripPrefix(path, s.AdminHandler()))
+}
+
+func (s *Server) adminRouter(w http.ResponseWriter, r *http.Request) {
+	if s.getSettings() == nil {
+		adminError(w, http.StatusServiceUnavailable, "Server is not running")
+		return
+	}
+	if !s.adminOriginAllowed(r) {
+		adminError(w, http.StatusForbidden, "Origin not allowed")
+		return
+	}
+	route := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+
+	// The dashboard's files are public, it logs in through the API
+	if route[0] == "ui" && s.getSettings().EnableAdminDashboard {
+		s.serveDashboard(w, r)
+		return
+	}
+
+	// Login doesn't need a session
+	if len(route) == 1 && route[0] == "login" {
+		if r.Method != http.MethodPost {
+			adminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		s.adminLogin(w, r)
+		return
+	}
+
+	// Check session token. Browsers can't set headers on websockets, so the console can also take it from the URL
+	token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if token == "" && len(route) == 1 && route[0] == "console" {
+		token = r.URL.Query().Get("token")
+	}
+	if !s.admin.checkToken(token) {
+		adminError(w, http.StatusUnauthorized, "Not logged in")
+		return
+	}
+
+	switch {
+	case len(route) == 1 && route[0] == "logout" && r.Method == http.MethodPost:
+		s.admin.removeToken(token)
+		adminJSON(w, http.StatusOK, nil)
+	case len(route) == 1 && route[0] == "console":
+		s.adminConsole(w, r, token)
+	case route[0] == "server":
+		s.adminServer(w, r, route[1:])
+	case route[0] == "users":
+		s.adminUsers(w, r, route[1:])
+	case route[0] == "rooms":
+		s.adminRooms(w, r, route[1:])
+	case route[0] == "snapshots":
+		s.adminSnapshots(w, r, route[1:])
+	default:
+		adminError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Admin sessions   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// adminOriginAllowed checks that browser requests come from HostName or HostAlias (see originAllowed()). Requests without an Origin
+// header don't come from a browser, and are allowed.
+func (s *Server) adminOriginAllowed(r *http.Request) bool {
+	return r.Header.Get("Origin") == "" || s.originAllowed(r)
+}
+
+func (s *Server) adminLogin(w http.ResponseWriter, r *http.Request) {
+	addr, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		addr = r.RemoteAddr
+	}
+	if wait := s.admin.lockedOut(addr); wait > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait/time.Second)+1))
+		adminError(w, http.StatusTooManyRequests, "Too many failed logins")
+		return
+	}
+
+	var params struct {
+		Login    string `json:"login"`
+		Password string `json:"password"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		adminError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	settings := s.getSettings()
+	loginOK := subtle.ConstantTimeCompare([]byte(params.Login), []byte(settings.AdminLogin)) == 1
+	passOK := subtle.ConstantTimeCompare([]byte(params.Password), []byte(settings.AdminPassword)) == 1
+	if !loginOK || !passOK {
+		s.admin.loginFailed(addr)
+		s.logger.Warn("Failed admin login", "address", addr)
+		adminError(w, http.StatusUnauthorized, "Incorrect login or password")
+		return
+	}
+
+	token, expires, tokenErr := s.admin.newToken(addr)
+	if tokenErr != nil {
+		adminError(w, http.StatusInternalServerError, tokenErr.Error())
+		return
+	}
+	s.logger.Info("Admin logged in", "address", addr)
+	adminJSON(w, http.StatusOK, map[string]interface{}{"token": token, "expires": expires})
+}
+
+func (a *adminSessions) lockedOut(addr string) time.Duration {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	attempt := a.attempts[addr]
+	if attempt == nil {
+		return 0
+	}
+	if wait := time.Until(attempt.lockedUntil); wait > 0 {
+		return wait
+	}
+	if attempt.expired(time.Now()) {
+		delete(a.attempts, addr)
+	}
+	return 0
+}
+
+// loginFailed counts a failed login. The adminMaxLoginAttempts'th failure in a row, each within adminLoginLockout of the last, locks the address out
+func (a *adminSessions) loginFailed(addr string) {
+	a.mux.Lock()
+	defer a.mux.Unlock()
+	if a.attempts == nil {
+		a.attempts = make(map[string]*adminLoginAttempt)
+	}
+	now := time.Now()
+	// Remove the addresses that are no longer locked out or counting failures
+	for other, attempt := range a.attempts {
+		if attempt.expired(now) {
+			delete(a.attempts, other)
+		}
+	}
+	attempt := a.attempts[addr]
+	if attempt == nil {
+		attempt = &adminLoginAttempt{}
+		a.attempts[addr] = attempt
+	}
+	attempt.failures++
+	attempt.last = now
+	if attempt.failures >= adminMaxLoginAttempts {
+		attempt.failures = 0
+		attempt.lockedUntil = now.Add(adminLoginLockout)
+	}
+}
+
+// expired reports whether the attempt's lockout and failures have both run out, so it can be forgotten
+func (attempt *adminLoginAttempt) expired(now time.Time) bool {
+	return !now.Before(attempt.lockedUntil) && now.Sub(attempt.last) >= adminLoginLockout
+}
+
+func (a *adminSessions) newToken(addr string) (string, time.Time, error) {
+	token, err := helpers.GenerateSecureString(32)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	expires := time.Now().Add(adminSessionDuration)
+	a.mux.Lock()
+	if a.tokens == nil {
+		a.tokens = make(map[string]time.Time)
+	}
+	// Remove expired sessions
+	for t, exp := range a.tokens {
+		if time.Now().After(exp) {
+			delete(a.tokens, t)
+		}
+	}
+	a.tokens[token] = expires
+	delete(a.attempts, addr)
+	a.mux.Unlock()
+	return token, expires, nil
+}
+
+func (a *adminSessions) checkToken(token string) bool {
+	if token == "" {
+		return false
+	}
+	a.mux.Lock()
+	expires, ok := a.tokens[token]
+	a.mux.Unlock()
+	return ok && time.Now().Before(expires)
+}
+
+func (a *adminSessions) removeToken(token string) {
+	a.mux.Lock()
+	delete(a.tokens, token)
+	a.mux.Unlock()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Server routes   /////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) adminServer(w http.ResponseWriter, r *http.Request, route []string) {
+	if len(route) == 0 {
+		if r.Method != http.MethodGet {
+			adminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		adminJSON(w, http.StatusOK, map[string]interface{}{
+			"name":        s.getSettings().ServerName,
+			"version":     version,
+			"state":       s.State(),
+			"connections": s.ClientsConnected(),
+			"users":       s.core.UserCount(),
+			"guests":      s.core.GuestCount(),
+			"rooms":       s.core.RoomCount(),
+		})
+		return
+	} else if len(route) != 1 || r.Method != http.MethodPost {
+		adminError(w, http.StatusNotFound, "Not found")
+		return
+	}
+
+	switch route[0] {
+	case "pause":
+		s.Pause()
+	case "resume":
+		s.Resume()
+	case "save":
+		if !s.getSettings().EnableRecovery {
+			adminError(w, http.StatusBadRequest, "EnableRecovery is not set in ServerSettings")
+			return
+		}
+		if err := s.saveState(); err != nil {
+			adminError(w, http.StatusInternalServerError, err.Error())
+			return
+		}
+	case "reload":
+		report, err := s.Reload()
+		if err != nil {
+			adminError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		adminJSON(w, http.StatusOK, report)
+		return
+	case "drain":
+		var params struct {
+			Seconds int `json:"seconds"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil || params.Seconds < 0 {
+			adminError(w, http.StatusBadRequest, "drain expects {\"seconds\": int}")
+			return
+		}
+		// The shut-down waits for this request to finish, so it can't run on the request's goroutine
+		go s.DrainAndShutdown(context.Background(), time.Duration(params.Seconds)*time.Second)
+	case "shutdown":
+		go s.Shutdown(context.Background())
+	default:
+		adminError(w, http.StatusNotFound, "Not found")
+		return
+	}
+	s.logger.Info("Admin API action", "action", route[0])
+	adminJSON(w, http.StatusOK, map[string]interface{}{"state": s.State()})
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Snapshot routes   ///////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) adminSnapshots(w http.ResponseWriter, r *http.Request, route []string) {
+	switch {
+	case len(route) == 0 && r.Method == http.MethodGet:
+		snapshots, err := s.listSnapshots()
+		if err != nil {
+			adminError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		adminJSON(w, http.StatusOK, snapshots)
+	case len(route) == 2 && route[1] == "diff" && r.Method == http.MethodGet:
+		diff, err := s.diffSnapshot(route[0])
+		if err != nil {
+			adminError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		adminJSON(w, http.StatusOK, diff)
+	case len(route) == 2 && route[1] == "restore" && r.Method == http.MethodPost:
+		var params struct {
+			Replace bool `json:"replace"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+				adminError(w, http.StatusBadRequest, "restore expects {\"replace\": bool}")
+				return
+			}
+		}
+		result, err := s.restoreSnapshot(route[0], params.Replace)
+		if err != nil {
+			adminError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.logger.Info("Admin API restored snapshot", "snapshot", route[0], "replace", params.Replace)
+		adminJSON(w, http.StatusOK, result)
+	default:
+		adminError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   User routes   ///////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) adminUsers(w http.ResponseWriter, r *http.Request, route []string) {
+	if len(route) == 0 {
+		if r.Method != http.MethodGet {
+			adminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			return
+		}
+		users := make([]adminUser, 0)
+		for _, user := range s.core.GetUsers() {
+			users = append(users, adminUser{Name: user.Name(), Guest: user.IsGuest(), Status: user.Status(), DatabaseID: user.DatabaseID()})
+		}
+		adminJSON(w, http.StatusOK, users)
+		return
+	}
+
+	user, userErr := s.core.GetUser(route[0])
+	if userErr != nil {
+		adminError(w, http.StatusNotFound, userErr.Error())
+		return
+	}
+
+	switch {
+	case len(route) == 1 && r.Method == http.MethodGet:
+		adminJSON(w, http.StatusOK, adminUserInfo(user))
+	case len(route) == 2 && route[1] == "kick" && r.Method == http.MethodPost:
+		user.Kick()
+		s.logger.Info("Admin API kicked user", "user", route[0])
+		adminJSON(w, http.StatusOK, nil)
+	case len(route) == 2 && route[1] == "message" && r.Method == http.MethodPost:
+		var params struct {
+			Message interface{} `json:"message"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+			adminError(w, http.StatusBadRequest, "message expects {\"message\": any}")
+			return
+		}
+		user.DataMessage(params.Message, "")
+		adminJSON(w, http.StatusOK, nil)
+	default:
+		adminError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func adminUserInfo(user *core.User) adminUser {
+	info := adminUser{Name: user.Name(), Guest: user.IsGuest(), Status: user.Status(), DatabaseID: user.DatabaseID()}
+	for _, connID := range user.ConnectionIDs() {
+		conn := adminConnection{ID: connID, Vars: user.GetVariables(nil, connID)}
+		if room := user.RoomIn(connID); room != nil {
+			conn.Room = room.Name()
+		}
+		info.Connections = append(info.Connections, conn)
+	}
+	for name, friend := range user.Friends() {
+		info.Friends = append(info.Friends, adminFriend{Name: name, Status: friend.RequestStatus()})
+	}
+	return info
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Room routes   ///////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) adminRooms(w http.ResponseWriter, r *http.Request, route []string) {
+	if len(route) == 0 {
+		switch r.Method {
+		case http.MethodGet:
+			rooms := make([]adminRoom, 0)
+			for _, room := range s.core.GetRooms() {
+				rooms = append(rooms, adminRoom{Name: room.Name(), Type: room.Type(), Private: room.IsPrivate(), Owner: room.Owner(),
+					MaxUsers: room.MaxUsers(), NumUsers: room.NumUsers()})
+			}
+			adminJSON(w, http.StatusOK, rooms)
+		case http.MethodPost:
+			s.adminNewRoom(w, r)
+		default:
+			adminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+		return
+	}
+
+	room, roomErr := s.core.GetRoom(route[0])
+	if roomErr != nil {
+		adminError(w, http.StatusNotFound, roomErr.Error())
+		return
+	}
+
+	switch {
+	case len(route) == 1 && r.Method == http.MethodGet:
+		adminJSON(w, http.StatusOK, adminRoomInfo(room))
+	case len(route) == 1 && r.Method == http.MethodDelete:
+		if err := room.Delete(); err != nil {
+			adminError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		s.logger.Info("Admin API deleted room", "room", route[0])
+		adminJSON(w, http.StatusOK, nil)
+	case len(route) == 2 && route[1] == "vars" && r.Method == http.MethodPost:
+		var vars map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&vars); err != nil {
+			adminError(w, http.StatusBadRequest, "Invalid JSON body")
+			return
+		}
+		room.SetVariables(vars)
+		adminJSON(w, http.StatusOK, adminRoomInfo(room))
+	default:
+		adminError(w, http.StatusNotFound, "Not found")
+	}
+}
+
+func (s *Server) adminNewRoom(w http.ResponseWriter, r *http.Request) {
+	var params struct {
+		Name     string `json:"name"`
+		Type     string `json:"type"`
+		Private  bool   `json:"private"`
+		MaxUsers int    `json:"maxUsers"`
+		Owner    string `json:"owner"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&params); err != nil {
+		adminError(w, http.StatusBadRequest, "Invalid JSON body")
+		return
+	}
+	if params.Private && params.Owner == "" {
+		params.Owner = s.getSettings().ServerName
+	}
+	room, roomErr := s.core.NewRoom(params.Name, params.Type, params.Private, params.MaxUsers, params.Owner)
+	if roomErr != nil {
+		adminError(w, http.StatusBadRequest, roomErr.Error())
+		return
+	}
+	s.logger.Info("Admin API created room", "room", params.Name)
+	adminJSON(w, http.StatusCreated, adminRoomInfo(room))
+}
+
+func adminRoomInfo(room *core.Room) adminRoom {
+	info := adminRoom{Name: room.Name(), Type: room.Type(), Private: room.IsPrivate(), Owner: room.Owner(),
+		MaxUsers: room.MaxUsers(), NumUsers: room.NumUsers()}
+	info.Invites, _ = room.InviteList()
+	info.Vars, _ = room.GetVariables(nil)
+	if usrMap, err := room.GetUserMap(); err == nil {
+		for name := range usrMap {
+			info.Users = append(info.Users, name)
+		}
+	}
+	return info
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Responses   /////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func adminJSON(w http.ResponseWriter, status int, body interface{}) {
+	if body == nil {
+		body = map[string]interface{}{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func adminError(w http.ResponseWriter, status int, msg string) {
+	adminJSON(w, status, map[string]interface{}{"error": msg})
+}
@@ -0,0 +1,156 @@
+package gopher
+
+import (
+	"encoding/json"
+	"github.com/gorilla/websocket"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAdminAPI(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:           "!server!",
+		HostName:             "localhost",
+		DisableListeners:     true,
+		AdminLogin:           "admin",
+		AdminPassword:        "password",
+		EnableAdminAPI:       true,
+		EnableAdminDashboard: true})
+	server.Core().NewRoomType("lobby", false)
+
+	api := httptest.NewServer(http.StripPrefix("/admin", server.AdminHandler()))
+	defer api.Close()
+	runTestServer(t, server)
+
+	request := func(method string, path string, token string, body string) (int, map[string]interface{}) {
+		req, _ := http.NewRequest(method, api.URL+"/admin"+path, strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		res, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer res.Body.Close()
+		var resBody map[string]interface{}
+		json.NewDecoder(res.Body).Decode(&resBody)
+		return res.StatusCode, resBody
+	}
+
+	res, err := http.Get(api.URL + "/admin/ui/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	res.Body.Close()
+	if res.StatusCode != http.StatusOK || !strings.HasPrefix(res.Header.Get("Content-Type"), "text/html") {
+		t.Errorf("GET /ui/ responded with %v %v", res.StatusCode, res.Header.Get("Content-Type"))
+	}
+
+	// Browsers must come from HostName, with the scheme and port the API is on
+	_, port, _ := net.SplitHostPort(api.Listener.Addr().String())
+	origins := map[string]int{
+		"http://localhost:" + port:  http.StatusOK,
+		"https://localhost:" + port: http.StatusForbidden,
+		"http://localhost:1":        http.StatusForbidden,
+		"http://localhost":          http.StatusForbidden,
+		"https://evil.example.com":  http.StatusForbidden,
+	}
+	for origin, expected := range origins {
+		req, _ := http.NewRequest("POST", api.URL+"/admin/login", strings.NewReader(`{"login": "admin", "password": "password"}`))
+		req.Header.Set("Origin", origin)
+		if res, err = http.DefaultClient.Do(req); err != nil {
+			t.Fatal(err)
+		}
+		res.Body.Close()
+		if res.StatusCode != expected {
+			t.Errorf("Login from %v responded with %v", origin, res.StatusCode)
+		}
+	}
+
+	if status, _ := request("GET", "/server", "", ""); status != http.StatusUnauthorized {
+		t.Errorf("GET /server without a token responded with %v", status)
+	}
+
+	// The lockout starts at the last failed login, not the first
+	defer func(lockout time.Duration) { adminLoginLockout = lockout }(adminLoginLockout)
+	adminLoginLockout = time.Millisecond * 300
+	request("POST", "/login", "", `{"login": "admin", "password": "wrong"}`)
+	time.Sleep(time.Millisecond * 200)
+	for i := 1; i < adminMaxLoginAttempts; i++ {
+		if status, _ := request("POST", "/login", "", `{"login": "admin", "password": "wrong"}`); status != http.StatusUnauthorized {
+			t.Errorf("Failed login %v responded with %v", i+1, status)
+		}
+	}
+	time.Sleep(time.Millisecond * 150)
+	if status, _ := request("POST", "/login", "", `{"login": "admin", "password": "password"}`); status != http.StatusTooManyRequests {
+		t.Errorf("Login after %v failed attempts responded with %v", adminMaxLoginAttempts, status)
+	}
+	time.Sleep(time.Millisecond * 200)
+
+	status, body := request("POST", "/login", "", `{"login": "admin", "password": "password"}`)
+	token, _ := body["token"].(string)
+	if status != http.StatusOK || token == "" {
+		t.Fatalf("Login responded with %v %v", status, body)
+	}
+	if status, body = request("POST", "/rooms", token, `{"name": "room", "type": "lobby", "maxUsers": 4}`); status != http.StatusCreated {
+		t.Errorf("POST /rooms responded with %v %v", status, body)
+	}
+	if status, body = request("POST", "/rooms/room/vars", token, `{"round": 2}`); status != http.StatusOK || body["vars"] == nil {
+		t.Errorf("POST /rooms/room/vars responded with %v %v", status, body)
+	}
+	if status, _ = request("DELETE", "/rooms/room", token, ""); status != http.StatusOK || server.Core().RoomCount() != 0 {
+		t.Errorf("DELETE /rooms/room responded with %v", status)
+	}
+	if status, body = request("POST", "/server/pause", token, ""); status != http.StatusOK || body["state"] != ServerStatePaused {
+		t.Errorf("POST /server/pause responded with %v %v", status, body)
+	}
+	request("POST", "/server/resume", token, "")
+	// Remote console
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/admin/console?token="+token, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second * 5))
+	var logMsg map[string]map[string]interface{}
+	if err = conn.ReadJSON(&logMsg); err != nil || logMsg["log"]["msg"] != "Admin console connected" {
+		t.Errorf("Console didn't stream the log, got %v %v", logMsg, err)
+	}
+	conn.WriteJSON(consoleCommand{ID: 1.0, Command: "roomcount"})
+	var result consoleResult
+	if err = conn.ReadJSON(&result); err != nil || !result.OK || result.ID != 1.0 {
+		t.Errorf("Console command responded with %+v %v", result, err)
+	}
+	conn.Close()
+
+	request("POST", "/logout", token, "")
+	if status, _ = request("GET", "/server", token, ""); status != http.StatusUnauthorized {
+		t.Errorf("GET /server after logout responded with %v", status)
+	}
+
+}
+
+func TestAdminLoginAttempts(t *testing.T) {
+	defer func(lockout time.Duration) { adminLoginLockout = lockout }(adminLoginLockout)
+	adminLoginLockout = time.Millisecond * 100
+	sessions := &adminSessions{}
+
+	// Failed logins from many addresses are forgotten once their lockout window has passed
+	for i := 0; i < 100; i++ {
+		for j := 0; j < adminMaxLoginAttempts; j++ {
+			sessions.loginFailed("10.0.0." + strconv.Itoa(i))
+		}
+	}
+	if wait := sessions.lockedOut("10.0.0.1"); wait <= 0 {
+		t.Error("Address wasn't locked out")
+	}
+	time.Sleep(time.Millisecond * 150)
+	sessions.loginFailed("10.0.1.1")
+	if len(sessions.attempts) != 1 {
+		t.Errorf("Kept failed logins for %v addresses", len(sessions.attempts))
+	}
+}
@@ -2,8 +2,6 @@ package gopher
 
 import (
 	"errors"
-	"github.com/hewiefreeman/GopherGameServer/core"
-	"github.com/hewiefreeman/GopherGameServer/database"
 	"net/http"
 )
 
@@ -13,6 +11,8 @@ const (
 	// ErrorServerRunning is thrown when an action cannot be taken because the server is running. Pausing the server
 	// will enable you to run the command.
 	ErrorServerRunning = "Cannot call when the server is running."
+	// ErrorInvalidSettings is thrown when the ServerSettings passed to a Server are missing required options.
+	ErrorInvalidSettings = "Invalid ServerSettings"
 )
 
 // SetStartCallback sets the callback that triggers when the server first starts up. The
@@ -21,11 +21,11 @@ const (
 //    func serverStarted(){
 //	     //code...
 //	 }
-func SetStartCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetStartCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func()); ok {
-		startCallback = callback
+		s.startCallback = callback
 	} else {
 		return errors.New(ErrorIncorrectFunction)
 	}
@@ -38,11 +38,11 @@ func SetStartCallback(cb interface{}) error {
 //    func serverPaused(){
 //	     //code...
 //	 }
-func SetPauseCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetPauseCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func()); ok {
-		pauseCallback = callback
+		s.pauseCallback = callback
 	} else {
 		return errors.New(ErrorIncorrectFunction)
 	}
@@ -55,11 +55,11 @@ func SetPauseCallback(cb interface{}) error {
 //    func serverResumed(){
 //	     //code...
 //	 }
-func SetResumeCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetResumeCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func()); ok {
-		resumeCallback = callback
+		s.resumeCallback = callback
 	} else {
 		return errors.New(ErrorIncorrectFunction)
 	}
@@ -72,11 +72,11 @@ func SetResumeCallback(cb interface{}) error {
 //    func serverStopped(){
 //	     //code...
 //	 }
-func SetShutDownCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetShutDownCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func()); ok {
-		stopCallback = callback
+		s.stopCallback = callback
 	} else {
 		return errors.New(ErrorIncorrectFunction)
 	}
@@ -92,11 +92,11 @@ func SetShutDownCallback(cb interface{}) error {
 //
 // The function returns a boolean. If false is returned, the client will receive an HTTP error `http.StatusForbidden` and
 // will be rejected from the server. This can be used to, for instance, make a black/white list or implement client sessions.
-func SetClientConnectCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetClientConnectCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(*http.ResponseWriter, *http.Request) bool); ok {
-		clientConnectCallback = callback
+		s.clientConnectCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
@@ -118,14 +118,14 @@ func SetClientConnectCallback(cb interface{}) error {
 // Note: the `clientColumns` decides which `AccountInfoColumn`s were fetched from the database, so the keys will always be the same as `receivedColumns`.
 // You can compare the `receivedColumns` and `clientColumns` to, for instance, compare the key 'email' to make sure the
 // client also provided the right email address for that account on the database.
-func SetLoginCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetLoginCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, int, map[string]interface{}, map[string]interface{}) bool); ok {
-		if (*settings).EnableSqlFeatures {
-			database.LoginCallback = callback
+		if settings := s.getSettings(); settings != nil && settings.EnableSqlFeatures {
+			s.database.LoginCallback = callback
 		} else {
-			core.LoginCallback = callback
+			s.core.LoginCallback = callback
 		}
 		return nil
 	}
@@ -140,11 +140,11 @@ func SetLoginCallback(cb interface{}) error {
 //	 }
 //
 // `userName` is the name of the User logging in, `databaseID` is the index of the User on the database.
-func SetLogoutCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetLogoutCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, int)); ok {
-		core.LogoutCallback = callback
+		s.core.LogoutCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
@@ -162,11 +162,11 @@ func SetLogoutCallback(cb interface{}) error {
 //
 // The function returns a boolean. If false is returned, the client will receive a `helpers.ErrorActionDenied` (1052) error and will be
 // denied from signing up. This can be used to, for instance, deny user names or `AccountInfoColumn`s with profanity.
-func SetSignupCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetSignupCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, map[string]interface{}) bool); ok {
-		database.SignUpCallback = callback
+		s.database.SignUpCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
@@ -188,11 +188,11 @@ func SetSignupCallback(cb interface{}) error {
 // Note: the `clientColumns` decides which `AccountInfoColumn`s were fetched from the database, so the keys will always be the same as `receivedColumns`.
 // You can compare the `receivedColumns` and `clientColumns` to, for instance, compare the keys named 'email' to make sure the
 // client also provided the right email address for that account on the database.
-func SetDeleteAccountCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetDeleteAccountCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, int, map[string]interface{}, map[string]interface{}) bool); ok {
-		database.DeleteAccountCallback = callback
+		s.database.DeleteAccountCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
@@ -214,11 +214,11 @@ func SetDeleteAccountCallback(cb interface{}) error {
 // Note: the `clientColumns` decides which `AccountInfoColumn`s were fetched from the database, so the keys will always be the same as `receivedColumns`.
 // You can compare the `receivedColumns` and `clientColumns` to, for instance, compare the keys named 'email' to make sure the
 // client also provided the right email address for that account on the database.
-func SetAccountInfoChangeCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetAccountInfoChangeCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, int, map[string]interface{}, map[string]interface{}) bool); ok {
-		database.AccountInfoChangeCallback = callback
+		s.database.AccountInfoChangeCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
@@ -240,12 +240,71 @@ func SetAccountInfoChangeCallback(cb interface{}) error {
 // Note: the `clientColumns` decides which `AccountInfoColumn`s were fetched from the database, so the keys will always be the same as `receivedColumns`.
 // You can compare the `receivedColumns` and `clientColumns` to, for instance, compare the keys named 'email' to make sure the
 // client also provided the right email address for that account on the database.
-func SetPasswordChangeCallback(cb interface{}) error {
-	if serverStarted {
+func (s *Server) SetPasswordChangeCallback(cb interface{}) error {
+	if s.isStarted() {
 		return errors.New(ErrorServerRunning)
 	} else if callback, ok := cb.(func(string, int, map[string]interface{}, map[string]interface{}) bool); ok {
-		database.PasswordChangeCallback = callback
+		s.database.PasswordChangeCallback = callback
 		return nil
 	}
 	return errors.New(ErrorIncorrectFunction)
 }
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Default server callbacks   //////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SetStartCallback is the same as *Server.SetStartCallback, but for the default server.
+func SetStartCallback(cb interface{}) error {
+	return defaultServer.SetStartCallback(cb)
+}
+
+// SetPauseCallback is the same as *Server.SetPauseCallback, but for the default server.
+func SetPauseCallback(cb interface{}) error {
+	return defaultServer.SetPauseCallback(cb)
+}
+
+// SetResumeCallback is the same as *Server.SetResumeCallback, but for the default server.
+func SetResumeCallback(cb interface{}) error {
+	return defaultServer.SetResumeCallback(cb)
+}
+
+// SetShutDownCallback is the same as *Server.SetShutDownCallback, but for the default server.
+func SetShutDownCallback(cb interface{}) error {
+	return defaultServer.SetShutDownCallback(cb)
+}
+
+// SetClientConnectCallback is the same as *Server.SetClientConnectCallback, but for the default server.
+func SetClientConnectCallback(cb interface{}) error {
+	return defaultServer.SetClientConnectCallback(cb)
+}
+
+// SetLoginCallback is the same as *Server.SetLoginCallback, but for the default server.
+func SetLoginCallback(cb interface{}) error {
+	return defaultServer.SetLoginCallback(cb)
+}
+
+// SetLogoutCallback is the same as *Server.SetLogoutCallback, but for the default server.
+func SetLogoutCallback(cb interface{}) error {
+	return defaultServer.SetLogoutCallback(cb)
+}
+
+// SetSignupCallback is the same as *Server.SetSignupCallback, but for the default server.
+func SetSignupCallback(cb interface{}) error {
+	return defaultServer.SetSignupCallback(cb)
+}
+
+// SetDeleteAccountCallback is the same as *Server.SetDeleteAccountCallback, but for the default server.
+func SetDeleteAccountCallback(cb interface{}) error {
+	return defaultServer.SetDeleteAccountCallback(cb)
+}
+
+// SetAccountInfoChangeCallback is the same as *Server.SetAccountInfoChangeCallback, but for the default server.
+func SetAccountInfoChangeCallback(cb interface{}) error {
+	return defaultServer.SetAccountInfoChangeCallback(cb)
+}
+
+// SetPasswordChangeCallback is the same as *Server.SetPasswordChangeCallback, but for the default server.
+func SetPasswordChangeCallback(cb interface{}) error {
+	return defaultServer.SetPasswordChangeCallback(cb)
+}
@@ -0,0 +1,105 @@
+package gopher
+
+import (
+	"crypto/tls"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"os"
+	"sync"
+	"time"
+)
+
+var (
+	// HOW OFTEN TLS CERTIFICATE FILES ARE CHECKED FOR CHANGES
+	certPollInterval time.Duration = time.Second * 30
+)
+
+// certReloader serves a TLS Listener's certificate, and swaps in a new one when the certificate or private key file changes on disk.
+type certReloader struct {
+	certFile string
+	keyFile  string
+	logger   helpers.Logger
+
+	cert    *tls.Certificate
+	certMod time.Time
+	keyMod  time.Time
+	mux     sync.Mutex
+}
+
+func newCertReloader(certFile string, keyFile string, logger helpers.Logger) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile, logger: logger}
+	certMod, keyMod, err := c.modTimes()
+	if err != nil {
+		return nil, err
+	}
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, err
+	}
+	c.cert = &cert
+	c.certMod = certMod
+	c.keyMod = keyMod
+	return c, nil
+}
+
+func (c *certReloader) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mux.Lock()
+	cert := c.cert
+	c.mux.Unlock()
+	return cert, nil
+}
+
+func (c *certReloader) modTimes() (time.Time, time.Time, error) {
+	certInfo, err := os.Stat(c.certFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	keyInfo, err := os.Stat(c.keyFile)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	return certInfo.ModTime(), keyInfo.ModTime(), nil
+}
+
+// check loads the certificate pair again if either file changed. If the new pair is invalid, the old certificate is kept.
+func (c *certReloader) check() {
+	certMod, keyMod, err := c.modTimes()
+	if err != nil {
+		c.logger.Error("TLS certificate reload error. Keeping the current certificate", "certFile", c.certFile, "error", err)
+		return
+	}
+	c.mux.Lock()
+	changed := !certMod.Equal(c.certMod) || !keyMod.Equal(c.keyMod)
+	c.mux.Unlock()
+	if !changed {
+		return
+	}
+
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	c.mux.Lock()
+	// Only try each new pair of files once
+	c.certMod = certMod
+	c.keyMod = keyMod
+	if err == nil {
+		c.cert = &cert
+	}
+	c.mux.Unlock()
+
+	if err != nil {
+		c.logger.Error("TLS certificate reload error. Keeping the current certificate", "certFile", c.certFile, "error", err)
+		return
+	}
+	c.logger.Info("TLS certificate reloaded", "certFile", c.certFile)
+}
+
+func (c *certReloader) watch(done chan struct{}) {
+	ticker := time.NewTicker(certPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.check()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package gopher
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// testCert writes a self-signed certificate for localhost to dir, and gets the certificate and private key file locations
+func testCert(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: "localhost"},
+		DNSNames:              []string{"localhost"},
+		IPAddresses:           []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	if err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile
+}
+
+func TestCertReloader(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := testCert(t, dir)
+	var log bytes.Buffer
+	reloader, err := newCertReloader(certFile, keyFile, helpers.NewTextLogger(&log, helpers.LogLevelInfo))
+	if err != nil {
+		t.Fatal(err)
+	}
+	served := func() []byte {
+		cert, _ := reloader.getCertificate(nil)
+		return cert.Certificate[0]
+	}
+	// touch moves the files' modification times forward, so changes are seen even on file systems with coarse times
+	touch := func(d time.Duration) {
+		later := time.Now().Add(d)
+		os.Chtimes(certFile, later, later)
+		os.Chtimes(keyFile, later, later)
+	}
+	first := served()
+
+	// Rotating both files swaps in the new certificate
+	testCert(t, dir)
+	touch(time.Minute)
+	reloader.check()
+	second := served()
+	if bytes.Equal(first, second) {
+		t.Fatal("Certificate wasn't reloaded")
+	}
+
+	// A certificate that doesn't match the private key is refused, and the current one is kept
+	otherCert, _ := testCert(t, t.TempDir())
+	data, _ := ioutil.ReadFile(otherCert)
+	ioutil.WriteFile(certFile, data, 0644)
+	touch(time.Minute * 2)
+	reloader.check()
+	if !bytes.Equal(served(), second) {
+		t.Error("Invalid certificate pair replaced the current certificate")
+	}
+	if !bytes.Contains(log.Bytes(), []byte("Keeping the current certificate")) {
+		t.Errorf("Invalid certificate pair wasn't logged, got %q", log.String())
+	}
+}
+
+func TestCertRotation(t *testing.T) {
+	defer func(interval time.Duration) { certPollInterval = interval }(certPollInterval)
+	certPollInterval = time.Millisecond * 50
+	dir := t.TempDir()
+	certFile, keyFile := testCert(t, dir)
+	port := freePort(t, "tcp")
+	server := NewServer(&ServerSettings{
+		ServerName:    "!server!",
+		HostName:      "localhost",
+		Listeners:     []Listener{{IP: "localhost", Port: port, TLS: true, CertFile: certFile, PrivKeyFile: keyFile}},
+		AdminLogin:    "admin",
+		AdminPassword: "password"})
+	runTestServer(t, server)
+
+	handshake := func() []byte {
+		conn, err := tls.Dial("tcp", "localhost:"+strconv.Itoa(port), &tls.Config{InsecureSkipVerify: true})
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.ConnectionState().PeerCertificates[0].Raw
+	}
+	first := handshake()
+
+	// The Listener serves the new certificate once it's rotated on disk, without a restart
+	testCert(t, dir)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(certFile, later, later)
+	os.Chtimes(keyFile, later, later)
+	deadline := time.Now().Add(time.Second * 5)
+	for bytes.Equal(handshake(), first) {
+		if time.Now().After(deadline) {
+			t.Fatal("Listener didn't serve the rotated certificate")
+		}
+		time.Sleep(time.Millisecond * 50)
+	}
+}
@@ -1,10 +1,7 @@
 package gopher
 
 import (
-	"github.com/gorilla/websocket"
-	"github.com/hewiefreeman/GopherGameServer/actions"
 	"github.com/hewiefreeman/GopherGameServer/core"
-	"github.com/hewiefreeman/GopherGameServer/database"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
 	"sync"
 )
@@ -16,6 +13,7 @@ const (
 	errorFeatureDisabled             = "Server feature not enabled"
 	errorRoomControl                 = "Clients cannot control rooms"
 	errorServerRoom                  = "Clients cannot control that room type"
+	errorServerDraining              = "Server is shutting down"
 	errorNotOwner                    = "You are not the owner of the room"
 	errorRoomType                    = "Invalid room type"
 	errorIncorrectFormat             = "Incorrect data format"
@@ -33,79 +31,81 @@ const (
 	errorIncorrectFormatVarKey       = "Incorrect data format for variable key"
 )
 
-func clientActionHandler(action clientAction, user **core.User, conn *websocket.Conn,
+func (s *Server) clientActionHandler(action clientAction, user **core.User, conn core.ClientConn,
 	deviceTag *string, devicePass *string, deviceUserID *int, connID *string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	switch action.A {
 
-	// Custom actions and voice streams
+	// Custom actions, voice streams and the UDP channel
 
 	case helpers.ClientActionCustomAction:
-		return clientCustomAction(action.P, user, conn, *connID, clientMux)
+		return s.clientCustomAction(action.P, user, conn, *connID, clientMux)
 	case helpers.ClientActionVoiceStream:
-		return clientActionVoiceStream(action.P, user, conn, *connID, clientMux)
+		return s.clientActionVoiceStream(action.P, user, conn, *connID, clientMux)
+	case helpers.ClientActionUDPToken:
+		return s.clientActionUDPToken(user, conn, connID, clientMux)
 
 	// User variables
 
 	case helpers.ClientActionSetVariable:
-		return clientActionSetVariable(action.P, user, *connID, clientMux)
+		return s.clientActionSetVariable(action.P, user, *connID, clientMux)
 	case helpers.ClientActionSetVariables:
-		return clientActionSetVariables(action.P, user, *connID, clientMux)
+		return s.clientActionSetVariables(action.P, user, *connID, clientMux)
 
 	// Chat
 
 	case helpers.ClientActionChatMessage:
-		return clientActionChatMessage(action.P, user, *connID, clientMux)
+		return s.clientActionChatMessage(action.P, user, *connID, clientMux)
 	case helpers.ClientActionPrivateMessage:
-		return clientActionPrivateMessage(action.P, user, *connID, clientMux)
+		return s.clientActionPrivateMessage(action.P, user, *connID, clientMux)
 
 	// Change user status
 
 	case helpers.ClientActionChangeStatus:
-		return clientActionChangeStatus(action.P, user, clientMux)
+		return s.clientActionChangeStatus(action.P, user, clientMux)
 
 	// Log in/out
 
 	case helpers.ClientActionLogin:
-		return clientActionLogin(action.P, user, deviceTag, devicePass, deviceUserID, conn, connID, clientMux)
+		return s.clientActionLogin(action.P, user, deviceTag, devicePass, deviceUserID, conn, connID, clientMux)
 	case helpers.ClientActionLogout:
-		return clientActionLogout(user, deviceTag, devicePass, deviceUserID, connID, clientMux)
+		return s.clientActionLogout(user, deviceTag, devicePass, deviceUserID, connID, clientMux)
 
 	// Room actions
 
 	case helpers.ClientActionJoinRoom:
-		return clientActionJoinRoom(action.P, user, *connID, clientMux)
+		return s.clientActionJoinRoom(action.P, user, *connID, clientMux)
 	case helpers.ClientActionLeaveRoom:
-		return clientActionLeaveRoom(user, *connID, clientMux)
+		return s.clientActionLeaveRoom(user, *connID, clientMux)
 	case helpers.ClientActionCreateRoom:
-		return clientActionCreateRoom(action.P, user, *connID, clientMux)
+		return s.clientActionCreateRoom(action.P, user, *connID, clientMux)
 	case helpers.ClientActionDeleteRoom:
-		return clientActionDeleteRoom(action.P, user, clientMux)
+		return s.clientActionDeleteRoom(action.P, user, clientMux)
 	case helpers.ClientActionRoomInvite:
-		return clientActionRoomInvite(action.P, user, *connID, clientMux)
+		return s.clientActionRoomInvite(action.P, user, *connID, clientMux)
 	case helpers.ClientActionRevokeInvite:
-		return clientActionRevokeInvite(action.P, user, *connID, clientMux)
+		return s.clientActionRevokeInvite(action.P, user, *connID, clientMux)
 
 	// Friending
 
 	case helpers.ClientActionFriendRequest:
-		return clientActionFriendRequest(action.P, user, clientMux)
+		return s.clientActionFriendRequest(action.P, user, clientMux)
 	case helpers.ClientActionAcceptFriend:
-		return clientActionAcceptFriend(action.P, user, clientMux)
+		return s.clientActionAcceptFriend(action.P, user, clientMux)
 	case helpers.ClientActionDeclineFriend:
-		return clientActionDeclineFriend(action.P, user, clientMux)
+		return s.clientActionDeclineFriend(action.P, user, clientMux)
 	case helpers.ClientActionRemoveFriend:
-		return clientActionRemoveFriend(action.P, user, clientMux)
+		return s.clientActionRemoveFriend(action.P, user, clientMux)
 
 	// Database
 
 	case helpers.ClientActionSignup:
-		return clientActionSignup(action.P, user, clientMux)
+		return s.clientActionSignup(action.P, user, clientMux)
 	case helpers.ClientActionDeleteAccount:
-		return clientActionDeleteAccount(action.P, user, clientMux)
+		return s.clientActionDeleteAccount(action.P, user, clientMux)
 	case helpers.ClientActionChangePassword:
-		return clientActionChangePassword(action.P, user, clientMux)
+		return s.clientActionChangePassword(action.P, user, clientMux)
 	case helpers.ClientActionChangeAccountInfo:
-		return clientActionChangeAccountInfo(action.P, user, clientMux)
+		return s.clientActionChangeAccountInfo(action.P, user, clientMux)
 
 	// Invalid client action
 
@@ -118,7 +118,7 @@ func clientActionHandler(action clientAction, user **core.User, conn *websocket.
 //   CUSTOM CLIENT ACTIONS   /////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientCustomAction(params interface{}, user **core.User, conn *websocket.Conn, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientCustomAction(params interface{}, user **core.User, conn core.ClientConn, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	var ok bool
 	var pMap map[string]interface{}
 	var action string
@@ -131,7 +131,7 @@ func clientCustomAction(params interface{}, user **core.User, conn *websocket.Co
 	(*clientMux).Lock()
 	userRef := *user
 	(*clientMux).Unlock()
-	actions.HandleCustomClientAction(action, pMap["d"], userRef, conn, connID)
+	s.actions.HandleCustomClientAction(action, pMap["d"], userRef, conn, connID)
 	return nil, false, helpers.NoError()
 }
 
@@ -139,7 +139,7 @@ func clientCustomAction(params interface{}, user **core.User, conn *websocket.Co
 //   CHANGE USER STATUS   ////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionChangeStatus(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionChangeStatus(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -164,12 +164,12 @@ func clientActionChangeStatus(params interface{}, user **core.User, clientMux *s
 //   ACCOUNT/DATABASE ACTIONS   //////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionSignup(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionSignup(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user != nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorLoggedIn, helpers.ErrorGopherLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -195,7 +195,7 @@ func clientActionSignup(params interface{}, user **core.User, clientMux *sync.Mu
 		return nil, true, helpers.NewError(errorIncorrectFormatPass, helpers.ErrorGopherPasswordFormat)
 	}
 	// Sign client up
-	signupErr := database.SignUpClient(userName, pass, customCols)
+	signupErr := s.database.SignUpClient(userName, pass, customCols)
 	if signupErr.ID != 0 {
 		return nil, true, signupErr
 	}
@@ -204,12 +204,12 @@ func clientActionSignup(params interface{}, user **core.User, clientMux *sync.Mu
 	return nil, true, helpers.NoError()
 }
 
-func clientActionDeleteAccount(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionDeleteAccount(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user != nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorLoggedIn, helpers.ErrorGopherLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -236,13 +236,13 @@ func clientActionDeleteAccount(params interface{}, user **core.User, clientMux *
 	}
 
 	// Check if online
-	_, err := core.GetUser(userName)
+	_, err := s.core.GetUser(userName)
 	if err == nil {
 		return nil, true, helpers.NewError(err.Error(), helpers.ErrorGopherLoggedIn)
 	}
 
 	// Delete account
-	deleteErr := database.DeleteAccount(userName, pass, customCols)
+	deleteErr := s.database.DeleteAccount(userName, pass, customCols)
 	if deleteErr.ID != 0 {
 		return nil, true, deleteErr
 	}
@@ -250,12 +250,12 @@ func clientActionDeleteAccount(params interface{}, user **core.User, clientMux *
 	return nil, true, helpers.NoError()
 }
 
-func clientActionChangePassword(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionChangePassword(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -282,7 +282,7 @@ func clientActionChangePassword(params interface{}, user **core.User, clientMux
 		return nil, true, helpers.NewError(errorIncorrectFormatNewPass, helpers.ErrorGopherNewPasswordFormat)
 	}
 	// Change password
-	changeErr := database.ChangePassword(userRef.Name(), pass, newPass, customCols)
+	changeErr := s.database.ChangePassword(userRef.Name(), pass, newPass, customCols)
 	if changeErr.ID != 0 {
 		return nil, true, changeErr
 	}
@@ -291,12 +291,12 @@ func clientActionChangePassword(params interface{}, user **core.User, clientMux
 	return nil, true, helpers.NoError()
 }
 
-func clientActionChangeAccountInfo(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionChangeAccountInfo(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -319,7 +319,7 @@ func clientActionChangeAccountInfo(params interface{}, user **core.User, clientM
 		return nil, true, helpers.NewError(errorIncorrectFormatPass, helpers.ErrorGopherPasswordFormat)
 	}
 	// Change account info
-	changeErr := database.ChangeAccountInfo(userRef.Name(), pass, customCols)
+	changeErr := s.database.ChangeAccountInfo(userRef.Name(), pass, customCols)
 	if changeErr.ID != 0 {
 		return nil, true, changeErr
 	}
@@ -332,7 +332,7 @@ func clientActionChangeAccountInfo(params interface{}, user **core.User, clientM
 //   LOGIN+LOGOUT ACTIONS   //////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionLogin(params interface{}, user **core.User, deviceTag *string, devicePass *string, deviceUserID *int, conn *websocket.Conn,
+func (s *Server) clientActionLogin(params interface{}, user **core.User, deviceTag *string, devicePass *string, deviceUserID *int, conn core.ClientConn,
 	connID *string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user != nil {
@@ -354,11 +354,11 @@ func clientActionLogin(params interface{}, user **core.User, deviceTag *string,
 	if name, ok = pMap["n"].(string); !ok {
 		return nil, true, helpers.NewError(errorIncorrectFormatName, helpers.ErrorGopherNameFormat)
 	}
-	if settings.EnableSqlFeatures {
+	if s.getSettings().EnableSqlFeatures {
 		if pass, ok = pMap["p"].(string); !ok {
 			return nil, true, helpers.NewError(errorIncorrectFormatPass, helpers.ErrorGopherPasswordFormat)
 		}
-		if settings.RememberMe {
+		if s.getSettings().RememberMe {
 			if remMe, ok = pMap["r"].(bool); !ok {
 				return nil, true, helpers.NewError(errorIncorrectFormatRemember, helpers.ErrorGopherRememberFormat)
 			}
@@ -379,9 +379,9 @@ func clientActionLogin(params interface{}, user **core.User, deviceTag *string,
 	var dPass string
 	var cID string
 	var err helpers.GopherError
-	if dbIndex, dPass, cID, err = loginClient(settings, guest, name, pass, *deviceTag, remMe, customCols, user,
+	if dbIndex, dPass, cID, err = s.loginClient(guest, name, pass, *deviceTag, remMe, customCols, user,
 							conn, clientMux); err.ID != 0 {
-		return nil, false, err
+		return nil, true, err
 	}
 
 	// Update socket
@@ -393,27 +393,27 @@ func clientActionLogin(params interface{}, user **core.User, deviceTag *string,
 	return nil, false, helpers.NoError()
 }
 
-func loginClient(s *ServerSettings, guest bool, name string, pass string, deviceTag string, remMe bool,
-		customCols map[string]interface{}, user **core.User, conn *websocket.Conn, clientMux *sync.Mutex) (int, string, string, helpers.GopherError) {
+func (s *Server) loginClient(guest bool, name string, pass string, deviceTag string, remMe bool,
+		customCols map[string]interface{}, user **core.User, conn core.ClientConn, clientMux *sync.Mutex) (int, string, string, helpers.GopherError) {
 	var dbIndex int
 	var dPass string
 	var cID string
 	var err helpers.GopherError
-	if s.EnableSqlFeatures && !guest {
+	if s.getSettings().EnableSqlFeatures && !guest {
 		var uName string
-		uName, dbIndex, dPass, err = database.LoginClient(name, pass, deviceTag, remMe, customCols)
+		uName, dbIndex, dPass, err = s.database.LoginClient(name, pass, deviceTag, remMe, customCols)
 		if err.ID != 0 {
 			return 0, "", "", err
 		}
-		cID, err = core.Login(uName, dbIndex, dPass, guest, remMe, conn, user, clientMux)
+		cID, err = s.core.Login(uName, dbIndex, dPass, guest, remMe, conn, user, clientMux)
 	} else {
-		cID, err = core.Login(name, -1, "", guest, false, conn, user, clientMux)
+		cID, err = s.core.Login(name, -1, "", guest, false, conn, user, clientMux)
 	}
 
 	return dbIndex, dPass, cID, err
 }
 
-func clientActionLogout(user **core.User, deviceTag *string, devicePass *string, deviceUserID *int, connID *string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionLogout(user **core.User, deviceTag *string, devicePass *string, deviceUserID *int, connID *string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -424,8 +424,8 @@ func clientActionLogout(user **core.User, deviceTag *string, devicePass *string,
 	// Log user out
 	userRef.Logout(*connID)
 	// Remove any auto-logins for this device tag
-	if (*settings).EnableSqlFeatures && (*settings).RememberMe {
-		database.RemoveAutoLog(*deviceUserID, *deviceTag)
+	if settings := s.getSettings(); settings.EnableSqlFeatures && settings.RememberMe {
+		s.database.RemoveAutoLog(*deviceUserID, *deviceTag)
 	}
 	// Update socket
 	*devicePass = ""
@@ -440,7 +440,7 @@ func clientActionLogout(user **core.User, deviceTag *string, devicePass *string,
 //   ROOM ACTIONS   //////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionJoinRoom(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionJoinRoom(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -455,7 +455,7 @@ func clientActionJoinRoom(params interface{}, user **core.User, connID string, c
 		return nil, true, helpers.NewError(errorIncorrectFormat, helpers.ErrorGopherIncorrectFormat)
 	}
 	// Get room
-	room, roomErr := core.GetRoom(roomName)
+	room, roomErr := s.core.GetRoom(roomName)
 	if roomErr != nil {
 		return nil, true, helpers.NewError(roomErr.Error(), helpers.ErrorGopherJoin)
 	}
@@ -469,7 +469,7 @@ func clientActionJoinRoom(params interface{}, user **core.User, connID string, c
 	return nil, false, helpers.NoError()
 }
 
-func clientActionLeaveRoom(user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionLeaveRoom(user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -487,14 +487,17 @@ func clientActionLeaveRoom(user **core.User, connID string, clientMux *sync.Mute
 	return nil, false, helpers.NoError()
 }
 
-func clientActionCreateRoom(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionCreateRoom(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).UserRoomControl {
+	} else if !s.getSettings().UserRoomControl {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorRoomControl, helpers.ErrorGopherRoomControl)
+	} else if s.isDraining() {
+		(*clientMux).Unlock()
+		return nil, true, helpers.NewError(errorServerDraining, helpers.ErrorServerDraining)
 	}
 	userRef := *user
 	(*clientMux).Unlock()
@@ -522,13 +525,13 @@ func clientActionCreateRoom(params interface{}, user **core.User, connID string,
 	}
 	maxUsers := int(maxUsersF)
 	// Verify type
-	if rType, ok := core.GetRoomTypes()[roomType]; !ok {
+	if rType, ok := s.core.GetRoomTypes()[roomType]; !ok {
 		return nil, true, helpers.NewError(errorRoomType, helpers.ErrorGopherMaxRoomFormat)
 	} else if rType.ServerOnly() {
 		return nil, true, helpers.NewError(errorServerRoom, helpers.ErrorGopherServerRoom)
 	}
 	// Make the room
-	room, roomErr := core.NewRoom(roomName, roomType, private, maxUsers, userRef.Name())
+	room, roomErr := s.core.NewRoom(roomName, roomType, private, maxUsers, userRef.Name())
 	if roomErr != nil {
 		return nil, true, helpers.NewError(roomErr.Error(), helpers.ErrorGopherCreateRoom)
 	}
@@ -542,12 +545,12 @@ func clientActionCreateRoom(params interface{}, user **core.User, connID string,
 	return roomName, true, helpers.NoError()
 }
 
-func clientActionDeleteRoom(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionDeleteRoom(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).UserRoomControl {
+	} else if !s.getSettings().UserRoomControl {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorRoomControl, helpers.ErrorGopherRoomControl)
 	}
@@ -560,14 +563,14 @@ func clientActionDeleteRoom(params interface{}, user **core.User, clientMux *syn
 		return nil, true, helpers.NewError(errorIncorrectFormat, helpers.ErrorGopherIncorrectFormat)
 	}
 	// Get room
-	room, roomErr := core.GetRoom(roomName)
+	room, roomErr := s.core.GetRoom(roomName)
 	if roomErr != nil {
 		return nil, true, helpers.NewError(roomErr.Error(), helpers.ErrorGopherDeleteRoom)
 	} else if room.Owner() != userRef.Name() {
 		return nil, true, helpers.NewError(errorNotOwner, helpers.ErrorGopherNotOwner)
 	}
 	//
-	rType := core.GetRoomTypes()[room.Type()]
+	rType := s.core.GetRoomTypes()[room.Type()]
 	if rType.ServerOnly() {
 		return nil, true, helpers.NewError(errorServerRoom, helpers.ErrorGopherServerRoom)
 	}
@@ -580,12 +583,12 @@ func clientActionDeleteRoom(params interface{}, user **core.User, clientMux *syn
 	return roomName, true, helpers.NoError()
 }
 
-func clientActionRoomInvite(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionRoomInvite(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).UserRoomControl {
+	} else if !s.getSettings().UserRoomControl {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorRoomControl, helpers.ErrorGopherRoomControl)
 	}
@@ -598,7 +601,7 @@ func clientActionRoomInvite(params interface{}, user **core.User, connID string,
 		return nil, true, helpers.NewError(errorIncorrectFormat, helpers.ErrorGopherIncorrectFormat)
 	}
 	// Get invited user
-	invUser, invUserErr := core.GetUser(name)
+	invUser, invUserErr := s.core.GetUser(name)
 	if invUserErr != nil {
 		return nil, true, helpers.NewError(invUserErr.Error(), helpers.ErrorGopherInvite)
 	}
@@ -611,12 +614,12 @@ func clientActionRoomInvite(params interface{}, user **core.User, connID string,
 	return nil, true, helpers.NoError()
 }
 
-func clientActionRevokeInvite(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionRevokeInvite(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).UserRoomControl {
+	} else if !s.getSettings().UserRoomControl {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorRoomControl, helpers.ErrorGopherRoomControl)
 	}
@@ -641,7 +644,7 @@ func clientActionRevokeInvite(params interface{}, user **core.User, connID strin
 //   CHAT+VOICE ACTIONS   ////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionVoiceStream(params interface{}, user **core.User, conn *websocket.Conn, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionVoiceStream(params interface{}, user **core.User, conn core.ClientConn, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -655,7 +658,7 @@ func clientActionVoiceStream(params interface{}, user **core.User, conn *websock
 		return nil, false, helpers.NoError()
 	}
 	// Check for voice chat
-	rType := core.GetRoomTypes()[currRoom.Type()]
+	rType := s.core.GetRoomTypes()[currRoom.Type()]
 	if !rType.VoiceChatEnabled() {
 		return nil, false, helpers.NoError()
 	}
@@ -665,7 +668,7 @@ func clientActionVoiceStream(params interface{}, user **core.User, conn *websock
 	return nil, false, helpers.NoError()
 }
 
-func clientActionChatMessage(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionChatMessage(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -684,7 +687,7 @@ func clientActionChatMessage(params interface{}, user **core.User, connID string
 	return nil, false, helpers.NoError()
 }
 
-func clientActionPrivateMessage(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionPrivateMessage(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -712,7 +715,7 @@ func clientActionPrivateMessage(params interface{}, user **core.User, connID str
 //   USER VARIABLES   ////////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionSetVariable(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionSetVariable(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -738,7 +741,7 @@ func clientActionSetVariable(params interface{}, user **core.User, connID string
 	return nil, false, helpers.NoError()
 }
 
-func clientActionSetVariables(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionSetVariables(params interface{}, user **core.User, connID string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
@@ -762,12 +765,12 @@ func clientActionSetVariables(params interface{}, user **core.User, connID strin
 //   FRIENDING ACTIONS   /////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-func clientActionFriendRequest(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionFriendRequest(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -790,12 +793,12 @@ func clientActionFriendRequest(params interface{}, user **core.User, clientMux *
 	return nil, false, helpers.NoError()
 }
 
-func clientActionAcceptFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionAcceptFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -818,12 +821,12 @@ func clientActionAcceptFriend(params interface{}, user **core.User, clientMux *s
 	return nil, false, helpers.NoError()
 }
 
-func clientActionDeclineFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionDeclineFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -846,12 +849,12 @@ func clientActionDeclineFriend(params interface{}, user **core.User, clientMux *
 	return nil, false, helpers.NoError()
 }
 
-func clientActionRemoveFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+func (s *Server) clientActionRemoveFriend(params interface{}, user **core.User, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
 	(*clientMux).Lock()
 	if *user == nil {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
-	} else if !(*settings).EnableSqlFeatures {
+	} else if !s.getSettings().EnableSqlFeatures {
 		(*clientMux).Unlock()
 		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
 	}
@@ -0,0 +1,209 @@
+package gopher
+
+import (
+	"fmt"
+	"github.com/gorilla/websocket"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// consoleLogger is the Logger used by a Server and it's Instances. It passes every message on to the Logger set with SetLogger, and
+// streams them to any connected remote consoles.
+type consoleLogger struct {
+	next helpers.Logger
+	subs map[chan consoleLog]int // log channel -> lowest log level
+	mux  sync.Mutex
+}
+
+type consoleLog struct {
+	Time   string                 `json:"time"`
+	Level  string                 `json:"level"`
+	Msg    string                 `json:"msg"`
+	Fields map[string]interface{} `json:"fields,omitempty"`
+}
+
+type consoleCommand struct {
+	ID      interface{} `json:"id"`
+	Command string      `json:"c"`
+}
+
+type consoleResult struct {
+	ID     interface{} `json:"id"`
+	OK     bool        `json:"ok"`
+	Result interface{} `json:"result,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+func newConsoleLogger(next helpers.Logger) *consoleLogger {
+	return &consoleLogger{next: next, subs: make(map[chan consoleLog]int)}
+}
+
+func (l *consoleLogger) setNext(next helpers.Logger) {
+	l.mux.Lock()
+	l.next = next
+	l.mux.Unlock()
+}
+
+// Debug logs a debug message.
+func (l *consoleLogger) Debug(msg string, keyVals ...interface{}) {
+	l.log(helpers.LogLevelDebug, msg, keyVals)
+}
+
+// Info logs an informational message.
+func (l *consoleLogger) Info(msg string, keyVals ...interface{}) {
+	l.log(helpers.LogLevelInfo, msg, keyVals)
+}
+
+// Warn logs a warning message.
+func (l *consoleLogger) Warn(msg string, keyVals ...interface{}) {
+	l.log(helpers.LogLevelWarn, msg, keyVals)
+}
+
+// Error logs an error message.
+func (l *consoleLogger) Error(msg string, keyVals ...interface{}) {
+	l.log(helpers.LogLevelError, msg, keyVals)
+}
+
+func (l *consoleLogger) log(level int, msg string, keyVals []interface{}) {
+	l.mux.Lock()
+	next := l.next
+	var entry *consoleLog
+	for sub, subLevel := range l.subs {
+		if level < subLevel {
+			continue
+		}
+		if entry == nil {
+			entry = &consoleLog{Time: time.Now().Format(time.RFC3339), Level: logLevelName(level), Msg: msg}
+			for i := 0; i+1 < len(keyVals); i += 2 {
+				if entry.Fields == nil {
+					entry.Fields = make(map[string]interface{})
+				}
+				entry.Fields[fmt.Sprint(keyVals[i])] = fmt.Sprint(keyVals[i+1])
+			}
+		}
+		// Slow consoles miss log lines rather than holding up the server
+		select {
+		case sub <- *entry:
+		default:
+		}
+	}
+	l.mux.Unlock()
+
+	switch level {
+	case helpers.LogLevelDebug:
+		next.Debug(msg, keyVals...)
+	case helpers.LogLevelInfo:
+		next.Info(msg, keyVals...)
+	case helpers.LogLevelWarn:
+		next.Warn(msg, keyVals...)
+	default:
+		next.Error(msg, keyVals...)
+	}
+}
+
+func (l *consoleLogger) subscribe(level int) chan consoleLog {
+	sub := make(chan consoleLog, 64)
+	l.mux.Lock()
+	l.subs[sub] = level
+	l.mux.Unlock()
+	return sub
+}
+
+func (l *consoleLogger) unsubscribe(sub chan consoleLog) {
+	l.mux.Lock()
+	delete(l.subs, sub)
+	l.mux.Unlock()
+}
+
+func logLevelName(level int) string {
+	switch level {
+	case helpers.LogLevelDebug:
+		return "DEBUG"
+	case helpers.LogLevelInfo:
+		return "INFO"
+	case helpers.LogLevelWarn:
+		return "WARN"
+	}
+	return "ERROR"
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Remote console   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// adminConsole serves the remote admin console on the admin API's '/console' path. The console is a websocket that accepts
+// the same commands as the command-line macros, and streams the server's log lines back. See *Server.AdminHandler() for more details.
+func (s *Server) adminConsole(w http.ResponseWriter, r *http.Request, token string) {
+	level := helpers.LogLevelInfo
+	switch strings.ToLower(r.URL.Query().Get("log")) {
+	case "debug":
+		level = helpers.LogLevelDebug
+	case "warn":
+		level = helpers.LogLevelWarn
+	case "error":
+		level = helpers.LogLevelError
+	case "none":
+		level = helpers.LogLevelError + 1
+	}
+
+	conn, err := websocket.Upgrade(w, r, nil, 1024, 1024)
+	if err != nil {
+		return
+	}
+
+	var writeMux sync.Mutex
+	write := func(msg interface{}) error {
+		writeMux.Lock()
+		defer writeMux.Unlock()
+		return conn.WriteJSON(msg)
+	}
+
+	// Stream logs
+	logs := s.console.subscribe(level)
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case entry := <-logs:
+				if write(map[string]interface{}{"log": entry}) != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	defer func() {
+		s.console.unsubscribe(logs)
+		close(done)
+		conn.Close()
+	}()
+	s.logger.Info("Admin console connected", "address", r.RemoteAddr)
+
+	// Run commands
+	for {
+		var cmd consoleCommand
+		if err := conn.ReadJSON(&cmd); err != nil {
+			s.logger.Info("Admin console disconnected", "address", r.RemoteAddr)
+			return
+		}
+		if !s.admin.checkToken(token) {
+			write(consoleResult{ID: cmd.ID, Error: "Not logged in"})
+			return
+		}
+		result, shutdown, err := s.runMacro(cmd.Command)
+		if err != nil {
+			write(consoleResult{ID: cmd.ID, Error: err.Error()})
+			continue
+		}
+		write(consoleResult{ID: cmd.ID, OK: true, Result: result})
+		if shutdown != nil {
+			s.logger.Info("Admin console shut-down", "command", cmd.Command)
+			// The shut-down closes this console, so it can't run on this goroutine
+			go shutdown()
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package core
+
+import (
+	"errors"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   BANNING USERS   /////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Ban prevents a User from logging in by name for the given duration, or until the server restarts if the duration is 0. If the User is logged in,
+// they are kicked. Banning a User that is already banned replaces the old ban.
+func (i *Instance) Ban(userName string, duration time.Duration) error {
+	if len(userName) == 0 {
+		return errors.New("*Instance.Ban() requires a user name")
+	} else if duration < 0 {
+		return errors.New("*Instance.Ban() requires a positive duration")
+	}
+	var until time.Time
+	if duration > 0 {
+		until = time.Now().Add(duration)
+	}
+	i.bansMux.Lock()
+	i.bans[userName] = until
+	i.bansMux.Unlock()
+
+	// Kick the User if they're online
+	i.usersMux.Lock()
+	user, ok := i.users[userName]
+	i.usersMux.Unlock()
+	if ok {
+		user.Kick()
+	}
+
+	//
+	return nil
+}
+
+// Unban lets a banned User log in again.
+func (i *Instance) Unban(userName string) error {
+	i.bansMux.Lock()
+	defer i.bansMux.Unlock()
+	if _, ok := i.bans[userName]; !ok {
+		return errors.New("User '" + userName + "' is not banned")
+	}
+	delete(i.bans, userName)
+	return nil
+}
+
+// IsBanned returns true if the User name is banned from logging in.
+func (i *Instance) IsBanned(userName string) bool {
+	i.bansMux.Lock()
+	defer i.bansMux.Unlock()
+	until, ok := i.bans[userName]
+	if !ok {
+		return false
+	} else if !until.IsZero() && time.Now().After(until) {
+		// Ban is over
+		delete(i.bans, userName)
+		return false
+	}
+	return true
+}
+
+// GetBans gets a map of all the banned User names, and when their bans end. A zero time.Time means the ban lasts until the server restarts.
+func (i *Instance) GetBans() map[string]time.Time {
+	bans := make(map[string]time.Time)
+	i.bansMux.Lock()
+	for name, until := range i.bans {
+		if until.IsZero() || time.Now().Before(until) {
+			bans[name] = until
+		}
+	}
+	i.bansMux.Unlock()
+	return bans
+}
@@ -20,30 +20,99 @@
 package core
 
 import (
+	"github.com/hewiefreeman/GopherGameServer/database"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"sync"
+	"time"
 )
 
-var (
+// Instance holds all the Users, Rooms, RoomTypes and settings of a single server. The package-level functions (GetUser, NewRoom,
+// NewRoomType, etc.) all work on the default Instance, which is the one used by the server when started with gopher.Start().
+// Servers made with gopher.NewServer() get their own Instance, which you can retrieve with *Server.Core(), so two servers can run
+// side by side in one process without sharing any Users or Rooms.
+type Instance struct {
+	db      *database.Instance
+	logger  helpers.Logger
+	metrics *helpers.Metrics
+
+	unreliableSender func(ClientConn, interface{}) bool
+
+	// MUST LOCK settingsMux WHEN USING settings, serverStarted, draining AND serverPaused
+	settings      settings
 	serverStarted bool
+	draining      bool
 	serverPaused  bool
+	settingsMux   sync.RWMutex
+
+	users    map[string]*User
+	usersMux sync.Mutex
+
+	rooms    map[string]*Room
+	roomsMux sync.Mutex
+
+	roomTypes map[string]*RoomType
 
+	bans    map[string]time.Time // user name -> end of ban (zero time means forever)
+	bansMux sync.Mutex
+
+	// LoginCallback is only for internal Gopher Game Server mechanics.
+	LoginCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
+	// LogoutCallback is only for internal Gopher Game Server mechanics.
+	LogoutCallback func(string, int)
+
+	privateMessageCallback    func(*User, *User, interface{})
+	privateMessageCallbackSet bool
+	chatMessageCallback       func(string, *Room, interface{})
+	chatMessageCallbackSet    bool
+	serverMessageCallback     func(*Room, int, interface{})
+	serverMessageCallbackSet  bool
+}
+
+// settings are the ServerSettings an Instance uses. They can be updated while the server runs, so they're read with *Instance.getSettings().
+type settings struct {
 	serverName        string
 	kickOnLogin       bool
 	sqlFeatures       bool
 	rememberMe        bool
 	multiConnect      bool
 	maxUserConns      uint8
-	deleteRoomOnLeave bool = true
+	deleteRoomOnLeave bool
+}
+
+var (
+	defaultInstance *Instance = NewInstance(database.Default())
 )
 
 // RoomRecoveryState is used internally for persisting room states on shutdown.
 type RoomRecoveryState struct {
-	T string                 // rType
-	P bool                   // private
-	O string                 // owner
-	M int                    // maxUsers
-	I []string               // inviteList
-	V map[string]interface{} // vars
+	T string                 `json:"type"`     // rType
+	P bool                   `json:"private"`  // private
+	O string                 `json:"owner"`    // owner
+	M int                    `json:"maxUsers"` // maxUsers
+	I []string               `json:"invites"`  // inviteList
+	V map[string]interface{} `json:"vars"`     // vars
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   MAKE AN Instance   //////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewInstance makes a new Instance with no Users, Rooms, or RoomTypes. The database Instance is used for the
+// SQL features (friending, auto-login, etc.) when they are enabled.
+func NewInstance(db *database.Instance) *Instance {
+	return &Instance{
+		db:        db,
+		logger:    helpers.DefaultLogger(),
+		settings:  settings{deleteRoomOnLeave: true},
+		users:     make(map[string]*User),
+		rooms:     make(map[string]*Room),
+		roomTypes: make(map[string]*RoomType),
+		bans:      make(map[string]time.Time)}
+}
+
+// Default gets the default Instance, which all of the package-level functions work on.
+func Default() *Instance {
+	return defaultInstance
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -51,38 +120,92 @@ type RoomRecoveryState struct {
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // SetServerStarted is for Gopher Game Server internal mechanics only.
-func SetServerStarted(val bool) {
-	if !serverStarted {
-		serverStarted = val
-	}
+func (i *Instance) SetServerStarted(val bool) {
+	i.settingsMux.Lock()
+	i.serverStarted = val
+	i.settingsMux.Unlock()
 }
 
 // SettingsSet is for Gopher Game Server internal mechanics only.
-func SettingsSet(kickDups bool, name string, deleteOnLeave bool, sqlFeat bool, remMe bool, multiConn bool, maxConns uint8) {
-	if !serverStarted {
-		kickOnLogin = kickDups
-		serverName = name
-		sqlFeatures = sqlFeat
-		rememberMe = remMe
-		multiConnect = multiConn
-		maxUserConns = maxConns
-		deleteRoomOnLeave = deleteOnLeave
+func (i *Instance) SettingsSet(kickDups bool, name string, deleteOnLeave bool, sqlFeat bool, remMe bool, multiConn bool, maxConns uint8) {
+	i.settingsMux.Lock()
+	defer i.settingsMux.Unlock()
+	if !i.serverStarted {
+		i.settings = settings{
+			serverName:        name,
+			kickOnLogin:       kickDups,
+			sqlFeatures:       sqlFeat,
+			rememberMe:        remMe,
+			multiConnect:      multiConn,
+			maxUserConns:      maxConns,
+			deleteRoomOnLeave: deleteOnLeave}
 	}
 }
 
+func (i *Instance) getSettings() settings {
+	i.settingsMux.RLock()
+	defer i.settingsMux.RUnlock()
+	return i.settings
+}
+
+// SetLogger is for Gopher Game Server internal mechanics only.
+func (i *Instance) SetLogger(l helpers.Logger) {
+	i.logger = l
+}
+
+// SetMetrics is for Gopher Game Server internal mechanics only.
+func (i *Instance) SetMetrics(m *helpers.Metrics) {
+	i.metrics = m
+}
+
+// SetUnreliableSender is for Gopher Game Server internal mechanics only.
+func (i *Instance) SetUnreliableSender(sender func(ClientConn, interface{}) bool) {
+	i.unreliableSender = sender
+}
+
+// SettingsUpdate is for Gopher Game Server internal mechanics only.
+func (i *Instance) SettingsUpdate(kickDups bool, deleteOnLeave bool, maxConns uint8) {
+	i.settingsMux.Lock()
+	i.settings.kickOnLogin = kickDups
+	i.settings.deleteRoomOnLeave = deleteOnLeave
+	i.settings.maxUserConns = maxConns
+	i.settingsMux.Unlock()
+}
+
+// writeMessage sends a message to a client, and logs the error if it fails.
+func (i *Instance) writeMessage(socket ClientConn, message interface{}) {
+	if err := socket.Send(message); err != nil {
+		i.logger.Warn("Error sending message to client", "address", socket.RemoteAddr(), "error", err)
+		return
+	}
+	i.metrics.Inc(helpers.MetricMessagesSent, "type", helpers.MessageType(message))
+}
+
+// writeUnreliable sends a message over the client's unreliable channel, or with writeMessage when the client doesn't have one.
+func (i *Instance) writeUnreliable(socket ClientConn, message interface{}) {
+	if i.unreliableSender == nil || !i.unreliableSender(socket, message) {
+		i.writeMessage(socket, message)
+		return
+	}
+	i.metrics.Inc(helpers.MetricMessagesSent, "type", helpers.MessageType(message))
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 //   SERVER PAUSE AND RESUME   ///////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // Pause is only for internal Gopher Game Server mechanics.
-func Pause() {
-	if !serverPaused {
-		serverPaused = true
+func (i *Instance) Pause() {
+	i.settingsMux.Lock()
+	wasPaused := i.serverPaused
+	i.serverPaused = true
+	i.settingsMux.Unlock()
+	if !wasPaused {
 
 		//
 		clientResp := helpers.MakeClientResponse(helpers.ClientActionLogout, nil, helpers.NoError())
-		usersMux.Lock()
-		for _, user := range users {
+		i.usersMux.Lock()
+		for _, user := range i.users {
 			user.mux.Lock()
 			for connID, conn := range user.conns {
 				//REMOVE CONNECTION FROM THEIR ROOM
@@ -101,20 +224,45 @@ func Pause() {
 				conn.clientMux.Unlock()
 
 				//SEND LOG OUT MESSAGE
-				conn.socket.WriteJSON(clientResp)
+				i.writeMessage(conn.socket, clientResp)
 			}
 			user.mux.Unlock()
 		}
-		users = make(map[string]*User)
-		usersMux.Unlock()
+		i.users = make(map[string]*User)
+		i.usersMux.Unlock()
 	}
 }
 
 // Resume is only for internal Gopher Game Server mechanics.
-func Resume() {
-	if serverPaused {
-		serverPaused = false
-	}
+func (i *Instance) Resume() {
+	i.settingsMux.Lock()
+	i.serverPaused = false
+	i.settingsMux.Unlock()
+}
+
+// SetDraining is only for internal Gopher Game Server mechanics.
+func (i *Instance) SetDraining(val bool) {
+	i.settingsMux.Lock()
+	i.draining = val
+	i.settingsMux.Unlock()
+}
+
+func (i *Instance) isStarted() bool {
+	i.settingsMux.RLock()
+	defer i.settingsMux.RUnlock()
+	return i.serverStarted
+}
+
+func (i *Instance) isDraining() bool {
+	i.settingsMux.RLock()
+	defer i.settingsMux.RUnlock()
+	return i.draining
+}
+
+func (i *Instance) isPaused() bool {
+	i.settingsMux.RLock()
+	defer i.settingsMux.RUnlock()
+	return i.serverPaused
 }
 
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -122,22 +270,27 @@ func Resume() {
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetRoomsState is only for internal Gopher Game Server mechanics.
-func GetRoomsState() map[string]RoomRecoveryState {
+func (i *Instance) GetRoomsState() map[string]RoomRecoveryState {
 	state := make(map[string]RoomRecoveryState)
-	roomsMux.Lock()
-	for _, room := range rooms {
+	i.roomsMux.Lock()
+	for _, room := range i.rooms {
 		room.mux.Lock()
+		// COPY SO THE STATE CAN BE READ WITHOUT LOCKING
+		vars := make(map[string]interface{}, len(room.vars))
+		for key, val := range room.vars {
+			vars[key] = val
+		}
 		state[room.name] = RoomRecoveryState{
 			T: room.rType,
 			P: room.private,
 			O: room.owner,
 			M: room.maxUsers,
-			I: room.inviteList,
-			V: room.vars,
+			I: append([]string(nil), room.inviteList...),
+			V: vars,
 		}
 		room.mux.Unlock()
 	}
-	roomsMux.Unlock()
+	i.roomsMux.Unlock()
 	//
 	return state
 }
@@ -0,0 +1,151 @@
+package core
+
+import (
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"sync"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   DEFAULT Instance   //////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewRoomType adds a RoomType to the default Instance. See Instance.NewRoomType for details.
+func NewRoomType(name string, serverOnly bool) *RoomType {
+	return defaultInstance.NewRoomType(name, serverOnly)
+}
+
+// GetRoomTypes gets a map of all the RoomTypes on the default Instance.
+func GetRoomTypes() map[string]*RoomType {
+	return defaultInstance.GetRoomTypes()
+}
+
+// NewRoom adds a new room to the default Instance. See Instance.NewRoom for details.
+func NewRoom(name string, rType string, isPrivate bool, maxUsers int, owner string) (*Room, error) {
+	return defaultInstance.NewRoom(name, rType, isPrivate, maxUsers, owner)
+}
+
+// GetRoom finds a Room on the default Instance. If the room does not exit, an error will be returned.
+func GetRoom(roomName string) (*Room, error) {
+	return defaultInstance.GetRoom(roomName)
+}
+
+// GetRooms gets a map of all the Rooms on the default Instance, where the key string is the Room's name.
+func GetRooms() map[string]*Room {
+	return defaultInstance.GetRooms()
+}
+
+// RoomCount returns the number of Rooms created on the default Instance.
+func RoomCount() int {
+	return defaultInstance.RoomCount()
+}
+
+// ActiveRoomCount returns the number of Rooms on the default Instance that have at least one User in them.
+func ActiveRoomCount() int {
+	return defaultInstance.ActiveRoomCount()
+}
+
+// RoomCountByType returns the number of Rooms on the default Instance for each RoomType name.
+func RoomCountByType() map[string]int {
+	return defaultInstance.RoomCountByType()
+}
+
+// GuestCount returns the number of guest Users logged into the default Instance.
+func GuestCount() int {
+	return defaultInstance.GuestCount()
+}
+
+// GetUser finds a logged in User by their name on the default Instance. Returns an error if the User is not online.
+func GetUser(userName string) (*User, error) {
+	return defaultInstance.GetUser(userName)
+}
+
+// GetUsers gets a map of all the Users logged into the default Instance, where the key string is the User's name.
+func GetUsers() map[string]*User {
+	return defaultInstance.GetUsers()
+}
+
+// Ban prevents a User from logging in to the default Instance. See *Instance.Ban() for more details.
+func Ban(userName string, duration time.Duration) error {
+	return defaultInstance.Ban(userName, duration)
+}
+
+// Unban lets a banned User log in to the default Instance again.
+func Unban(userName string) error {
+	return defaultInstance.Unban(userName)
+}
+
+// IsBanned returns true if the User name is banned from logging in to the default Instance.
+func IsBanned(userName string) bool {
+	return defaultInstance.IsBanned(userName)
+}
+
+// GetBans gets a map of all the User names banned from the default Instance, and when their bans end.
+func GetBans() map[string]time.Time {
+	return defaultInstance.GetBans()
+}
+
+// UserCount returns the number of Users logged into the default Instance.
+func UserCount() int {
+	return defaultInstance.UserCount()
+}
+
+// SetPrivateMessageCallback sets the callback function for when a *User sends a private message to another *User
+// on the default Instance. See Instance.SetPrivateMessageCallback for details.
+func SetPrivateMessageCallback(cb func(*User, *User, interface{})) {
+	defaultInstance.SetPrivateMessageCallback(cb)
+}
+
+// SetChatMessageCallback sets the callback function for when a *User sends a chat message to a *Room
+// on the default Instance. See Instance.SetChatMessageCallback for details.
+func SetChatMessageCallback(cb func(string, *Room, interface{})) {
+	defaultInstance.SetChatMessageCallback(cb)
+}
+
+// SetServerMessageCallback sets the callback function for when the server sends a message to a *Room
+// on the default Instance. See Instance.SetServerMessageCallback for details.
+func SetServerMessageCallback(cb func(*Room, int, interface{})) {
+	defaultInstance.SetServerMessageCallback(cb)
+}
+
+// SetServerStarted is for Gopher Game Server internal mechanics only.
+func SetServerStarted(val bool) {
+	defaultInstance.SetServerStarted(val)
+}
+
+// SettingsSet is for Gopher Game Server internal mechanics only.
+func SettingsSet(kickDups bool, name string, deleteOnLeave bool, sqlFeat bool, remMe bool, multiConn bool, maxConns uint8) {
+	defaultInstance.SettingsSet(kickDups, name, deleteOnLeave, sqlFeat, remMe, multiConn, maxConns)
+}
+
+// Pause is only for internal Gopher Game Server mechanics.
+func Pause() {
+	defaultInstance.Pause()
+}
+
+// Resume is only for internal Gopher Game Server mechanics.
+func Resume() {
+	defaultInstance.Resume()
+}
+
+// GetRoomsState is only for internal Gopher Game Server mechanics.
+func GetRoomsState() map[string]RoomRecoveryState {
+	return defaultInstance.GetRoomsState()
+}
+
+// Login logs a User in to the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to log in.
+func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bool, socket ClientConn,
+	connUser **User, clientMux *sync.Mutex) (string, helpers.GopherError) {
+	return defaultInstance.Login(userName, dbID, autologPass, isGuest, remMe, socket, connUser, clientMux)
+}
+
+// AutoLogIn logs a User in to the default Instance with the "Remember Me" feature.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. If you want the "Remember Me"
+// (AKA auto login) feature, enable it in ServerSettings along with the SqlFeatures and corresponding
+// options.
+func AutoLogIn(tag string, pass string, newPass string, dbID int, conn ClientConn, connUser **User, clientMux *sync.Mutex) (string, helpers.GopherError) {
+	return defaultInstance.AutoLogIn(tag, pass, newPass, dbID, conn, connUser, clientMux)
+}
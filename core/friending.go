@@ -16,12 +16,12 @@ func (u *User) FriendRequest(friendName string) error {
 		return errors.New("The user '" + friendName + "' cannot be requested as a friend")
 	}
 	//CHECK IF FRIEND IS ONLINE & GET DATABASE ID
-	friend, friendErr := GetUser(friendName)
+	friend, friendErr := u.inst.GetUser(friendName)
 	var friendOnline bool = false
 	var friendID int
 	if friendErr != nil {
 		//GET FRIEND'S DATABASE ID FROM database PACKAGE
-		friendID, friendErr = database.GetUserDatabaseIndex(friendName)
+		friendID, friendErr = u.inst.db.GetUserDatabaseIndex(friendName)
 		if friendErr != nil {
 			return errors.New("The user '" + friendName + "' does not exist")
 		}
@@ -43,7 +43,7 @@ func (u *User) FriendRequest(friendName string) error {
 	}
 
 	//MAKE THE FRIEND REQUEST ON DATABASE
-	friendingErr := database.FriendRequest(u.databaseID, friendID)
+	friendingErr := u.inst.db.FriendRequest(u.databaseID, friendID)
 	if friendingErr != nil {
 		return errors.New("Unexpected friend error")
 	}
@@ -57,7 +57,7 @@ func (u *User) FriendRequest(friendName string) error {
 		}
 		friend.mux.Lock()
 		for _, conn := range friend.conns {
-			(*conn).socket.WriteJSON(message)
+			u.inst.writeMessage((*conn).socket, message)
 		}
 		friend.mux.Unlock()
 	}
@@ -66,7 +66,7 @@ func (u *User) FriendRequest(friendName string) error {
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionFriendRequest, friendName, helpers.NoError())
 	u.mux.Lock()
 	for _, conn := range u.conns {
-		(*conn).socket.WriteJSON(clientResp)
+		u.inst.writeMessage((*conn).socket, clientResp)
 	}
 	u.mux.Unlock()
 
@@ -86,12 +86,12 @@ func (u *User) AcceptFriendRequest(friendName string) error {
 		return errors.New("The user '" + friendName + "' cannot be accepted as a friend")
 	}
 	//CHECK IF FRIEND IS ONLINE & GET DATABASE ID
-	friend, friendErr := GetUser(friendName)
+	friend, friendErr := u.inst.GetUser(friendName)
 	var friendOnline bool = false
 	var friendID int
 	if friendErr != nil {
 		//GET FRIEND'S DATABASE ID FROM database PACKAGE
-		friendID, friendErr = database.GetUserDatabaseIndex(friendName)
+		friendID, friendErr = u.inst.db.GetUserDatabaseIndex(friendName)
 		if friendErr != nil {
 			return errors.New("The user '" + friendName + "' does not exist")
 		}
@@ -111,7 +111,7 @@ func (u *User) AcceptFriendRequest(friendName string) error {
 		friend.mux.Unlock()
 	}
 	//UPDATE FRIENDS ON DATABASE
-	friendingErr := database.FriendRequestAccepted(u.databaseID, friendID)
+	friendingErr := u.inst.db.FriendRequestAccepted(u.databaseID, friendID)
 	if friendingErr != nil {
 		return errors.New("Unexpected friend error")
 	}
@@ -127,7 +127,7 @@ func (u *User) AcceptFriendRequest(friendName string) error {
 		}
 		friend.mux.Lock()
 		for _, conn := range friend.conns {
-			(*conn).socket.WriteJSON(message)
+			u.inst.writeMessage((*conn).socket, message)
 		}
 		fStatus = friend.status
 		friend.mux.Unlock()
@@ -143,7 +143,7 @@ func (u *User) AcceptFriendRequest(friendName string) error {
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionAcceptFriend, responseMap, helpers.NoError())
 	u.mux.Lock()
 	for _, conn := range u.conns {
-		(*conn).socket.WriteJSON(clientResp)
+		u.inst.writeMessage((*conn).socket, clientResp)
 	}
 	u.mux.Unlock()
 
@@ -163,12 +163,12 @@ func (u *User) DeclineFriendRequest(friendName string) error {
 		return errors.New("The user '" + friendName + "' cannot be declined as a friend")
 	}
 	//CHECK IF FRIEND IS ONLINE & GET DATABASE ID
-	friend, friendErr := GetUser(friendName)
+	friend, friendErr := u.inst.GetUser(friendName)
 	var friendOnline bool = false
 	var friendID int
 	if friendErr != nil {
 		//GET FRIEND'S DATABASE ID FROM database PACKAGE
-		friendID, friendErr = database.GetUserDatabaseIndex(friendName)
+		friendID, friendErr = u.inst.db.GetUserDatabaseIndex(friendName)
 		if friendErr != nil {
 			return errors.New("The user '" + friendName + "' does not exist")
 		}
@@ -189,7 +189,7 @@ func (u *User) DeclineFriendRequest(friendName string) error {
 	}
 
 	//UPDATE FRIENDS ON DATABASE
-	removeErr := database.RemoveFriend(u.databaseID, friendID)
+	removeErr := u.inst.db.RemoveFriend(u.databaseID, friendID)
 	if removeErr != nil {
 		return errors.New("Unexpected friend error")
 	}
@@ -203,7 +203,7 @@ func (u *User) DeclineFriendRequest(friendName string) error {
 		}
 		friend.mux.Lock()
 		for _, conn := range friend.conns {
-			(*conn).socket.WriteJSON(message)
+			u.inst.writeMessage((*conn).socket, message)
 		}
 		friend.mux.Unlock()
 	}
@@ -212,7 +212,7 @@ func (u *User) DeclineFriendRequest(friendName string) error {
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionDeclineFriend, friendName, helpers.NoError())
 	u.mux.Lock()
 	for _, conn := range u.conns {
-		(*conn).socket.WriteJSON(clientResp)
+		u.inst.writeMessage((*conn).socket, clientResp)
 	}
 	u.mux.Unlock()
 
@@ -232,12 +232,12 @@ func (u *User) RemoveFriend(friendName string) error {
 		return errors.New("The user '" + friendName + "' cannot be removed as a friend")
 	}
 	//CHECK IF FRIEND IS ONLINE & GET DATABASE ID
-	friend, friendErr := GetUser(friendName)
+	friend, friendErr := u.inst.GetUser(friendName)
 	var friendOnline bool = false
 	var friendID int
 	if friendErr != nil {
 		//GET FRIEND'S DATABASE ID FROM database PACKAGE
-		friendID, friendErr = database.GetUserDatabaseIndex(friendName)
+		friendID, friendErr = u.inst.db.GetUserDatabaseIndex(friendName)
 		if friendErr != nil {
 			return errors.New("The user '" + friendName + "' does not exist")
 		}
@@ -258,7 +258,7 @@ func (u *User) RemoveFriend(friendName string) error {
 	}
 
 	//UPDATE FRIENDS ON DATABASE
-	removeErr := database.RemoveFriend(u.databaseID, friendID)
+	removeErr := u.inst.db.RemoveFriend(u.databaseID, friendID)
 	if removeErr != nil {
 		return errors.New("Unexpected friend error")
 	}
@@ -272,7 +272,7 @@ func (u *User) RemoveFriend(friendName string) error {
 		}
 		friend.mux.Lock()
 		for _, conn := range friend.conns {
-			(*conn).socket.WriteJSON(message)
+			u.inst.writeMessage((*conn).socket, message)
 		}
 		friend.mux.Unlock()
 	}
@@ -281,7 +281,7 @@ func (u *User) RemoveFriend(friendName string) error {
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionRemoveFriend, friendName, helpers.NoError())
 	u.mux.Lock()
 	for _, conn := range u.conns {
-		(*conn).socket.WriteJSON(clientResp)
+		u.inst.writeMessage((*conn).socket, clientResp)
 	}
 	u.mux.Unlock()
 
@@ -296,11 +296,11 @@ func (u *User) RemoveFriend(friendName string) error {
 func (u *User) sendToFriends(message interface{}) {
 	for key, val := range u.friends {
 		if val.RequestStatus() == database.FriendStatusAccepted {
-			friend, friendErr := GetUser(key)
+			friend, friendErr := u.inst.GetUser(key)
 			if friendErr == nil {
 				friend.mux.Lock()
 				for _, friendConn := range friend.conns {
-					(*friendConn).socket.WriteJSON(message)
+					u.inst.writeMessage((*friendConn).socket, message)
 				}
 				friend.mux.Unlock()
 			}
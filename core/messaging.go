@@ -2,7 +2,6 @@ package core
 
 import (
 	"errors"
-	"github.com/gorilla/websocket"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
 )
 
@@ -19,22 +18,13 @@ const (
 	ServerMessageImportant
 )
 
-var (
-	privateMessageCallback    func(*User, *User, interface{})
-	privateMessageCallbackSet bool
-	chatMessageCallback       func(string, *Room, interface{})
-	chatMessageCallbackSet    bool
-	serverMessageCallback     func(*Room, int, interface{})
-	serverMessageCallbackSet  bool
-)
-
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 //   Messaging Users   ///////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // PrivateMessage sends a private message to another User by name.
 func (u *User) PrivateMessage(userName string, message interface{}) {
-	user, userErr := GetUser(userName)
+	user, userErr := u.inst.GetUser(userName)
 	if userErr != nil {
 		return
 	}
@@ -51,17 +41,17 @@ func (u *User) PrivateMessage(userName string, message interface{}) {
 	//SEND MESSAGES
 	user.mux.Lock()
 	for _, conn := range user.conns {
-		(*conn).socket.WriteJSON(theMessage)
+		u.inst.writeMessage((*conn).socket, theMessage)
 	}
 	user.mux.Unlock()
 	u.mux.Lock()
 	for _, conn := range u.conns {
-		(*conn).socket.WriteJSON(theMessage)
+		u.inst.writeMessage((*conn).socket, theMessage)
 	}
 	u.mux.Unlock()
 
-	if privateMessageCallbackSet {
-		privateMessageCallback(u, user, message)
+	if u.inst.privateMessageCallbackSet {
+		u.inst.privateMessageCallback(u, user, message)
 	}
 
 	return
@@ -69,20 +59,35 @@ func (u *User) PrivateMessage(userName string, message interface{}) {
 
 // DataMessage sends a data message directly to the User.
 func (u *User) DataMessage(data interface{}, connID string) {
+	u.dataMessage(data, connID, false)
+}
+
+// UnreliableDataMessage sends a data message directly to the User over their unreliable UDP channel, for realtime state (ex: positions)
+// that is fine to lose, and would only be out of date if it arrived late. Clients without a UDP channel get the message over their
+// normal connection instead, like with *User.DataMessage().
+func (u *User) UnreliableDataMessage(data interface{}, connID string) {
+	u.dataMessage(data, connID, true)
+}
+
+func (u *User) dataMessage(data interface{}, connID string, unreliable bool) {
 	//CONSTRUCT MESSAGE
 	message := map[string]interface{}{
 		helpers.ServerActionDataMessage: data,
 	}
 
 	//SEND MESSAGE TO USER
+	send := u.inst.writeMessage
+	if unreliable {
+		send = u.inst.writeUnreliable
+	}
 	u.mux.Lock()
 	if connID == "" {
 		for _, conn := range u.conns {
-			(*conn).socket.WriteJSON(message)
+			send((*conn).socket, message)
 		}
 	} else {
 		if conn, ok := u.conns[connID]; ok {
-			(*conn).socket.WriteJSON(message)
+			send((*conn).socket, message)
 		}
 	}
 	u.mux.Unlock()
@@ -99,8 +104,8 @@ func (r *Room) ServerMessage(message interface{}, messageType int, recipients []
 		return errors.New("*Room.ServerMessage() requires a message")
 	}
 
-	if serverMessageCallbackSet {
-		serverMessageCallback(r, messageType, message)
+	if r.inst.serverMessageCallbackSet {
+		r.inst.serverMessageCallback(r, messageType, message)
 	}
 
 	return r.sendMessage(MessageTypeServer, messageType, recipients, "", message)
@@ -115,8 +120,8 @@ func (r *Room) ChatMessage(author string, message interface{}) error {
 		return errors.New("*Room.ChatMessage() requires a message")
 	}
 
-	if chatMessageCallbackSet {
-		chatMessageCallback(author, r, message)
+	if r.inst.chatMessageCallbackSet {
+		r.inst.chatMessageCallback(author, r, message)
 	}
 
 	return r.sendMessage(MessageTypeChat, 0, nil, author, message)
@@ -125,6 +130,17 @@ func (r *Room) ChatMessage(author string, message interface{}) error {
 // DataMessage sends a data message to the specified recipients in the Room. The parameter recipients can be nil or an empty slice
 // of string. In which case, the data message will be sent to all Users in the Room.
 func (r *Room) DataMessage(message interface{}, recipients []string) error {
+	return r.dataMessage(message, recipients, false)
+}
+
+// UnreliableDataMessage sends a data message to the specified recipients in the Room over their unreliable UDP channels. Use it for
+// realtime state (ex: movement updates), so one lost packet doesn't hold up every message after it. Chat and Room management stay
+// on the normal connection. Recipients without a UDP channel get the message over their normal connection instead.
+func (r *Room) UnreliableDataMessage(message interface{}, recipients []string) error {
+	return r.dataMessage(message, recipients, true)
+}
+
+func (r *Room) dataMessage(message interface{}, recipients []string, unreliable bool) error {
 	//GET USER MAP
 	userMap, err := r.GetUserMap()
 	if err != nil {
@@ -137,11 +153,15 @@ func (r *Room) DataMessage(message interface{}, recipients []string) error {
 	}
 
 	//SEND MESSAGE TO USERS
+	send := r.inst.writeMessage
+	if unreliable {
+		send = r.inst.writeUnreliable
+	}
 	if recipients == nil || len(recipients) == 0 {
 		for _, u := range userMap {
 			u.mux.Lock()
 			for _, conn := range u.conns {
-				conn.socket.WriteJSON(theMessage)
+				send(conn.socket, theMessage)
 			}
 			u.mux.Unlock()
 		}
@@ -150,7 +170,7 @@ func (r *Room) DataMessage(message interface{}, recipients []string) error {
 			if u, ok := userMap[recipients[i]]; ok {
 				u.mux.Lock()
 				for _, conn := range u.conns {
-					conn.socket.WriteJSON(theMessage)
+					send(conn.socket, theMessage)
 				}
 				u.mux.Unlock()
 			}
@@ -188,7 +208,7 @@ func (r *Room) sendMessage(mt int, st int, rec []string, a string, m interface{}
 		for _, u := range userMap {
 			u.mux.Lock()
 			for _, conn := range u.conns {
-				conn.socket.WriteJSON(message)
+				r.inst.writeMessage(conn.socket, message)
 			}
 			u.mux.Unlock()
 		}
@@ -197,7 +217,7 @@ func (r *Room) sendMessage(mt int, st int, rec []string, a string, m interface{}
 			if u, ok := userMap[rec[i]]; ok {
 				u.mux.Lock()
 				for _, conn := range u.conns {
-					conn.socket.WriteJSON(message)
+					r.inst.writeMessage(conn.socket, message)
 				}
 				u.mux.Unlock()
 			}
@@ -212,7 +232,7 @@ func (r *Room) sendMessage(mt int, st int, rec []string, a string, m interface{}
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // VoiceStream sends a voice stream from the client API to all the users in the room besides the user who is speaking.
-func (r *Room) VoiceStream(userName string, userSocket *websocket.Conn, stream interface{}) {
+func (r *Room) VoiceStream(userName string, userSocket ClientConn, stream interface{}) {
 	//GET USER MAP
 	userMap, err := r.GetUserMap()
 	if err != nil {
@@ -233,7 +253,7 @@ func (r *Room) VoiceStream(userName string, userSocket *websocket.Conn, stream i
 	//SEND MESSAGE TO USERS
 	for _, u := range userMap {
 		for _, conn := range u.conns {
-			(*conn).socket.WriteJSON(theMessage)
+			r.inst.writeMessage((*conn).socket, theMessage)
 		}
 	}
 
@@ -243,7 +263,7 @@ func (r *Room) VoiceStream(userName string, userSocket *websocket.Conn, stream i
 	}
 
 	//SEND PING MESSAGE TO SENDING USER
-	userSocket.WriteJSON(pingMessage)
+	r.inst.writeMessage(userSocket, pingMessage)
 
 	//
 	return
@@ -259,10 +279,10 @@ func (r *Room) VoiceStream(userName string, userSocket *websocket.Conn, stream i
 //    func onPrivateMessage(from *core.User, to *core.User, message interface{}) {
 //	     //code...
 //	 }
-func SetPrivateMessageCallback(cb func(*User, *User, interface{})) {
-	if !serverStarted {
-		privateMessageCallback = cb
-		privateMessageCallbackSet = true
+func (i *Instance) SetPrivateMessageCallback(cb func(*User, *User, interface{})) {
+	if !i.isStarted() {
+		i.privateMessageCallback = cb
+		i.privateMessageCallbackSet = true
 	}
 
 }
@@ -273,10 +293,10 @@ func SetPrivateMessageCallback(cb func(*User, *User, interface{})) {
 //    func onChatMessage(userName string, room *core.Room, message interface{}) {
 //	     //code...
 //	 }
-func SetChatMessageCallback(cb func(string, *Room, interface{})) {
-	if !serverStarted {
-		chatMessageCallback = cb
-		chatMessageCallbackSet = true
+func (i *Instance) SetChatMessageCallback(cb func(string, *Room, interface{})) {
+	if !i.isStarted() {
+		i.chatMessageCallback = cb
+		i.chatMessageCallbackSet = true
 	}
 }
 
@@ -289,9 +309,9 @@ func SetChatMessageCallback(cb func(string, *Room, interface{})) {
 //
 // The messageType value can be one of: core.ServerMessageGame, core.ServerMessageNotice,
 // core.ServerMessageImportant, or a custom value you have set.
-func SetServerMessageCallback(cb func(*Room, int, interface{})) {
-	if !serverStarted {
-		serverMessageCallback = cb
-		serverMessageCallbackSet = true
+func (i *Instance) SetServerMessageCallback(cb func(*Room, int, interface{})) {
+	if !i.isStarted() {
+		i.serverMessageCallback = cb
+		i.serverMessageCallbackSet = true
 	}
 }
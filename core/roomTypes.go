@@ -1,13 +1,11 @@
 package core
 
-var (
-	roomTypes = make(map[string]*RoomType)
-)
-
 // RoomType represents a type of room a client or the server can make. You can only make and set
 // options for a RoomType before starting the server. Doing so at any other time will have no effect
 // at all.
 type RoomType struct {
+	inst *Instance
+
 	serverOnly bool
 
 	voiceChat bool
@@ -31,13 +29,15 @@ type RoomType struct {
 //    rooms.NewRoomType("lobby", true).EnableBroadcastUserEnter().EnableBroadcastUserLeave().
 //         .SetCreateCallback(yourFunc).SetDeleteCallback(anotherFunc)
 //
-func NewRoomType(name string, serverOnly bool) *RoomType {
+func (i *Instance) NewRoomType(name string, serverOnly bool) *RoomType {
 	if len(name) == 0 {
-		return &RoomType{}
-	} else if serverStarted {
-		return &RoomType{}
+		return &RoomType{inst: i}
+	} else if i.isStarted() {
+		return &RoomType{inst: i}
 	}
 	rt := RoomType{
+		inst: i,
+
 		serverOnly: serverOnly,
 
 		voiceChat: false,
@@ -50,15 +50,15 @@ func NewRoomType(name string, serverOnly bool) *RoomType {
 		userEnterCallback: nil,
 		userLeaveCallback: nil}
 
-	roomTypes[name] = &rt
+	i.roomTypes[name] = &rt
 
 	//
-	return roomTypes[name]
+	return i.roomTypes[name]
 }
 
 // GetRoomTypes gets a map of all the RoomTypes.
-func GetRoomTypes() map[string]*RoomType {
-	return roomTypes
+func (i *Instance) GetRoomTypes() map[string]*RoomType {
+	return i.roomTypes
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -69,7 +69,7 @@ func GetRoomTypes() map[string]*RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) EnableVoiceChat() *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).voiceChat = true
@@ -81,7 +81,7 @@ func (r *RoomType) EnableVoiceChat() *RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) EnableBroadcastUserEnter() *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).broadcastUserEnter = true
@@ -93,7 +93,7 @@ func (r *RoomType) EnableBroadcastUserEnter() *RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) EnableBroadcastUserLeave() *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).broadcastUserLeave = true
@@ -105,7 +105,7 @@ func (r *RoomType) EnableBroadcastUserLeave() *RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) SetCreateCallback(callback func(*Room)) *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).createCallback = callback
@@ -117,7 +117,7 @@ func (r *RoomType) SetCreateCallback(callback func(*Room)) *RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) SetDeleteCallback(callback func(*Room)) *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).deleteCallback = callback
@@ -130,7 +130,7 @@ func (r *RoomType) SetDeleteCallback(callback func(*Room)) *RoomType {
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) SetUserEnterCallback(callback func(*Room, *RoomUser)) *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).userEnterCallback = callback
@@ -143,7 +143,7 @@ func (r *RoomType) SetUserEnterCallback(callback func(*Room, *RoomUser)) *RoomTy
 //
 // Note: You must call this BEFORE starting the server in order for it to take effect.
 func (r *RoomType) SetUserLeaveCallback(callback func(*Room, *RoomUser)) *RoomType {
-	if serverStarted {
+	if r.inst.isStarted() {
 		return r
 	}
 	(*r).userLeaveCallback = callback
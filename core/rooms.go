@@ -12,6 +12,8 @@ import (
 // many methods for *Room for maniupulating and retrieving any information about them you could possibly need.
 // Dereferencing them could cause data races in the Room fields that get locked by mutexes.
 type Room struct {
+	inst *Instance
+
 	name     string
 	rType    string
 	private  bool
@@ -33,11 +35,6 @@ type RoomUser struct {
 	conns map[string]*userConn
 }
 
-var (
-	rooms    map[string]*Room = make(map[string]*Room)
-	roomsMux sync.Mutex
-)
-
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 //   MAKE A NEW ROOM   ////////////////////////////////////////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -54,32 +51,32 @@ var (
 // - maxUsers (int): Maximum User capacity (Note: 0 means no limit)
 //
 // - owner (string): The owner of the room. If provided a blank string, will set the owner to the ServerName from ServerSettings
-func NewRoom(name string, rType string, isPrivate bool, maxUsers int, owner string) (*Room, error) {
+func (i *Instance) NewRoom(name string, rType string, isPrivate bool, maxUsers int, owner string) (*Room, error) {
 	//REJECT INCORRECT INPUT
 	if len(name) == 0 {
-		return &Room{}, errors.New("core.NewRoom() requires a name")
+		return &Room{inst: i}, errors.New("core.NewRoom() requires a name")
 	} else if maxUsers < 0 {
 		maxUsers = 0
 	} else if owner == "" {
-		owner = serverName
+		owner = i.getSettings().serverName
 	}
 
 	var roomType *RoomType
 	var ok bool
-	if roomType, ok = roomTypes[rType]; !ok {
-		return &Room{}, errors.New("Invalid room type")
+	if roomType, ok = i.roomTypes[rType]; !ok {
+		return &Room{inst: i}, errors.New("Invalid room type")
 	}
 
 	//ADD THE ROOM
-	roomsMux.Lock()
-	if _, ok := rooms[name]; ok {
-		roomsMux.Unlock()
-		return &Room{}, errors.New("A Room with the name '" + name + "' already exists")
+	i.roomsMux.Lock()
+	if _, ok := i.rooms[name]; ok {
+		i.roomsMux.Unlock()
+		return &Room{inst: i}, errors.New("A Room with the name '" + name + "' already exists")
 	}
-	theRoom := Room{name: name, private: isPrivate, inviteList: []string{}, usersMap: make(map[string]*RoomUser), maxUsers: maxUsers,
+	theRoom := Room{inst: i, name: name, private: isPrivate, inviteList: []string{}, usersMap: make(map[string]*RoomUser), maxUsers: maxUsers,
 		vars: make(map[string]interface{}), owner: owner, rType: rType}
-	rooms[name] = &theRoom
-	roomsMux.Unlock()
+	i.rooms[name] = &theRoom
+	i.roomsMux.Unlock()
 
 	//CALLBACK
 	if roomType.HasCreateCallback() {
@@ -110,7 +107,7 @@ func (r *Room) Delete() error {
 		//CHANGE User's room POINTER TO nil & SEND MESSAGES
 		u.mux.Lock()
 		for key := range u.conns {
-			(*u.conns[key]).socket.WriteJSON(leaveMessage)
+			r.inst.writeMessage((*u.conns[key]).socket, leaveMessage)
 			u.user.mux.Lock()
 			(*u.conns[key]).room = nil
 			u.user.mux.Unlock()
@@ -122,12 +119,12 @@ func (r *Room) Delete() error {
 	r.mux.Unlock()
 
 	// DELETE THE ROOM
-	roomsMux.Lock()
-	delete(rooms, r.name)
-	roomsMux.Unlock()
+	r.inst.roomsMux.Lock()
+	delete(r.inst.rooms, r.name)
+	r.inst.roomsMux.Unlock()
 
 	// CALLBACK
-	rType := roomTypes[r.rType]
+	rType := r.inst.roomTypes[r.rType]
 	if rType.HasDeleteCallback() {
 		rType.DeleteCallback()(r)
 	}
@@ -141,26 +138,37 @@ func (r *Room) Delete() error {
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetRoom finds a Room on the server. If the room does not exit, an error will be returned.
-func GetRoom(roomName string) (*Room, error) {
+func (i *Instance) GetRoom(roomName string) (*Room, error) {
 	//REJECT INCORRECT INPUT
 	if len(roomName) == 0 {
-		return &Room{}, errors.New("core.GetRoom() requires a room name")
+		return &Room{inst: i}, errors.New("core.GetRoom() requires a room name")
 	}
 
 	var room *Room
 	var ok bool
 
-	roomsMux.Lock()
-	if room, ok = rooms[roomName]; !ok {
-		roomsMux.Unlock()
-		return &Room{}, errors.New("The room '" + roomName + "' does not exist")
+	i.roomsMux.Lock()
+	if room, ok = i.rooms[roomName]; !ok {
+		i.roomsMux.Unlock()
+		return &Room{inst: i}, errors.New("The room '" + roomName + "' does not exist")
 	}
-	roomsMux.Unlock()
+	i.roomsMux.Unlock()
 
 	//
 	return room, nil
 }
 
+// GetRooms gets a map of all the Rooms on the server, where the key string is the Room's name.
+func (i *Instance) GetRooms() map[string]*Room {
+	i.roomsMux.Lock()
+	rooms := make(map[string]*Room, len(i.rooms))
+	for name, room := range i.rooms {
+		rooms[name] = room
+	}
+	i.roomsMux.Unlock()
+	return rooms
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 //   ADD A USER   /////////////////////////////////////////////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -173,9 +181,9 @@ func (r *Room) AddUser(user *User, connID string) error {
 	// REJECT INCORRECT INPUT
 	if user == nil {
 		return errors.New("*Room.AddUser() requires a valid User")
-	} else if multiConnect && len(connID) == 0 {
+	} else if r.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !r.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 	r.mux.Lock()
@@ -210,7 +218,7 @@ func (r *Room) AddUser(user *User, connID string) error {
 	var ru *RoomUser
 	var ok bool
 	if ru, ok = r.usersMap[userName]; ok {
-		if !multiConnect {
+		if !r.inst.getSettings().multiConnect {
 			r.mux.Unlock()
 			return errors.New("User '" + userName + "' is already in room '" + r.name + "'")
 		}
@@ -247,7 +255,7 @@ func (r *Room) AddUser(user *User, connID string) error {
 	r.mux.Unlock()
 
 	//
-	roomType := roomTypes[r.rType]
+	roomType := r.inst.roomTypes[r.rType]
 	if roomType.BroadcastUserEnter() {
 		//BROADCAST ENTER TO USERS IN ROOM
 		message := map[string]map[string]interface{}{
@@ -260,7 +268,7 @@ func (r *Room) AddUser(user *User, connID string) error {
 			u.mux.Lock()
 			if u.user.Name() != userName {
 				for _, conn := range u.conns {
-					(*conn).socket.WriteJSON(message)
+					r.inst.writeMessage((*conn).socket, message)
 				}
 			}
 			u.mux.Unlock()
@@ -273,7 +281,7 @@ func (r *Room) AddUser(user *User, connID string) error {
 
 	// SEND RESPONSE TO CLIENT
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionJoinRoom, r.Name(), helpers.NoError())
-	c.socket.WriteJSON(clientResp)
+	r.inst.writeMessage(c.socket, clientResp)
 
 	//
 	return nil
@@ -290,9 +298,9 @@ func (r *Room) RemoveUser(user *User, connID string) error {
 	//REJECT INCORRECT INPUT
 	if user == nil || len(user.name) == 0 {
 		return errors.New("*Room.RemoveUser() requires a valid *User")
-	} else if multiConnect && len(connID) == 0 {
+	} else if r.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !r.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 	//
@@ -323,10 +331,10 @@ func (r *Room) RemoveUser(user *User, connID string) error {
 	userList := r.usersMap
 	r.mux.Unlock()
 	//
-	roomType := roomTypes[r.rType]
+	roomType := r.inst.roomTypes[r.rType]
 
 	//DELETE THE ROOM IF THE OWNER LEFT AND UserRoomControl IS ENABLED
-	if deleteRoomOnLeave && user.name == r.owner {
+	if r.inst.getSettings().deleteRoomOnLeave && user.name == r.owner {
 		deleteErr := r.Delete()
 		if deleteErr != nil {
 			return deleteErr
@@ -343,7 +351,7 @@ func (r *Room) RemoveUser(user *User, connID string) error {
 		for _, u := range userList {
 			u.mux.Lock()
 			for _, conn := range u.conns {
-				conn.socket.WriteJSON(message)
+				r.inst.writeMessage(conn.socket, message)
 			}
 			u.mux.Unlock()
 		}
@@ -361,7 +369,7 @@ func (r *Room) RemoveUser(user *User, connID string) error {
 
 	//SEND RESPONSE TO CLIENT
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionLeaveRoom, r.Name(), helpers.NoError())
-	uConn.socket.WriteJSON(clientResp)
+	r.inst.writeMessage(uConn.socket, clientResp)
 
 	//
 	return nil
@@ -474,11 +482,10 @@ func (r *Room) MaxUsers() int {
 
 // NumUsers gets the number of Users in the Room.
 func (r *Room) NumUsers() int {
-	m, e := r.GetUserMap()
-	if e != nil {
-		return 0
-	}
-	return len(m)
+	r.mux.Lock()
+	num := len(r.usersMap)
+	r.mux.Unlock()
+	return num
 }
 
 // InviteList gets a private Room's invite list.
@@ -488,7 +495,7 @@ func (r *Room) InviteList() ([]string, error) {
 		r.mux.Unlock()
 		return []string{}, errors.New("The room '" + r.name + "' does not exist")
 	}
-	list := r.inviteList
+	list := append([]string{}, r.inviteList...)
 	r.mux.Unlock()
 	//
 	return list, nil
@@ -503,7 +510,11 @@ func (r *Room) GetUserMap() (map[string]*RoomUser, error) {
 	if r.usersMap == nil {
 		err = errors.New("The room '" + r.name + "' does not exist")
 	} else {
-		userMap = r.usersMap
+		// COPY SO THE MAP CAN BE READ WITHOUT LOCKING
+		userMap = make(map[string]*RoomUser, len(r.usersMap))
+		for name, roomUser := range r.usersMap {
+			userMap[name] = roomUser
+		}
 	}
 	r.mux.Unlock()
 
@@ -511,13 +522,42 @@ func (r *Room) GetUserMap() (map[string]*RoomUser, error) {
 }
 
 // RoomCount returns the number of Rooms created on the server.
-func RoomCount() int {
-	roomsMux.Lock()
-	length := len(rooms)
-	roomsMux.Unlock()
+func (i *Instance) RoomCount() int {
+	i.roomsMux.Lock()
+	length := len(i.rooms)
+	i.roomsMux.Unlock()
 	return length
 }
 
+// RoomCountByType returns the number of Rooms on the server for each RoomType name.
+func (i *Instance) RoomCountByType() map[string]int {
+	counts := make(map[string]int)
+	i.roomsMux.Lock()
+	for _, room := range i.rooms {
+		counts[room.rType]++
+	}
+	i.roomsMux.Unlock()
+	return counts
+}
+
+// ActiveRoomCount returns the number of Rooms on the server that have at least one User in them.
+func (i *Instance) ActiveRoomCount() int {
+	i.roomsMux.Lock()
+	rooms := make([]*Room, 0, len(i.rooms))
+	for _, room := range i.rooms {
+		rooms = append(rooms, room)
+	}
+	i.roomsMux.Unlock()
+
+	count := 0
+	for _, room := range rooms {
+		if room.NumUsers() > 0 {
+			count++
+		}
+	}
+	return count
+}
+
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 //   RoomUser ATTRIBUTE READERS   /////////////////////////////////////////////////////////////////////////////////
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -0,0 +1,19 @@
+package core
+
+import (
+	"net"
+)
+
+// ClientConn is a client's connection to the server. The server makes one for every client that connects to it's Listeners, and
+// you can implement your own to plug in other transports, in-process test clients, or server-side bots. See gopher.ServeClient()
+// for running one through the server's client actions. Implementations must be comparable, like a pointer to a struct.
+type ClientConn interface {
+	// Send encodes a message and sends it to the client. Send can be called from several goroutines at once.
+	Send(message interface{}) error
+	// Close closes the connection.
+	Close() error
+	// RemoteAddr gets the client's network address, or nil if it has none.
+	RemoteAddr() net.Addr
+	// Metadata gets information about the connection, like the "transport" and "codec" it uses.
+	Metadata() map[string]string
+}
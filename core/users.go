@@ -2,7 +2,6 @@ package core
 
 import (
 	"errors"
-	"github.com/gorilla/websocket"
 	"github.com/hewiefreeman/GopherGameServer/database"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
 	"sync"
@@ -21,6 +20,8 @@ import (
 // Dereferencing them could cause data races (which will panic and stop the server) in the User
 // fields that get locked for synchronizing access.
 type User struct {
+	inst *Instance
+
 	name       string
 	databaseID int
 	isGuest    bool
@@ -37,23 +38,13 @@ type userConn struct {
 	clientMux *sync.Mutex
 	user      **User
 
-	socket *websocket.Conn
+	socket ClientConn
 
 	//Must lock user's mux to use below items
 	room *Room
 	vars map[string]interface{}
 }
 
-var (
-	users    map[string]*User = make(map[string]*User)
-	usersMux sync.Mutex
-
-	// LoginCallback is only for internal Gopher Game Server mechanics.
-	LoginCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
-	// LogoutCallback is only for internal Gopher Game Server mechanics.
-	LogoutCallback func(string, int)
-)
-
 // These represent the four statuses a User could be.
 const (
 	StatusAvailable = iota // User is available
@@ -62,6 +53,9 @@ const (
 	StatusOffline          // User is offline
 )
 
+// The maximum simultaneous connections on a single User when MaxUserConns in ServerSettings is 0
+const defaultMaxUserConns = 255
+
 // Error messages
 const (
 	errorDenied         = "Action was denied"
@@ -72,6 +66,9 @@ const (
 	errorUnexpected     = "Unexpected error"
 	errorAlreadyLogged  = "User is already logged in"
 	errorServerPaused   = "Server is paused"
+	errorServerDraining = "Server is shutting down"
+	errorBanned         = "User is banned"
+	errorMaxConns       = "User has too many connections"
 )
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -79,15 +76,19 @@ const (
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // Login logs a User in to the service.
-func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bool, socket *websocket.Conn,
+func (i *Instance) Login(userName string, dbID int, autologPass string, isGuest bool, remMe bool, socket ClientConn,
 	connUser **User, clientMux *sync.Mutex) (string, helpers.GopherError) {
 	// Verify input
-	if serverPaused {
+	if i.isPaused() {
 		return "", helpers.NewError(errorServerPaused, helpers.ErrorServerPaused)
+	} else if i.isDraining() {
+		return "", helpers.NewError(errorServerDraining, helpers.ErrorServerDraining)
 	} else if len(userName) == 0 {
 		return "", helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
-	} else if userName == serverName {
+	} else if userName == i.getSettings().serverName {
 		return "", helpers.NewError(errorNameUnavail, helpers.ErrorAuthNameUnavail)
+	} else if i.IsBanned(userName) {
+		return "", helpers.NewError(errorBanned, helpers.ErrorAuthBanned)
 	} else if dbID < -1 {
 		return "", helpers.NewError(errorRequiredID, helpers.ErrorAuthRequiredID)
 	} else if socket == nil {
@@ -101,7 +102,7 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 	}
 
 	// Callback
-	if LoginCallback != nil && !LoginCallback(userName, dbID, nil, nil) {
+	if i.LoginCallback != nil && !i.LoginCallback(userName, dbID, nil, nil) {
 		return "", helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 	}
 
@@ -110,11 +111,11 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 	var connErr error
 	var userExists bool = false
 	//
-	usersMux.Lock()
+	i.usersMux.Lock()
 	//
-	if userOnline, ok := users[userName]; ok {
+	if userOnline, ok := i.users[userName]; ok {
 		userExists = true
-		if kickOnLogin {
+		if i.getSettings().kickOnLogin {
 			// Kick user & remove from room
 			userOnline.mux.Lock()
 			for connKey, conn := range userOnline.conns {
@@ -129,22 +130,35 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 				(*(*conn).clientMux).Unlock()
 				// Send logout message to client
 				clientResp := helpers.MakeClientResponse(helpers.ClientActionLogout, nil, helpers.NoError())
-				(*conn).socket.WriteJSON(clientResp)
+				i.writeMessage((*conn).socket, clientResp)
 			}
 			userOnline.mux.Unlock()
 
 			// Remove user from users map
-			delete(users, userName)
+			delete(i.users, userName)
 
 			// Make connID
 			connID = "1"
 			userExists = false
-		} else if multiConnect {
+		} else if settings := i.getSettings(); settings.multiConnect {
+			// Limit the User's connections
+			maxConns := int(settings.maxUserConns)
+			if maxConns == 0 {
+				maxConns = defaultMaxUserConns
+			}
+			userOnline.mux.Lock()
+			connCount := len(userOnline.conns)
+			userOnline.mux.Unlock()
+			if connCount >= maxConns {
+				i.usersMux.Unlock()
+				return "", helpers.NewError(errorMaxConns, helpers.ErrorAuthMaxConns)
+			}
+
 			// Make a unique connID
 			for {
 				connID, connErr = helpers.GenerateSecureString(5)
 				if connErr != nil {
-					usersMux.Unlock()
+					i.usersMux.Unlock()
 					return "", helpers.NewError(errorUnexpected, helpers.ErrorAuthUnexpected)
 				}
 				userOnline.mux.Lock()
@@ -155,14 +169,14 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 				userOnline.mux.Unlock()
 			}
 		} else {
-			usersMux.Unlock()
+			i.usersMux.Unlock()
 			return "", helpers.NewError(errorAlreadyLogged, helpers.ErrorAuthAlreadyLogged)
 		}
-	} else if multiConnect {
+	} else if i.getSettings().multiConnect {
 		// Make connID
 		connID, connErr = helpers.GenerateSecureString(5)
 		if connErr != nil {
-			usersMux.Unlock()
+			i.usersMux.Unlock()
 			return "", helpers.NewError(errorUnexpected, helpers.ErrorAuthUnexpected)
 		}
 	} else {
@@ -178,34 +192,34 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 	var friendsMap map[string]*database.Friend
 	// Add the userConn to the User or make new User
 	if userExists {
-		(*users[userName]).mux.Lock()
-		(*users[userName]).conns[connID] = &conn
-		friendsMap = (*users[userName]).friends
-		(*users[userName]).mux.Unlock()
+		(*i.users[userName]).mux.Lock()
+		(*i.users[userName]).conns[connID] = &conn
+		friendsMap = (*i.users[userName]).friends
+		(*i.users[userName]).mux.Unlock()
 		// Make friends list for response
-		friends = makeFriendsResponse(friendsMap)
+		friends = i.makeFriendsResponse(friendsMap)
 	} else {
 		// Get friend list from database
-		if dbID != -1 && sqlFeatures {
+		if dbID != -1 && i.getSettings().sqlFeatures {
 			var friendsErr error
-			if friendsMap, friendsErr = database.GetFriends(dbID); friendsErr == nil {
+			if friendsMap, friendsErr = i.db.GetFriends(dbID); friendsErr == nil {
 				// Make friends list for response
-				friends = makeFriendsResponse(friendsMap)
+				friends = i.makeFriendsResponse(friendsMap)
 			}
 		}
 		conns := map[string]*userConn{
 			connID: &conn,
 		}
-		newUser := User{name: userName, databaseID: databaseID, isGuest: isGuest, status: 0,
+		newUser := User{inst: i, name: userName, databaseID: databaseID, isGuest: isGuest, status: 0,
 			friends: friendsMap, conns: conns}
 		u = &newUser
-		users[userName] = u
+		i.users[userName] = u
 	}
 	(*conn.clientMux).Lock()
-	*(conn.user) = users[userName]
+	*(conn.user) = i.users[userName]
 	(*conn.clientMux).Unlock()
 	//
-	usersMux.Unlock()
+	i.usersMux.Unlock()
 
 	// Send online message to friends
 	statusMessage := map[string]map[string]interface{}{
@@ -218,7 +232,7 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 
 	// Login success, send response to client
 	var responseVal map[string]interface{}
-	if rememberMe && len(autologPass) > 0 && remMe {
+	if i.getSettings().rememberMe && len(autologPass) > 0 && remMe {
 		responseVal = map[string]interface{}{
 			"n": userName,
 			"f": friends,
@@ -232,15 +246,15 @@ func Login(userName string, dbID int, autologPass string, isGuest bool, remMe bo
 		}
 	}
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionLogin, responseVal, helpers.NoError())
-	socket.WriteJSON(clientResp)
+	i.writeMessage(socket, clientResp)
 
 	//
 	return connID, helpers.NoError()
 }
 
-func makeFriendsResponse(friendsMap map[string]*database.Friend) []map[string]interface{} {
+func (i *Instance) makeFriendsResponse(friendsMap map[string]*database.Friend) []map[string]interface{} {
 	friends := make([]map[string]interface{}, len(friendsMap), len(friendsMap))
-	i := 0;
+	n := 0
 	for _, val := range friendsMap {
 		frs := val.RequestStatus()
 		friendEntry := map[string]interface{}{
@@ -249,14 +263,14 @@ func makeFriendsResponse(friendsMap map[string]*database.Friend) []map[string]in
 		}
 		if frs == database.FriendStatusAccepted {
 			// Get the friend's status
-			if friend, ok := users[val.Name()]; ok {
+			if friend, ok := i.users[val.Name()]; ok {
 				friendEntry["s"] = friend.Status()
 			} else {
 				friendEntry["s"] = StatusOffline
 			}
 		}
-		friends[i] = friendEntry
-		i++;
+		friends[n] = friendEntry
+		n++
 	}
 	return friends
 }
@@ -270,18 +284,18 @@ func makeFriendsResponse(friendsMap map[string]*database.Friend) []map[string]in
 // WARNING: This is only meant for internal Gopher Game Server mechanics. If you want the "Remember Me"
 // (AKA auto login) feature, enable it in ServerSettings along with the SqlFeatures and corresponding
 // options. You can read more about the "Remember Me" login in the project's usage section.
-func AutoLogIn(tag string, pass string, newPass string, dbID int, conn *websocket.Conn, connUser **User, clientMux *sync.Mutex) (string, helpers.GopherError) {
-	if serverPaused {
+func (i *Instance) AutoLogIn(tag string, pass string, newPass string, dbID int, conn ClientConn, connUser **User, clientMux *sync.Mutex) (string, helpers.GopherError) {
+	if i.isPaused() {
 		return "", helpers.NewError(errorServerPaused, helpers.ErrorServerPaused)
 	}
 
 	// Verify and get user name from database
-	userName, autoLogErr := database.AutoLoginClient(tag, pass, newPass, dbID)
+	userName, autoLogErr := i.db.AutoLoginClient(tag, pass, newPass, dbID)
 	if autoLogErr.ID != 0 {
 		return "", autoLogErr
 	}
 	// Log user in
-	connID, userErr := Login(userName, dbID, newPass, false, true, conn, connUser, clientMux)
+	connID, userErr := i.Login(userName, dbID, newPass, false, true, conn, connUser, clientMux)
 	if userErr.ID != 0 {
 		return "", userErr
 	}
@@ -297,9 +311,9 @@ func AutoLogIn(tag string, pass string, newPass string, dbID int, conn *websocke
 // parameter is the connection ID associated with one of the connections attached to that User. This must
 // be provided when logging a User out with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) Logout(connID string) {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -337,20 +351,20 @@ func (u *User) Logout(connID string) {
 	if len(u.conns) == 0 {
 		// Delete user if there are no more conns
 		u.mux.Unlock()
-		usersMux.Lock()
-		delete(users, u.name)
-		usersMux.Unlock()
+		u.inst.usersMux.Lock()
+		delete(u.inst.users, u.name)
+		u.inst.usersMux.Unlock()
 	} else {
 		u.mux.Unlock()
 	}
 
 	// Send response
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionLogout, nil, helpers.NoError())
-	socket.WriteJSON(clientResp)
+	u.inst.writeMessage(socket, clientResp)
 
 	// Run callback
-	if LogoutCallback != nil {
-		LogoutCallback(u.Name(), u.DatabaseID())
+	if u.inst.LogoutCallback != nil {
+		u.inst.LogoutCallback(u.Name(), u.DatabaseID())
 	}
 }
 
@@ -388,19 +402,19 @@ func (u *User) Kick() {
 		(*conn).clientMux.Unlock()
 
 		// Send response
-		(*conn).socket.WriteJSON(clientResp)
+		u.inst.writeMessage((*conn).socket, clientResp)
 	}
 
 	u.mux.Unlock()
 
 	// Remove from users
-	usersMux.Lock()
-	delete(users, u.name)
-	usersMux.Unlock()
+	u.inst.usersMux.Lock()
+	delete(u.inst.users, u.name)
+	u.inst.usersMux.Unlock()
 
 	// Run callback
-	if LogoutCallback != nil {
-		LogoutCallback(u.Name(), u.DatabaseID())
+	if u.inst.LogoutCallback != nil {
+		u.inst.LogoutCallback(u.Name(), u.DatabaseID())
 	}
 }
 
@@ -409,28 +423,39 @@ func (u *User) Kick() {
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetUser finds a logged in User by their name. Returns an error if the User is not online.
-func GetUser(userName string) (*User, error) {
+func (i *Instance) GetUser(userName string) (*User, error) {
 	// Verify input
 	if len(userName) == 0 {
-		return &User{}, errors.New("users.Get() requires a user name")
-	} else if serverPaused {
-		return &User{}, errors.New(errorServerPaused)
+		return &User{inst: i}, errors.New("users.Get() requires a user name")
+	} else if i.isPaused() {
+		return &User{inst: i}, errors.New(errorServerPaused)
 	}
 
 	var user *User
 	var ok bool
 
-	usersMux.Lock()
-	if user, ok = users[userName]; !ok {
-		usersMux.Unlock()
-		return &User{}, errors.New("User '" + userName + "' is not logged in")
+	i.usersMux.Lock()
+	if user, ok = i.users[userName]; !ok {
+		i.usersMux.Unlock()
+		return &User{inst: i}, errors.New("User '" + userName + "' is not logged in")
 	}
-	usersMux.Unlock()
+	i.usersMux.Unlock()
 
 	//
 	return user, nil
 }
 
+// GetUsers gets a map of all the Users logged into the server, where the key string is the User's name.
+func (i *Instance) GetUsers() map[string]*User {
+	i.usersMux.Lock()
+	users := make(map[string]*User, len(i.users))
+	for name, user := range i.users {
+		users[name] = user
+	}
+	i.usersMux.Unlock()
+	return users
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 //   MAKE A USER JOIN/LEAVE A ROOM   /////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -439,9 +464,9 @@ func GetUser(userName string) (*User, error) {
 // parameter is the connection ID associated with one of the connections attached to that User. This must
 // be provided when making a User join a Room with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) Join(r *Room, connID string) error {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 	u.mux.Lock()
@@ -475,9 +500,9 @@ func (u *User) Join(r *Room, connID string) error {
 // parameter is the connection ID associated with one of the connections attached to that User. This must
 // be provided when making a User leave a Room with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) Leave(connID string) error {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -530,9 +555,9 @@ func (u *User) SetStatus(status int) {
 // parameter is the connection ID associated with one of the connections attached to the inviting User. This must
 // be provided when making a User invite another with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) Invite(invUser *User, connID string) error {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -543,7 +568,7 @@ func (u *User) Invite(invUser *User, connID string) error {
 	}
 	currRoom := (*u.conns[connID]).room
 	u.mux.Unlock()
-	rType := GetRoomTypes()[currRoom.Type()]
+	rType := u.inst.GetRoomTypes()[currRoom.Type()]
 	if currRoom == nil || currRoom.Name() == "" {
 		return errors.New("The user '" + u.name + "' is not in a room")
 	} else if !currRoom.IsPrivate() {
@@ -571,7 +596,7 @@ func (u *User) Invite(invUser *User, connID string) error {
 	// Send response to all connections
 	invUser.mux.Lock()
 	for _, conn := range invUser.conns {
-		(*conn).socket.WriteJSON(invMessage)
+		u.inst.writeMessage((*conn).socket, invMessage)
 	}
 	invUser.mux.Unlock()
 
@@ -588,9 +613,9 @@ func (u *User) Invite(invUser *User, connID string) error {
 // parameter is the connection ID associated with one of the connections attached to the inviting User. This must
 // be provided when making a User revoke an invite with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) RevokeInvite(revokeUser string, connID string) error {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return errors.New("Must provide a connID when MultiConnect is enabled")
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -601,7 +626,7 @@ func (u *User) RevokeInvite(revokeUser string, connID string) error {
 	}
 	currRoom := (*u.conns[connID]).room
 	u.mux.Unlock()
-	rType := GetRoomTypes()[currRoom.Type()]
+	rType := u.inst.GetRoomTypes()[currRoom.Type()]
 	if currRoom == nil || currRoom.Name() == "" {
 		return errors.New("The user '" + u.name + "' is not in a room")
 	} else if !currRoom.IsPrivate() {
@@ -627,13 +652,26 @@ func (u *User) RevokeInvite(revokeUser string, connID string) error {
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // UserCount returns the number of Users logged into the server.
-func UserCount() int {
-	usersMux.Lock()
-	length := len(users)
-	usersMux.Unlock()
+func (i *Instance) UserCount() int {
+	i.usersMux.Lock()
+	length := len(i.users)
+	i.usersMux.Unlock()
 	return length
 }
 
+// GuestCount returns the number of guest Users logged into the server.
+func (i *Instance) GuestCount() int {
+	count := 0
+	i.usersMux.Lock()
+	for _, user := range i.users {
+		if user.isGuest {
+			count++
+		}
+	}
+	i.usersMux.Unlock()
+	return count
+}
+
 // Name gets the name of the User.
 func (u *User) Name() string {
 	return u.name
@@ -660,13 +698,16 @@ func (u *User) Friends() map[string]database.Friend {
 // parameter is the connection ID associated with one of the connections attached to that User. This must
 // be provided when getting a User's Room with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) RoomIn(connID string) *Room {
-	if multiConnect && len(connID) == 0 {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return nil
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 	u.mux.Lock()
-	room := (*u.conns[connID]).room
+	var room *Room
+	if conn, ok := u.conns[connID]; ok {
+		room = (*conn).room
+	}
 	u.mux.Unlock()
 	//
 	return room
@@ -680,13 +721,13 @@ func (u *User) Status() int {
 	return status
 }
 
-// Socket gets the WebSocket connection of a User. If you are using MultiConnect in ServerSettings, the connID
+// Socket gets the ClientConn of a User. If you are using MultiConnect in ServerSettings, the connID
 // parameter is the connection ID associated with one of the connections attached to that User. This must
 // be provided when getting a User's socket connection with MultiConnect enabled. Otherwise, an empty string can be used.
-func (u *User) Socket(connID string) *websocket.Conn {
-	if multiConnect && len(connID) == 0 {
+func (u *User) Socket(connID string) ClientConn {
+	if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return nil
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 	u.mux.Lock()
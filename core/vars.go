@@ -16,9 +16,9 @@ func (u *User) SetVariable(key string, value interface{}, connID string) {
 	//REJECT INCORRECT INPUT
 	if len(key) == 0 {
 		return
-	} else if multiConnect && len(connID) == 0 {
+	} else if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -40,7 +40,7 @@ func (u *User) SetVariable(key string, value interface{}, connID string) {
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionSetVariable, resp, helpers.NoError())
 
 	//SEND RESPONSE TO CLIENT
-	socket.WriteJSON(clientResp)
+	u.inst.writeMessage(socket, clientResp)
 }
 
 // SetVariables sets all the specified User variables at once. The client API of the User will also receive these changes. If you are using MultiConnect in ServerSettings, the connID
@@ -50,9 +50,9 @@ func (u *User) SetVariables(values map[string]interface{}, connID string) {
 	//REJECT INCORRECT INPUT
 	if values == nil || len(values) == 0 {
 		return
-	} else if multiConnect && len(connID) == 0 {
+	} else if u.inst.getSettings().multiConnect && len(connID) == 0 {
 		return
-	} else if !multiConnect {
+	} else if !u.inst.getSettings().multiConnect {
 		connID = "1"
 	}
 
@@ -70,7 +70,7 @@ func (u *User) SetVariables(values map[string]interface{}, connID string) {
 
 	//SEND RESPONSE TO CLIENT
 	clientResp := helpers.MakeClientResponse(helpers.ClientActionSetVariables, values, helpers.NoError())
-	socket.WriteJSON(clientResp)
+	u.inst.writeMessage(socket, clientResp)
 
 }
 
@@ -96,17 +96,23 @@ func (u *User) GetVariable(key string, connID string) interface{} {
 // be provided when getting a User's variables with MultiConnect enabled. Otherwise, an empty string can be used.
 func (u *User) GetVariables(keys []string, connID string) map[string]interface{} {
 	var value map[string]interface{} = make(map[string]interface{})
-	if keys == nil || len(keys) == 0 {
-		u.mux.Lock()
-		value = (*u.conns[connID]).vars
+	u.mux.Lock()
+	conn, ok := u.conns[connID]
+	if !ok {
 		u.mux.Unlock()
+		return value
+	}
+	if keys == nil || len(keys) == 0 {
+		// COPY SO THE MAP CAN BE READ WITHOUT LOCKING
+		for key, val := range (*conn).vars {
+			value[key] = val
+		}
 	} else {
-		u.mux.Lock()
 		for i := 0; i < len(keys); i++ {
-			value[keys[i]] = (*u.conns[connID]).vars[keys[i]]
+			value[keys[i]] = (*conn).vars[keys[i]]
 		}
-		u.mux.Unlock()
 	}
+	u.mux.Unlock()
 
 	//
 	return value
@@ -151,6 +157,17 @@ func (r *Room) SetVariables(values map[string]interface{}) {
 	return
 }
 
+// DeleteVariable deletes a Room variable.
+func (r *Room) DeleteVariable(key string) {
+	r.mux.Lock()
+	if r.usersMap == nil {
+		r.mux.Unlock()
+		return
+	}
+	delete(r.vars, key)
+	r.mux.Unlock()
+}
+
 // GetVariable gets one of the Room's variables.
 func (r *Room) GetVariable(key string) (interface{}, error) {
 	//REJECT INCORRECT INPUT
@@ -179,7 +196,10 @@ func (r *Room) GetVariables(keys []string) (map[string]interface{}, error) {
 		return nil, errors.New("Room '" + r.name + "' does not exist")
 	}
 	if keys == nil || len(keys) == 0 {
-		value = r.vars
+		// COPY SO THE MAP CAN BE READ WITHOUT LOCKING
+		for key, val := range r.vars {
+			value[key] = val
+		}
 	} else {
 		for i := 0; i < len(keys); i++ {
 			value[keys[i]] = r.vars[keys[i]]
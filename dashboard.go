@@ -0,0 +1,38 @@
+package gopher
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// The admin dashboard's static files. The dashboard runs in the browser, and uses the admin REST API for everything.
+//
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+var dashboardHandler http.Handler = makeDashboardHandler()
+
+func makeDashboardHandler() http.Handler {
+	files, err := fs.Sub(dashboardFiles, "dashboard")
+	if err != nil {
+		panic(err)
+	}
+	return http.StripPrefix("/ui", http.FileServer(http.FS(files)))
+}
+
+// serveDashboard serves the admin dashboard's files on the admin API's '/ui/' path.
+func (s *Server) serveDashboard(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		adminError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		return
+	} else if r.URL.Path == "/ui" {
+		// Relative links in the dashboard need the trailing slash
+		http.Redirect(w, r, "ui/", http.StatusMovedPermanently)
+		return
+	}
+	w.Header().Set("Content-Security-Policy", "default-src 'self'; frame-ancestors 'none'")
+	w.Header().Set("X-Frame-Options", "DENY")
+	w.Header().Set("Cache-Control", "no-cache")
+	dashboardHandler.ServeHTTP(w, r)
+}
@@ -0,0 +1,255 @@
+// Gopher Game Server admin dashboard. Everything goes through the admin REST API, which is served one folder up from the dashboard.
+(function () {
+	"use strict";
+
+	var API = "../";
+	var REFRESH_INTERVAL = 2000;
+
+	var token = sessionStorage.getItem("gopherAdminToken");
+	var refreshTimer = null;
+	var selectedRoom = null;
+	var selectedUser = null;
+
+	function $(id) {
+		return document.getElementById(id);
+	}
+
+	function show(id, visible) {
+		$(id).classList.toggle("hidden", !visible);
+	}
+
+	function showError(msg) {
+		$("error").textContent = msg || "";
+	}
+
+	// Calls the admin API, and resolves with the response's JSON body
+	function api(method, path, body) {
+		var options = {method: method, headers: {}};
+		if (token) {
+			options.headers["Authorization"] = "Bearer " + token;
+		}
+		if (body !== undefined) {
+			options.headers["Content-Type"] = "application/json";
+			options.body = JSON.stringify(body);
+		}
+		return fetch(API + path, options).then(function (res) {
+			return res.json().then(function (json) {
+				if (res.status === 401 && path !== "login") {
+					logOut();
+				}
+				if (!res.ok) {
+					throw new Error(json.error || res.statusText);
+				}
+				return json;
+			});
+		});
+	}
+
+	function cell(row, text) {
+		var td = document.createElement("td");
+		td.textContent = text;
+		row.appendChild(td);
+		return td;
+	}
+
+	function listItems(id, items) {
+		var list = $(id);
+		list.textContent = "";
+		(items || []).forEach(function (item) {
+			var li = document.createElement("li");
+			li.textContent = item;
+			list.appendChild(li);
+		});
+	}
+
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+	//   Login   /////////////////////////////////////////////////////////////////////////////////////////
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	function logIn(e) {
+		e.preventDefault();
+		$("loginError").textContent = "";
+		api("POST", "login", {login: $("loginName").value, password: $("loginPassword").value}).then(function (res) {
+			token = res.token;
+			sessionStorage.setItem("gopherAdminToken", token);
+			$("loginPassword").value = "";
+			start();
+		}).catch(function (err) {
+			$("loginError").textContent = err.message;
+		});
+	}
+
+	function logOut() {
+		if (token) {
+			api("POST", "logout").catch(function () {});
+		}
+		token = null;
+		sessionStorage.removeItem("gopherAdminToken");
+		clearInterval(refreshTimer);
+		show("dashboard", false);
+		show("login", true);
+	}
+
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+	//   Server   ////////////////////////////////////////////////////////////////////////////////////////
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	function refresh() {
+		api("GET", "server").then(function (server) {
+			$("serverName").textContent = server.name;
+			$("serverVersion").textContent = "v" + server.version;
+			$("serverState").textContent = server.state;
+			$("serverState").className = "state " + server.state;
+			$("countConnections").textContent = server.connections;
+			$("countUsers").textContent = server.users;
+			$("countGuests").textContent = server.guests;
+			$("countRooms").textContent = server.rooms;
+			showError();
+		}).catch(function (err) {
+			showError(err.message);
+		});
+		refreshRooms();
+		if (selectedRoom) {
+			showRoom(selectedRoom);
+		}
+	}
+
+	function serverAction(action, confirmMsg) {
+		if (confirmMsg && !confirm(confirmMsg)) {
+			return;
+		}
+		api("POST", "server/" + action).then(refresh).catch(function (err) {
+			showError(err.message);
+		});
+	}
+
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+	//   Rooms   /////////////////////////////////////////////////////////////////////////////////////////
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	function refreshRooms() {
+		api("GET", "rooms").then(function (rooms) {
+			var list = $("roomList");
+			list.textContent = "";
+			rooms.sort(function (a, b) {
+				return a.name < b.name ? -1 : 1;
+			});
+			rooms.forEach(function (room) {
+				var row = document.createElement("tr");
+				cell(row, room.name);
+				cell(row, room.type);
+				cell(row, room.private ? "yes" : "no");
+				cell(row, room.owner);
+				cell(row, room.numUsers + (room.maxUsers > 0 ? " / " + room.maxUsers : ""));
+				row.addEventListener("click", function () {
+					showRoom(room.name);
+				});
+				list.appendChild(row);
+			});
+		}).catch(function () {});
+	}
+
+	function showRoom(name) {
+		api("GET", "rooms/" + encodeURIComponent(name)).then(function (room) {
+			selectedRoom = name;
+			$("roomName").textContent = room.name + " (" + room.type + ")";
+			listItems("roomUsers", room.users);
+			listItems("roomInvites", room.invites);
+			$("roomVars").textContent = JSON.stringify(room.vars || {}, null, 2);
+			show("roomInfo", true);
+		}).catch(function () {
+			selectedRoom = null;
+			show("roomInfo", false);
+		});
+	}
+
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+	//   Users   /////////////////////////////////////////////////////////////////////////////////////////
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	function lookUpUser(e) {
+		if (e) {
+			e.preventDefault();
+		}
+		var name = $("userName").value.trim();
+		api("GET", "users/" + encodeURIComponent(name)).then(function (user) {
+			selectedUser = user.name;
+			$("userTitle").textContent = user.name;
+			$("userDetails").textContent = "Status: " + user.status + ", Guest: " + (user.guest ? "yes" : "no") +
+				", Database ID: " + user.databaseID + ", Friends: " + (user.friends || []).length;
+			var list = $("userConnections");
+			list.textContent = "";
+			(user.connections || []).forEach(function (conn) {
+				var row = document.createElement("tr");
+				cell(row, conn.id);
+				cell(row, conn.room);
+				cell(row, JSON.stringify(conn.vars || {}));
+				list.appendChild(row);
+			});
+			show("userInfo", true);
+			showError();
+		}).catch(function (err) {
+			selectedUser = null;
+			show("userInfo", false);
+			showError(err.message);
+		});
+	}
+
+	function kickUser() {
+		if (!selectedUser || !confirm("Kick " + selectedUser + "?")) {
+			return;
+		}
+		api("POST", "users/" + encodeURIComponent(selectedUser) + "/kick").then(function () {
+			selectedUser = null;
+			show("userInfo", false);
+			refresh();
+		}).catch(function (err) {
+			showError(err.message);
+		});
+	}
+
+	function messageUser(e) {
+		e.preventDefault();
+		if (!selectedUser) {
+			return;
+		}
+		api("POST", "users/" + encodeURIComponent(selectedUser) + "/message", {message: $("messageText").value}).then(function () {
+			$("messageText").value = "";
+		}).catch(function (err) {
+			showError(err.message);
+		});
+	}
+
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+	//   Start   /////////////////////////////////////////////////////////////////////////////////////////
+	//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+	function start() {
+		show("login", false);
+		show("dashboard", true);
+		refresh();
+		clearInterval(refreshTimer);
+		refreshTimer = setInterval(refresh, REFRESH_INTERVAL);
+	}
+
+	$("loginForm").addEventListener("submit", logIn);
+	$("logoutButton").addEventListener("click", logOut);
+	$("pauseButton").addEventListener("click", function () {
+		serverAction("pause", "Pause the server? All Users will be logged out.");
+	});
+	$("resumeButton").addEventListener("click", function () {
+		serverAction("resume");
+	});
+	$("shutdownButton").addEventListener("click", function () {
+		serverAction("shutdown", "Shut the server down?");
+	});
+	$("userForm").addEventListener("submit", lookUpUser);
+	$("messageForm").addEventListener("submit", messageUser);
+	$("kickButton").addEventListener("click", kickUser);
+
+	if (token) {
+		start();
+	} else {
+		show("login", true);
+	}
+})();
@@ -24,10 +24,6 @@ type AccountInfoColumn struct {
 	encrypt   bool
 }
 
-var (
-	customAccountInfo map[string]AccountInfoColumn = make(map[string]AccountInfoColumn)
-)
-
 // MySQL database data types. Use one of these when making a new AccountInfoColumn or
 // CustomTable's columns. The parentheses next to a type indicate it requires a maximum
 // size when making a column of that type. Two pairs of parentheses means it requires a
@@ -140,8 +136,8 @@ var (
 )
 
 // NewAccountInfoColumn makes a new AccountInfoColumn. You can only make new AccountInfoColumns before starting the server.
-func NewAccountInfoColumn(name string, dataType int, maxSize int, precision int, notNull bool, unique bool, encrypt bool) error {
-	if serverStarted {
+func (db *Instance) NewAccountInfoColumn(name string, dataType int, maxSize int, precision int, notNull bool, unique bool, encrypt bool) error {
+	if db.serverStarted {
 		return errors.New("You can't make a new AccountInfoColumn after the server has started")
 	} else if len(name) == 0 {
 		return errors.New("database.NewAccountInfoColumn() requires a name")
@@ -157,7 +153,7 @@ func NewAccountInfoColumn(name string, dataType int, maxSize int, precision int,
 		return errors.New("The data type '" + dataTypesSize[dataType] + "' requires a max size and precision")
 	}
 
-	customAccountInfo[name] = AccountInfoColumn{dataType: dataType, maxSize: maxSize, precision: precision, notNull: notNull, unique: unique, encrypt: encrypt}
+	db.customAccountInfo[name] = AccountInfoColumn{dataType: dataType, maxSize: maxSize, precision: precision, notNull: notNull, unique: unique, encrypt: encrypt}
 
 	//
 	return nil
@@ -6,28 +6,6 @@ import (
 	"strconv"
 )
 
-var (
-	encryptionCost    int = 4
-	customLoginColumn string
-
-	customLoginRequirements             map[string]struct{} = make(map[string]struct{})
-	customSignupRequirements            map[string]struct{} = make(map[string]struct{})
-	customPasswordChangeRequirements    map[string]struct{} = make(map[string]struct{})
-	customAccountInfoChangeRequirements map[string]struct{} = make(map[string]struct{})
-	customDeleteAccountRequirements     map[string]struct{} = make(map[string]struct{})
-
-	// SignUpCallback is only for internal Gopher Game Server mechanics.
-	SignUpCallback func(string, map[string]interface{}) bool
-	// LoginCallback is only for internal Gopher Game Server mechanics.
-	LoginCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
-	// DeleteAccountCallback is only for internal Gopher Game Server mechanics.
-	DeleteAccountCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
-	// AccountInfoChangeCallback is only for internal Gopher Game Server mechanics.
-	AccountInfoChangeCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
-	// PasswordChangeCallback is only for internal Gopher Game Server mechanics.
-	PasswordChangeCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
-)
-
 // Authentication error messages
 const (
 	errorDenied           = "Action was denied"
@@ -48,90 +26,90 @@ const (
 
 // SetCustomSignupRequirements sets the required AccountInfoColumn names for processing a sign up request from a client. If a client
 // doesn't send the required info, an error will be sent back.
-func SetCustomSignupRequirements(columnNames ...string) error {
-	if serverStarted {
+func (db *Instance) SetCustomSignupRequirements(columnNames ...string) error {
+	if db.serverStarted {
 		return errors.New("You can't run SetCustomSignupRequirements after the server has started")
 	}
 	for i := 0; i < len(columnNames); i++ {
 		if checkStringSQLInjection(columnNames[i]) {
 			return errors.New("Malicious characters detected")
 		}
-		if _, ok := customAccountInfo[columnNames[i]]; !ok {
+		if _, ok := db.customAccountInfo[columnNames[i]]; !ok {
 			return errors.New("Incorrect column name '" + columnNames[i] + "'")
 		}
-		customSignupRequirements[columnNames[i]] = struct{}{}
+		db.customSignupRequirements[columnNames[i]] = struct{}{}
 	}
 	return nil
 }
 
 // SetCustomLoginRequirements sets the required AccountInfoColumn names for processing a login request from a client. If a client
 // doesn't send the required info, an error will be sent back.
-func SetCustomLoginRequirements(columnNames ...string) error {
-	if serverStarted {
+func (db *Instance) SetCustomLoginRequirements(columnNames ...string) error {
+	if db.serverStarted {
 		return errors.New("You can't run SetCustomLoginRequirements after the server has started")
 	}
 	for i := 0; i < len(columnNames); i++ {
 		if checkStringSQLInjection(columnNames[i]) {
 			return errors.New("Malicious characters detected")
 		}
-		if _, ok := customAccountInfo[columnNames[i]]; !ok {
+		if _, ok := db.customAccountInfo[columnNames[i]]; !ok {
 			return errors.New("Incorrect column name '" + columnNames[i] + "'")
 		}
-		customLoginRequirements[columnNames[i]] = struct{}{}
+		db.customLoginRequirements[columnNames[i]] = struct{}{}
 	}
 	return nil
 }
 
 // SetCustomPasswordChangeRequirements sets the required AccountInfoColumn names for processing a password change request from a client. If a client
 // doesn't send the required info, an error will be sent back.
-func SetCustomPasswordChangeRequirements(columnNames ...string) error {
-	if serverStarted {
+func (db *Instance) SetCustomPasswordChangeRequirements(columnNames ...string) error {
+	if db.serverStarted {
 		return errors.New("You can't run SetCustomPasswordChangeRequirements after the server has started")
 	}
 	for i := 0; i < len(columnNames); i++ {
 		if checkStringSQLInjection(columnNames[i]) {
 			return errors.New("Malicious characters detected")
 		}
-		if _, ok := customAccountInfo[columnNames[i]]; !ok {
+		if _, ok := db.customAccountInfo[columnNames[i]]; !ok {
 			return errors.New("Incorrect column name '" + columnNames[i] + "'")
 		}
-		customPasswordChangeRequirements[columnNames[i]] = struct{}{}
+		db.customPasswordChangeRequirements[columnNames[i]] = struct{}{}
 	}
 	return nil
 }
 
 // SetCustomAccountInfoChangeRequirements sets the required AccountInfoColumn names for processing an AccountInfoColumn change request from a client. If a client
 // doesn't send the required info, an error will be sent back.
-func SetCustomAccountInfoChangeRequirements(columnNames ...string) error {
-	if serverStarted {
+func (db *Instance) SetCustomAccountInfoChangeRequirements(columnNames ...string) error {
+	if db.serverStarted {
 		return errors.New("You can't run SetCustomAccountInfoChangeRequirements after the server has started")
 	}
 	for i := 0; i < len(columnNames); i++ {
 		if checkStringSQLInjection(columnNames[i]) {
 			return errors.New("Malicious characters detected")
 		}
-		if _, ok := customAccountInfo[columnNames[i]]; !ok {
+		if _, ok := db.customAccountInfo[columnNames[i]]; !ok {
 			return errors.New("Incorrect column name '" + columnNames[i] + "'")
 		}
-		customAccountInfoChangeRequirements[columnNames[i]] = struct{}{}
+		db.customAccountInfoChangeRequirements[columnNames[i]] = struct{}{}
 	}
 	return nil
 }
 
 // SetCustomDeleteAccountRequirements sets the required AccountInfoColumn names for processing a delete account request from a client. If a client
 // doesn't send the required info, an error will be sent back.
-func SetCustomDeleteAccountRequirements(columnNames ...string) error {
-	if serverStarted {
+func (db *Instance) SetCustomDeleteAccountRequirements(columnNames ...string) error {
+	if db.serverStarted {
 		return errors.New("You can't run SetCustomDeleteAccountRequirements after the server has started")
 	}
 	for i := 0; i < len(columnNames); i++ {
 		if checkStringSQLInjection(columnNames[i]) {
 			return errors.New("Malicious characters detected")
 		}
-		if _, ok := customAccountInfo[columnNames[i]]; !ok {
+		if _, ok := db.customAccountInfo[columnNames[i]]; !ok {
 			return errors.New("Incorrect column name '" + columnNames[i] + "'")
 		}
-		customDeleteAccountRequirements[columnNames[i]] = struct{}{}
+		db.customDeleteAccountRequirements[columnNames[i]] = struct{}{}
 	}
 	return nil
 }
@@ -164,24 +142,24 @@ func checkCustomRequirements(customCols map[string]interface{}, requirements map
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to sign a
 // client up when using the SQL features.
-func SignUpClient(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+func (db *Instance) SignUpClient(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
 	if len(userName) == 0 {
 		return helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
 	} else if len(password) == 0 {
 		return helpers.NewError(errorRequiredPass, helpers.ErrorAuthRequiredPass)
 	} else if checkStringSQLInjection(userName) {
 		return helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
-	} else if !checkCustomRequirements(customCols, customSignupRequirements) {
+	} else if !checkCustomRequirements(customCols, db.customSignupRequirements) {
 		return helpers.NewError(errorIncorrectCols, helpers.ErrorAuthIncorrectCols)
 	}
 
 	//RUN CALLBACK
-	if SignUpCallback != nil && !SignUpCallback(userName, customCols) {
+	if db.SignUpCallback != nil && !db.SignUpCallback(userName, customCols) {
 		return helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 	}
 
 	//ENCRYPT PASSWORD
-	passHash, hashErr := helpers.EncryptString(password, encryptionCost)
+	passHash, hashErr := helpers.EncryptString(password, db.encryptionCost)
 	if hashErr != nil {
 		return helpers.NewError(hashErr.Error(), helpers.ErrorAuthEncryption)
 	}
@@ -193,17 +171,17 @@ func SignUpClient(userName string, password string, customCols map[string]interf
 
 	if customCols != nil {
 		vals = make([]interface{}, 0, len(customCols))
-		if customLoginColumn != "" {
-			if _, ok := customCols[customLoginColumn]; !ok {
+		if db.customLoginColumn != "" {
+			if _, ok := customCols[db.customLoginColumn]; !ok {
 				return helpers.NewError(errorInsufficientCols, helpers.ErrorAuthInsufficientCols)
 			}
 		}
 		for key, val := range customCols {
 			queryPart1 = queryPart1 + key + ", "
 			//MAINTAIN THE ORDER IN WHICH THE COLUMNS WERE DECLARED VIA A SLICE
-			vals = append(vals, []interface{}{val, customAccountInfo[key]})
+			vals = append(vals, []interface{}{val, db.customAccountInfo[key]})
 		}
-	} else if customLoginColumn != "" {
+	} else if db.customLoginColumn != "" {
 		return helpers.NewError(errorInsufficientCols, helpers.ErrorAuthInsufficientCols)
 	}
 	queryPart1 = queryPart1[0:len(queryPart1)-2] + ") "
@@ -220,7 +198,7 @@ func SignUpClient(userName string, password string, customCols map[string]interf
 			}
 			//CHECK FOR ENCRYPT
 			if dt.encrypt {
-				value, valueErr = helpers.EncryptString(value, encryptionCost)
+				value, valueErr = helpers.EncryptString(value, db.encryptionCost)
 				if valueErr != nil {
 					return helpers.NewError(valueErr.Error(), helpers.ErrorAuthEncryption)
 				}
@@ -233,7 +211,7 @@ func SignUpClient(userName string, password string, customCols map[string]interf
 	queryPart2 = queryPart2[0:len(queryPart2)-2] + ");"
 
 	//EXECUTE QUERY
-	_, insertErr := database.Exec(queryPart1 + queryPart2)
+	_, insertErr := db.exec(queryPart1 + queryPart2)
 	if insertErr != nil {
 		return helpers.NewError(insertErr.Error(), helpers.ErrorAuthQuery)
 	}
@@ -250,7 +228,7 @@ func SignUpClient(userName string, password string, customCols map[string]interf
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to log in a
 // client when using the SQL features.
-func LoginClient(userName string, password string, deviceTag string, remMe bool, customCols map[string]interface{}) (string, int, string, helpers.GopherError) {
+func (db *Instance) LoginClient(userName string, password string, deviceTag string, remMe bool, customCols map[string]interface{}) (string, int, string, helpers.GopherError) {
 	if len(userName) == 0 {
 		return "", 0, "", helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
 	} else if len(password) == 0 {
@@ -259,7 +237,7 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 		return "", 0, "", helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
 	} else if checkStringSQLInjection(deviceTag) {
 		return "", 0, "", helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
-	} else if !checkCustomRequirements(customCols, customLoginRequirements) {
+	} else if !checkCustomRequirements(customCols, db.customLoginRequirements) {
 		return "", 0, "", helpers.NewError(errorIncorrectCols, helpers.ErrorAuthIncorrectCols)
 	}
 
@@ -285,14 +263,14 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 	// GET LOGIN COLUMN AND TABLE NAME
 	var loginCol string = usersColumnName
 	var tableName string = tableUsers
-	if len(customLoginColumn) > 0 {
-		loginCol = customLoginColumn
+	if len(db.customLoginColumn) > 0 {
+		loginCol = db.customLoginColumn
 	}
 
 	selectQuery = selectQuery[0:len(selectQuery)-2] + " FROM " + tableName + " WHERE " + loginCol + "=\"" + userName + "\" LIMIT 1;"
 
 	//EXECUTE SELECT QUERY
-	checkRows, err := database.Query(selectQuery)
+	checkRows, err := db.query(selectQuery)
 	if err != nil {
 		return "", 0, "", helpers.NewError(errorIncorrectLogin, helpers.ErrorAuthIncorrectLogin)
 	}
@@ -310,7 +288,7 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 	uName := vals[2].(*string)
 
 	//RUN CALLBACK
-	if LoginCallback != nil {
+	if db.LoginCallback != nil {
 		// GET THE RECEIVED COLUMN VALUES AS MAP
 		var receivedVals map[string]interface{} = make(map[string]interface{})
 		if customCols != nil {
@@ -322,7 +300,7 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 			}
 		}
 
-		if !LoginCallback(*uName, *dbIndex, receivedVals, customCols) {
+		if !db.LoginCallback(*uName, *dbIndex, receivedVals, customCols) {
 			return "", 0, "", helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 		}
 	}
@@ -336,17 +314,17 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 	var devicePass string
 	var devicePassErr error
 
-	if rememberMe && remMe {
+	if db.rememberMe && remMe {
 		//MAKE AUTO-LOG ENTRY
 		devicePass, devicePassErr = helpers.GenerateSecureString(32)
 		if devicePassErr == nil {
-			_, exErr := database.Exec("INSERT INTO " + tableAutologs + " (" + autologsColumnID + ", " + autologsColumnDeviceTag + ", " + autologsColumnDevicePass +
+			_, exErr := db.exec("INSERT INTO " + tableAutologs + " (" + autologsColumnID + ", " + autologsColumnDeviceTag + ", " + autologsColumnDevicePass +
 				") VALUES (" + strconv.Itoa(*dbIndex) + ", \"" + deviceTag + "\", \"" + devicePass + "\");")
 			if exErr != nil {
-				////// LOG ERROR!!!!!!
+				db.logger.Error("Error making auto-log entry", "user", *uName, "error", exErr)
 			}
 		} else {
-			////// LOG ERROR!!!!!!
+			db.logger.Error("Error generating auto-log pass", "user", *uName, "error", devicePassErr)
 		}
 	}
 
@@ -362,16 +340,15 @@ func LoginClient(userName string, password string, deviceTag string, remMe bool,
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to automatically
 // log in a client when using the "Remember Me" SQL feature.
-func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string, helpers.GopherError) {
+func (db *Instance) AutoLoginClient(tag string, pass string, newPass string, dbID int) (string, helpers.GopherError) {
 	if checkStringSQLInjection(tag) {
 		return "", helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
 	}
 
 	//EXECUTE SELECT QUERY
 	var dPass string
-	db := database
 	tableName := tableAutologs
-	checkRows, checkErr := db.Query("Select " + autologsColumnDevicePass + " FROM " + tableName + " WHERE " + autologsColumnID + "=" + strconv.Itoa(dbID) + " AND " +
+	checkRows, checkErr := db.query("Select " + autologsColumnDevicePass + " FROM " + tableName + " WHERE " + autologsColumnID + "=" + strconv.Itoa(dbID) + " AND " +
 		autologsColumnDeviceTag + "=\"" + tag + "\" LIMIT 1;")
 	if checkErr != nil {
 		return "", helpers.NewError(errorInvalidAutoLog, helpers.ErrorDatabaseInvalidAutolog)
@@ -387,12 +364,12 @@ func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string,
 	//COMPARE PASSES
 	if pass != dPass {
 		//SOMEONE TRIED TO COMPROMISE THIS KEY PAIR. DELETE IT NOW.
-		RemoveAutoLog(dbID, tag)
+		db.RemoveAutoLog(dbID, tag)
 		return "", helpers.NewError(errorInvalidAutoLog, helpers.ErrorDatabaseInvalidAutolog)
 	}
 
 	//UPDATE TO NEW PASS
-	_, updateErr := db.Exec("UPDATE " + tableName + " SET " + autologsColumnDevicePass + "=\"" + newPass + "\" WHERE " + autologsColumnID + "=" + strconv.Itoa(dbID) + " AND " +
+	_, updateErr := db.exec("UPDATE " + tableName + " SET " + autologsColumnDevicePass + "=\"" + newPass + "\" WHERE " + autologsColumnID + "=" + strconv.Itoa(dbID) + " AND " +
 		autologsColumnDeviceTag + "=\"" + tag + "\" LIMIT 1;")
 	if updateErr != nil {
 		return "", helpers.NewError(errorInvalidAutoLog, helpers.ErrorDatabaseInvalidAutolog)
@@ -401,7 +378,7 @@ func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string,
 	//EVERYTHING WENT WELL, GET THE User's NAME
 	tableName = tableUsers
 	var userName string
-	userRows, usrErr := db.Query("Select " + usersColumnName + " FROM " + tableName + " WHERE " + usersColumnID + "=" + strconv.Itoa(dbID) + " LIMIT 1;")
+	userRows, usrErr := db.query("Select " + usersColumnName + " FROM " + tableName + " WHERE " + usersColumnID + "=" + strconv.Itoa(dbID) + " LIMIT 1;")
 	if usrErr != nil {
 		return "", helpers.NewError(errorInvalidAutoLog, helpers.ErrorDatabaseInvalidAutolog)
 	}
@@ -414,7 +391,7 @@ func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string,
 	userRows.Close()
 
 	//RUN CALLBACK
-	if LoginCallback != nil && !LoginCallback(userName, dbID, nil, nil) {
+	if db.LoginCallback != nil && !db.LoginCallback(userName, dbID, nil, nil) {
 		return "", helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 	}
 
@@ -430,11 +407,13 @@ func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string,
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Auto-login entries in the database
 // are automatically deleted when a User logs off, or are thought to be compromised by the server.
-func RemoveAutoLog(userID int, deviceTag string) {
+func (db *Instance) RemoveAutoLog(userID int, deviceTag string) {
 	if checkStringSQLInjection(deviceTag) {
 		return
 	}
-	database.Exec("DELETE FROM " + tableAutologs + " WHERE " + autologsColumnID + "=" + strconv.Itoa(userID) + " AND " + autologsColumnDeviceTag + "=\"" + deviceTag + "\" LIMIT 1;")
+	if _, err := db.exec("DELETE FROM " + tableAutologs + " WHERE " + autologsColumnID + "=" + strconv.Itoa(userID) + " AND " + autologsColumnDeviceTag + "=\"" + deviceTag + "\" LIMIT 1;"); err != nil {
+		db.logger.Error("Error removing auto-log entry", "userID", userID, "error", err)
+	}
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -445,7 +424,7 @@ func RemoveAutoLog(userID int, deviceTag string) {
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to change
 // a user's password when using the SQL features.
-func ChangePassword(userName string, password string, newPassword string, customCols map[string]interface{}) helpers.GopherError {
+func (db *Instance) ChangePassword(userName string, password string, newPassword string, customCols map[string]interface{}) helpers.GopherError {
 	if len(userName) == 0 {
 		return helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
 	} else if len(password) == 0 {
@@ -454,7 +433,7 @@ func ChangePassword(userName string, password string, newPassword string, custom
 		return helpers.NewError(errorRequiredNewPass, helpers.ErrorAuthRequiredNewPass)
 	} else if checkStringSQLInjection(userName) {
 		return helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
-	} else if !checkCustomRequirements(customCols, customPasswordChangeRequirements) {
+	} else if !checkCustomRequirements(customCols, db.customPasswordChangeRequirements) {
 		return helpers.NewError(errorIncorrectCols, helpers.ErrorAuthIncorrectCols)
 	}
 
@@ -472,7 +451,7 @@ func ChangePassword(userName string, password string, newPassword string, custom
 			selectQuery = selectQuery + key + ", "
 			//MAINTAIN THE ORDER IN WHICH THE COLUMNS WERE DECLARED VIA A SLICE
 			vals = append(vals, new(interface{}))
-			valsList = append(valsList, []interface{}{val, customAccountInfo[key].dataType, key})
+			valsList = append(valsList, []interface{}{val, db.customAccountInfo[key].dataType, key})
 		}
 	} else {
 		vals = make([]interface{}, 0, 2)
@@ -481,7 +460,7 @@ func ChangePassword(userName string, password string, newPassword string, custom
 	selectQuery = selectQuery[0:len(selectQuery)-2] + " FROM " + tableUsers + " WHERE " + usersColumnName + "=\"" + userName + "\" LIMIT 1;"
 
 	//EXECUTE SELECT QUERY
-	checkRows, err := database.Query(selectQuery)
+	checkRows, err := db.query(selectQuery)
 	if err != nil {
 		return helpers.NewError(errorIncorrectLogin, helpers.ErrorAuthIncorrectLogin)
 	}
@@ -503,7 +482,7 @@ func ChangePassword(userName string, password string, newPassword string, custom
 	}
 
 	//RUN CALLBACK
-	if PasswordChangeCallback != nil {
+	if db.PasswordChangeCallback != nil {
 		// GET THE RECEIVED COLUMN VALUES AS MAP
 		var receivedVals map[string]interface{} = make(map[string]interface{})
 		if customCols != nil {
@@ -515,19 +494,19 @@ func ChangePassword(userName string, password string, newPassword string, custom
 			}
 		}
 
-		if !PasswordChangeCallback(userName, dbIndex, receivedVals, customCols) {
+		if !db.PasswordChangeCallback(userName, dbIndex, receivedVals, customCols) {
 			return helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 		}
 	}
 
 	//ENCRYPT NEW PASSWORD
-	passHash, hashErr := helpers.EncryptString(newPassword, encryptionCost)
+	passHash, hashErr := helpers.EncryptString(newPassword, db.encryptionCost)
 	if hashErr != nil {
 		return helpers.NewError(hashErr.Error(), helpers.ErrorAuthEncryption)
 	}
 
 	//UPDATE THE PASSWORD
-	_, updateErr := database.Exec("UPDATE " + tableUsers + " SET " + usersColumnPassword + "=\"" + passHash + "\" WHERE " + usersColumnID + "=" + strconv.Itoa(dbIndex) + " LIMIT 1;")
+	_, updateErr := db.exec("UPDATE " + tableUsers + " SET " + usersColumnPassword + "=\"" + passHash + "\" WHERE " + usersColumnID + "=" + strconv.Itoa(dbIndex) + " LIMIT 1;")
 	if updateErr != nil {
 		return helpers.NewError(updateErr.Error(), helpers.ErrorAuthQuery)
 	}
@@ -544,7 +523,7 @@ func ChangePassword(userName string, password string, newPassword string, custom
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to change
 // a user's AccountInfoColumn when using the SQL features.
-func ChangeAccountInfo(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+func (db *Instance) ChangeAccountInfo(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
 	if len(userName) == 0 {
 		return helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
 	} else if len(password) == 0 {
@@ -553,7 +532,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 		return helpers.NewError(errorInsufficientCols, helpers.ErrorAuthInsufficientCols)
 	} else if checkStringSQLInjection(userName) {
 		return helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
-	} else if !checkCustomRequirements(customCols, customAccountInfoChangeRequirements) {
+	} else if !checkCustomRequirements(customCols, db.customAccountInfoChangeRequirements) {
 		return helpers.NewError(errorIncorrectCols, helpers.ErrorAuthIncorrectCols)
 	}
 
@@ -571,7 +550,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 			selectQuery = selectQuery + key + ", "
 			//MAINTAIN THE ORDER IN WHICH THE COLUMNS WERE DECLARED VIA A SLICE
 			vals = append(vals, new(interface{}))
-			valsList = append(valsList, []interface{}{val, customAccountInfo[key].dataType, key})
+			valsList = append(valsList, []interface{}{val, db.customAccountInfo[key].dataType, key})
 		}
 	} else {
 		vals = make([]interface{}, 0, 2)
@@ -580,7 +559,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 	selectQuery = selectQuery[0:len(selectQuery)-2] + " FROM " + tableUsers + " WHERE " + usersColumnName + "=\"" + userName + "\" LIMIT 1;"
 
 	//EXECUTE SELECT QUERY
-	checkRows, err := database.Query(selectQuery)
+	checkRows, err := db.query(selectQuery)
 	if err != nil {
 		return helpers.NewError(errorIncorrectLogin, helpers.ErrorAuthIncorrectLogin)
 	}
@@ -602,7 +581,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 	}
 
 	//RUN CALLBACK
-	if AccountInfoChangeCallback != nil {
+	if db.AccountInfoChangeCallback != nil {
 		// GET THE RECEIVED COLUMN VALUES AS MAP
 		var receivedVals map[string]interface{} = make(map[string]interface{})
 		if customCols != nil {
@@ -614,7 +593,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 			}
 		}
 
-		if !AccountInfoChangeCallback(userName, dbIndex, receivedVals, customCols) {
+		if !db.AccountInfoChangeCallback(userName, dbIndex, receivedVals, customCols) {
 			return helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 		}
 	}
@@ -634,7 +613,7 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 	updateQuery = updateQuery[0:len(updateQuery)-2] + " WHERE " + usersColumnID + "=" + strconv.Itoa(dbIndex) + " LIMIT 1;"
 
 	//EXECUTE THE UPDATE QUERY
-	_, updateErr := database.Exec(updateQuery)
+	_, updateErr := db.exec(updateQuery)
 	if updateErr != nil {
 		return helpers.NewError(updateErr.Error(), helpers.ErrorAuthQuery)
 	}
@@ -651,14 +630,14 @@ func ChangeAccountInfo(userName string, password string, customCols map[string]i
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to delete a
 // user's account when using the SQL features.
-func DeleteAccount(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+func (db *Instance) DeleteAccount(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
 	if len(userName) == 0 {
 		return helpers.NewError(errorRequiredName, helpers.ErrorAuthRequiredName)
 	} else if len(password) == 0 {
 		return helpers.NewError(errorRequiredPass, helpers.ErrorAuthRequiredPass)
 	} else if checkStringSQLInjection(userName) {
 		return helpers.NewError(errorMaliciousChars, helpers.ErrorAuthMaliciousChars)
-	} else if !checkCustomRequirements(customCols, customDeleteAccountRequirements) {
+	} else if !checkCustomRequirements(customCols, db.customDeleteAccountRequirements) {
 		return helpers.NewError(errorIncorrectCols, helpers.ErrorAuthIncorrectCols)
 	}
 
@@ -682,7 +661,7 @@ func DeleteAccount(userName string, password string, customCols map[string]inter
 	selectQuery = selectQuery[0:len(selectQuery)-2] + " FROM " + tableUsers + " WHERE " + usersColumnName + "=\"" + userName + "\" LIMIT 1;"
 
 	//EXECUTE SELECT QUERY
-	checkRows, err := database.Query(selectQuery)
+	checkRows, err := db.query(selectQuery)
 	if err != nil {
 		return helpers.NewError(errorIncorrectLogin, helpers.ErrorAuthIncorrectLogin)
 	}
@@ -704,7 +683,7 @@ func DeleteAccount(userName string, password string, customCols map[string]inter
 	}
 
 	//RUN CALLBACK
-	if DeleteAccountCallback != nil {
+	if db.DeleteAccountCallback != nil {
 		// GET THE RECEIVED COLUMN VALUES AS MAP
 		var receivedVals map[string]interface{} = make(map[string]interface{})
 		if customCols != nil {
@@ -716,16 +695,18 @@ func DeleteAccount(userName string, password string, customCols map[string]inter
 			}
 		}
 
-		if !DeleteAccountCallback(userName, dbIndex, receivedVals, customCols) {
+		if !db.DeleteAccountCallback(userName, dbIndex, receivedVals, customCols) {
 			return helpers.NewError(errorDenied, helpers.ErrorActionDenied)
 		}
 	}
 
 	//REMOVE INSTANCES FROM friends TABLE
-	database.Exec("DELETE FROM " + tableFriends + " WHERE " + friendsColumnUser + "=" + strconv.Itoa(dbIndex) + " OR " + friendsColumnFriend + "=" + strconv.Itoa(dbIndex) + ";")
+	if _, err := db.exec("DELETE FROM " + tableFriends + " WHERE " + friendsColumnUser + "=" + strconv.Itoa(dbIndex) + " OR " + friendsColumnFriend + "=" + strconv.Itoa(dbIndex) + ";"); err != nil {
+		db.logger.Error("Error removing friends of deleted account", "userID", dbIndex, "error", err)
+	}
 
 	//DELETE THE ACCOUNT
-	_, deleteErr := database.Exec("DELETE FROM " + tableUsers + " WHERE " + usersColumnID + "=" + strconv.Itoa(dbIndex) + " LIMIT 1;")
+	_, deleteErr := db.exec("DELETE FROM " + tableUsers + " WHERE " + usersColumnID + "=" + strconv.Itoa(dbIndex) + " LIMIT 1;")
 	if deleteErr != nil {
 		return helpers.NewError(deleteErr.Error(), helpers.ErrorAuthQuery)
 	}
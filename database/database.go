@@ -5,23 +5,62 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
 	_ "github.com/go-sql-driver/mysql" // Github project page specifies to use blank import
+	"github.com/hewiefreeman/GopherGameServer/helpers"
 	"strconv"
+	"strings"
+	"sync"
+	"time"
 )
 
-var (
+// Instance holds a database connection along with the AccountInfoColumns, custom requirements and callbacks
+// of a single server. The package-level functions all work on the default Instance, which is the one used
+// by the server when started with gopher.Start(). Servers made with gopher.NewServer() get their own Instance,
+// which you can retrieve with *Server.Database().
+type Instance struct {
 	//THE DATABASE
-	database *sql.DB
+	conn *sql.DB
+
+	logger  helpers.Logger
+	metrics *helpers.Metrics
 
 	//SERVER SETTINGS
-	serverStarted bool   = false
-	serverPaused  bool   = false
-	rememberMe    bool   = false
-	databaseName  string = "gopherDB"
-	inited        bool   = false
+	serverStarted bool
+	serverPaused  bool
+	rememberMe    bool
+	databaseName  string
+	inited        bool
+
+	recoveryTable    bool
+	recoveryTableMux sync.Mutex
+
+	encryptionCost    int
+	customLoginColumn string
+	customAccountInfo map[string]AccountInfoColumn
+
+	customLoginRequirements             map[string]struct{}
+	customSignupRequirements            map[string]struct{}
+	customPasswordChangeRequirements    map[string]struct{}
+	customAccountInfoChangeRequirements map[string]struct{}
+	customDeleteAccountRequirements     map[string]struct{}
+
+	// SignUpCallback is only for internal Gopher Game Server mechanics.
+	SignUpCallback func(string, map[string]interface{}) bool
+	// LoginCallback is only for internal Gopher Game Server mechanics.
+	LoginCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
+	// DeleteAccountCallback is only for internal Gopher Game Server mechanics.
+	DeleteAccountCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
+	// AccountInfoChangeCallback is only for internal Gopher Game Server mechanics.
+	AccountInfoChangeCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
+	// PasswordChangeCallback is only for internal Gopher Game Server mechanics.
+	PasswordChangeCallback func(string, int, map[string]interface{}, map[string]interface{}) bool
+}
+
+var (
+	defaultInstance *Instance = NewInstance()
 )
 
 //TABLE & COLUMN NAMES
@@ -29,6 +68,7 @@ const (
 	tableUsers    = "users"
 	tableFriends  = "friends"
 	tableAutologs = "autologs"
+	tableRecovery = "recovery"
 
 	//users TABLE COLUMNS
 	usersColumnID       = "_id"
@@ -44,14 +84,74 @@ const (
 	autologsColumnID         = "_id"
 	autologsColumnDeviceTag  = "dn"
 	autologsColumnDevicePass = "da"
+
+	//recovery TABLE COLUMNS
+	recoveryColumnID    = "_id"
+	recoveryColumnSaved = "saved"
+	recoveryColumnData  = "data"
 )
 
+// NewInstance makes a new database Instance with no connection. The connection is made once the server
+// owning the Instance starts with EnableSqlFeatures set in its ServerSettings.
+func NewInstance() *Instance {
+	return &Instance{
+		logger:            helpers.DefaultLogger(),
+		databaseName:      "gopherDB",
+		encryptionCost:    4,
+		customAccountInfo: make(map[string]AccountInfoColumn),
+
+		customLoginRequirements:             make(map[string]struct{}),
+		customSignupRequirements:            make(map[string]struct{}),
+		customPasswordChangeRequirements:    make(map[string]struct{}),
+		customAccountInfoChangeRequirements: make(map[string]struct{}),
+		customDeleteAccountRequirements:     make(map[string]struct{})}
+}
+
+// Default gets the default Instance, which all of the package-level functions work on.
+func Default() *Instance {
+	return defaultInstance
+}
+
+// SetLogger is only for internal Gopher Game Server mechanics.
+func (db *Instance) SetLogger(l helpers.Logger) {
+	db.logger = l
+}
+
+// SetMetrics is only for internal Gopher Game Server mechanics.
+func (db *Instance) SetMetrics(m *helpers.Metrics) {
+	db.metrics = m
+}
+
+// exec runs a query that returns no rows, and records it's latency and errors.
+func (db *Instance) exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	res, err := db.conn.Exec(query, args...)
+	db.observeQuery(query, start, err)
+	return res, err
+}
+
+// query runs a query that returns rows, and records it's latency and errors.
+func (db *Instance) query(query string, args ...interface{}) (*sql.Rows, error) {
+	start := time.Now()
+	rows, err := db.conn.Query(query, args...)
+	db.observeQuery(query, start, err)
+	return rows, err
+}
+
+func (db *Instance) observeQuery(query string, start time.Time, err error) {
+	statement := strings.ToUpper(strings.SplitN(strings.TrimSpace(query), " ", 2)[0])
+	db.metrics.Observe(helpers.MetricDBQueryDuration, time.Since(start).Seconds(), "query", statement)
+	if err != nil {
+		db.metrics.Inc(helpers.MetricDBQueryErrors, "query", statement)
+	}
+}
+
 // Init initializes the database connection and sets up the database according to your custom parameters.
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. If you want to enable SQL authorization
 // and friending, use the EnableSqlFeatures and corresponding options in ServerSetting.
-func Init(userName string, password string, dbName string, protocol string, ip string, port int, encryptCost int, remMe bool, custLoginCol string) error {
-	if inited {
+func (db *Instance) Init(userName string, password string, dbName string, protocol string, ip string, port int, encryptCost int, remMe bool, custLoginCol string) error {
+	if db.inited {
 		return errors.New("sql package is already initialized")
 	} else if len(userName) == 0 {
 		return errors.New("sql.Start() requires a user name")
@@ -60,62 +160,73 @@ func Init(userName string, password string, dbName string, protocol string, ip s
 	} else if len(userName) == 0 {
 		return errors.New("sql.Start() requires a database name")
 	} else if len(custLoginCol) > 0 {
-		if _, ok := customAccountInfo[custLoginCol]; !ok {
+		if _, ok := db.customAccountInfo[custLoginCol]; !ok {
 			return errors.New("The AccountInfoColumn '" + custLoginCol + "' does not exist. Use database.NewAccountInfoColumn() to make a column with that name.")
 		}
-		customLoginColumn = custLoginCol
+		db.customLoginColumn = custLoginCol
 	}
 
 	if encryptCost >= 4 && encryptCost <= 31 {
-		encryptionCost = encryptCost
+		db.encryptionCost = encryptCost
 	} else if encryptCost != 0 {
-		fmt.Println("EncryptionCost must be a minimum of 4, and max of 31. Setting to default: 4")
+		db.logger.Warn("EncryptionCost must be a minimum of 4, and max of 31. Setting to default: 4", "encryptionCost", encryptCost)
 	}
 
-	rememberMe = remMe
+	db.rememberMe = remMe
 
 	var err error
 
 	//OPEN THE DATABASE
-	database, err = sql.Open("mysql", userName+":"+password+"@"+protocol+"("+ip+":"+strconv.Itoa(port)+")/"+dbName)
+	db.conn, err = sql.Open("mysql", userName+":"+password+"@"+protocol+"("+ip+":"+strconv.Itoa(port)+")/"+dbName)
 	if err != nil {
 		return err
 	}
 	//NOTE: Open doesn't open a connection.
 	//MUST PING TO CHECK IF FOUND DATABASE
-	err = database.Ping()
+	err = db.conn.Ping()
 	if err != nil {
+		db.conn.Close()
 		return errors.New("Could not connect to database!")
 	}
 
 	if len(dbName) != 0 {
-		databaseName = dbName
+		db.databaseName = dbName
 	}
 
 	//CONFIGURE DATABASE
-	err = setUp()
+	err = db.setUp()
 	if err != nil {
+		db.conn.Close()
 		return err
 	}
 
 	//
-	inited = true
+	db.inited = true
 
 	//
 	return nil
 }
 
+// Ping checks that the database connection is still alive. Returns an error if the Instance was never initialized, or the
+// database could not be reached before the Context is done.
+func (db *Instance) Ping(ctx context.Context) error {
+	if !db.inited {
+		return errors.New("Database is not initialized")
+	}
+	return db.conn.PingContext(ctx)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 //   GET User's DATABASE INDEX   /////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // GetUserDatabaseIndex gets the database index of a User by their name.
-func GetUserDatabaseIndex(userName string) (int, error) {
+func (db *Instance) GetUserDatabaseIndex(userName string) (int, error) {
 	if checkStringSQLInjection(userName) {
 		return 0, errors.New("Malicious characters detected")
 	}
 	var id int
-	rows, err := database.Query("SELECT " + usersColumnID + " FROM " + tableUsers + " WHERE " + usersColumnName + "=\"" + userName + "\" LIMIT 1;")
+	rows, err := db.query("SELECT " + usersColumnID + " FROM " + tableUsers + " WHERE " + usersColumnName + "=\"" + userName + "\" LIMIT 1;")
 	if err != nil {
 		return 0, err
 	}
@@ -136,10 +247,8 @@ func GetUserDatabaseIndex(userName string) (int, error) {
 ///////////////////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // SetServerStarted is for Gopher Game Server internal mechanics only.
-func SetServerStarted(val bool) {
-	if !serverStarted {
-		serverStarted = val
-	}
+func (db *Instance) SetServerStarted(val bool) {
+	db.serverStarted = val
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -147,17 +256,17 @@ func SetServerStarted(val bool) {
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
 // Pause is only for internal Gopher Game Server mechanics.
-func Pause() {
-	if !serverPaused {
-		serverPaused = true
-		serverStarted = false
+func (db *Instance) Pause() {
+	if !db.serverPaused {
+		db.serverPaused = true
+		db.serverStarted = false
 	}
 }
 
 // Resume is only for internal Gopher Game Server mechanics.
-func Resume() {
-	if serverPaused {
-		serverStarted = true
-		serverPaused = false
+func (db *Instance) Resume() {
+	if db.serverPaused {
+		db.serverStarted = true
+		db.serverPaused = false
 	}
 }
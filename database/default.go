@@ -0,0 +1,161 @@
+package database
+
+import (
+	"context"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   DEFAULT Instance   //////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewAccountInfoColumn makes a new AccountInfoColumn on the default Instance. You can only make new AccountInfoColumns before starting the server.
+func NewAccountInfoColumn(name string, dataType int, maxSize int, precision int, notNull bool, unique bool, encrypt bool) error {
+	return defaultInstance.NewAccountInfoColumn(name, dataType, maxSize, precision, notNull, unique, encrypt)
+}
+
+// SetCustomSignupRequirements sets the required AccountInfoColumn names for processing a sign up request from a client
+// on the default Instance.
+func SetCustomSignupRequirements(columnNames ...string) error {
+	return defaultInstance.SetCustomSignupRequirements(columnNames...)
+}
+
+// SetCustomLoginRequirements sets the required AccountInfoColumn names for processing a login request from a client
+// on the default Instance.
+func SetCustomLoginRequirements(columnNames ...string) error {
+	return defaultInstance.SetCustomLoginRequirements(columnNames...)
+}
+
+// SetCustomPasswordChangeRequirements sets the required AccountInfoColumn names for processing a password change request from a client
+// on the default Instance.
+func SetCustomPasswordChangeRequirements(columnNames ...string) error {
+	return defaultInstance.SetCustomPasswordChangeRequirements(columnNames...)
+}
+
+// SetCustomAccountInfoChangeRequirements sets the required AccountInfoColumn names for processing an AccountInfoColumn change request from a client
+// on the default Instance.
+func SetCustomAccountInfoChangeRequirements(columnNames ...string) error {
+	return defaultInstance.SetCustomAccountInfoChangeRequirements(columnNames...)
+}
+
+// SetCustomDeleteAccountRequirements sets the required AccountInfoColumn names for processing a delete account request from a client
+// on the default Instance.
+func SetCustomDeleteAccountRequirements(columnNames ...string) error {
+	return defaultInstance.SetCustomDeleteAccountRequirements(columnNames...)
+}
+
+// GetUserDatabaseIndex gets the database index of a User by their name on the default Instance.
+func GetUserDatabaseIndex(userName string) (int, error) {
+	return defaultInstance.GetUserDatabaseIndex(userName)
+}
+
+// Ping checks that the default Instance's database connection is still alive. See *Instance.Ping for more details.
+func Ping(ctx context.Context) error {
+	return defaultInstance.Ping(ctx)
+}
+
+// Init initializes the default Instance's database connection.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. If you want to enable SQL authorization
+// and friending, use the EnableSqlFeatures and corresponding options in ServerSetting.
+func Init(userName string, password string, dbName string, protocol string, ip string, port int, encryptCost int, remMe bool, custLoginCol string) error {
+	return defaultInstance.Init(userName, password, dbName, protocol, ip, port, encryptCost, remMe, custLoginCol)
+}
+
+// SetServerStarted is for Gopher Game Server internal mechanics only.
+func SetServerStarted(val bool) {
+	defaultInstance.SetServerStarted(val)
+}
+
+// Pause is only for internal Gopher Game Server mechanics.
+func Pause() {
+	defaultInstance.Pause()
+}
+
+// Resume is only for internal Gopher Game Server mechanics.
+func Resume() {
+	defaultInstance.Resume()
+}
+
+// SignUpClient signs up the client on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to sign a
+// client up when the SQL features are enabled.
+func SignUpClient(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+	return defaultInstance.SignUpClient(userName, password, customCols)
+}
+
+// LoginClient logs in the client on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to log in a
+// client when the SQL features are enabled.
+func LoginClient(userName string, password string, deviceTag string, remMe bool, customCols map[string]interface{}) (string, int, string, helpers.GopherError) {
+	return defaultInstance.LoginClient(userName, password, deviceTag, remMe, customCols)
+}
+
+// AutoLoginClient logs in the client automatically on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to automatically
+// log in a client when the SQL features and RememberMe are enabled.
+func AutoLoginClient(tag string, pass string, newPass string, dbID int) (string, helpers.GopherError) {
+	return defaultInstance.AutoLoginClient(tag, pass, newPass, dbID)
+}
+
+// RemoveAutoLog removes an auto-log entry on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics.
+func RemoveAutoLog(userID int, deviceTag string) {
+	defaultInstance.RemoveAutoLog(userID, deviceTag)
+}
+
+// ChangePassword changes a client's password on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to change
+// a client's password when the SQL features are enabled.
+func ChangePassword(userName string, password string, newPassword string, customCols map[string]interface{}) helpers.GopherError {
+	return defaultInstance.ChangePassword(userName, password, newPassword, customCols)
+}
+
+// ChangeAccountInfo changes a client's AccountInfoColumn on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to change
+// a client's AccountInfoColumn when the SQL features are enabled.
+func ChangeAccountInfo(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+	return defaultInstance.ChangeAccountInfo(userName, password, customCols)
+}
+
+// DeleteAccount deletes a client's account on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to delete
+// a client's account when the SQL features are enabled.
+func DeleteAccount(userName string, password string, customCols map[string]interface{}) helpers.GopherError {
+	return defaultInstance.DeleteAccount(userName, password, customCols)
+}
+
+// FriendRequest stores the data for a friend request on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use *User.FriendRequest() instead.
+func FriendRequest(userIndex int, friendIndex int) error {
+	return defaultInstance.FriendRequest(userIndex, friendIndex)
+}
+
+// FriendRequestAccepted stores the data for an accepted friend request on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use *User.AcceptFriendRequest() instead.
+func FriendRequestAccepted(userIndex int, friendIndex int) error {
+	return defaultInstance.FriendRequestAccepted(userIndex, friendIndex)
+}
+
+// RemoveFriend removes a friendship on the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use *User.RemoveFriend() instead.
+func RemoveFriend(userIndex int, friendIndex int) error {
+	return defaultInstance.RemoveFriend(userIndex, friendIndex)
+}
+
+// GetFriends gets a User's friends from the default Instance.
+//
+// WARNING: This is only meant for internal Gopher Game Server mechanics. Use *User.Friends() instead.
+func GetFriends(userIndex int) (map[string]*Friend, error) {
+	return defaultInstance.GetFriends(userIndex)
+}
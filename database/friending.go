@@ -33,13 +33,13 @@ const (
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to send a
 // friend request when using the SQL features.
-func FriendRequest(userIndex int, friendIndex int) error {
-	_, insertErr := database.Exec("INSERT INTO " + tableFriends + " (" + friendsColumnUser + ", " + friendsColumnFriend + ", " + friendsColumnStatus + ") " +
+func (db *Instance) FriendRequest(userIndex int, friendIndex int) error {
+	_, insertErr := db.exec("INSERT INTO " + tableFriends + " (" + friendsColumnUser + ", " + friendsColumnFriend + ", " + friendsColumnStatus + ") " +
 		"VALUES (" + strconv.Itoa(userIndex) + ", " + strconv.Itoa(friendIndex) + ", " + strconv.Itoa(FriendStatusPending) + ");")
 	if insertErr != nil {
 		return insertErr
 	}
-	_, insertErr = database.Exec("INSERT INTO " + tableFriends + " (" + friendsColumnUser + ", " + friendsColumnFriend + ", " + friendsColumnStatus + ") " +
+	_, insertErr = db.exec("INSERT INTO " + tableFriends + " (" + friendsColumnUser + ", " + friendsColumnFriend + ", " + friendsColumnStatus + ") " +
 		"VALUES (" + strconv.Itoa(friendIndex) + ", " + strconv.Itoa(userIndex) + ", " + strconv.Itoa(FriendStatusRequested) + ");")
 	if insertErr != nil {
 		return insertErr
@@ -56,8 +56,8 @@ func FriendRequest(userIndex int, friendIndex int) error {
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to accept a
 // friend request when using the SQL features.
-func FriendRequestAccepted(userIndex int, friendIndex int) error {
-	_, updateErr := database.Exec("UPDATE " + tableFriends + " SET " + friendsColumnStatus + "=" + strconv.Itoa(FriendStatusAccepted) + " WHERE (" + friendsColumnUser + "=" + strconv.Itoa(userIndex) +
+func (db *Instance) FriendRequestAccepted(userIndex int, friendIndex int) error {
+	_, updateErr := db.exec("UPDATE " + tableFriends + " SET " + friendsColumnStatus + "=" + strconv.Itoa(FriendStatusAccepted) + " WHERE (" + friendsColumnUser + "=" + strconv.Itoa(userIndex) +
 		" AND " + friendsColumnFriend + "=" + strconv.Itoa(friendIndex) + ") OR (" + friendsColumnUser + "=" + strconv.Itoa(friendIndex) +
 		" AND " + friendsColumnFriend + "=" + strconv.Itoa(userIndex) + ");")
 	if updateErr != nil {
@@ -75,8 +75,8 @@ func FriendRequestAccepted(userIndex int, friendIndex int) error {
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the client APIs to remove a
 // friend when using the SQL features.
-func RemoveFriend(userIndex int, friendIndex int) error {
-	_, updateErr := database.Exec("DELETE FROM " + tableFriends + " WHERE (" + friendsColumnUser + "=" + strconv.Itoa(userIndex) + " AND " + friendsColumnFriend + "=" + strconv.Itoa(friendIndex) + ") OR (" +
+func (db *Instance) RemoveFriend(userIndex int, friendIndex int) error {
+	_, updateErr := db.exec("DELETE FROM " + tableFriends + " WHERE (" + friendsColumnUser + "=" + strconv.Itoa(userIndex) + " AND " + friendsColumnFriend + "=" + strconv.Itoa(friendIndex) + ") OR (" +
 		friendsColumnUser + "=" + strconv.Itoa(friendIndex) + " AND " + friendsColumnFriend + "=" + strconv.Itoa(userIndex) + ");")
 	if updateErr != nil {
 		return updateErr
@@ -93,11 +93,11 @@ func RemoveFriend(userIndex int, friendIndex int) error {
 //
 // WARNING: This is only meant for internal Gopher Game Server mechanics. Use the *User.Friends() function
 // instead to avoid errors when using the SQL features.
-func GetFriends(userIndex int) (map[string]*Friend, error) {
+func (db *Instance) GetFriends(userIndex int) (map[string]*Friend, error) {
 	var friends map[string]*Friend = make(map[string]*Friend)
 
 	//EXECUTE SELECT QUERY
-	friendRows, friendRowsErr := database.Query("Select " + friendsColumnFriend + ", " + friendsColumnStatus + " FROM " + tableFriends + " WHERE " + friendsColumnUser + "=" + strconv.Itoa(userIndex) + ";")
+	friendRows, friendRowsErr := db.query("Select " + friendsColumnFriend + ", " + friendsColumnStatus + " FROM " + tableFriends + " WHERE " + friendsColumnUser + "=" + strconv.Itoa(userIndex) + ";")
 	if friendRowsErr != nil {
 		return nil, friendRowsErr
 	}
@@ -111,7 +111,7 @@ func GetFriends(userIndex int) (map[string]*Friend, error) {
 			return nil, scanErr
 		}
 		//
-		friendInfoRows, friendInfoErr := database.Query("Select " + usersColumnName + " FROM " + tableUsers + " WHERE " + usersColumnID + "=" + strconv.Itoa(friendID) + " LIMIT 1;")
+		friendInfoRows, friendInfoErr := db.query("Select " + usersColumnName + " FROM " + tableUsers + " WHERE " + usersColumnID + "=" + strconv.Itoa(friendID) + " LIMIT 1;")
 		if friendInfoErr != nil {
 			friendRows.Close()
 			return nil, friendInfoErr
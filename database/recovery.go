@@ -0,0 +1,116 @@
+package database
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// RecoveryState describes a server state saved in the recovery table.
+type RecoveryState struct {
+	ID    int
+	Saved time.Time
+	Size  int
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   RECOVERY TABLE   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// The recovery table is only made once a server saves it's state to the database, so servers that don't
+// use SQL recovery storage never get one.
+func (db *Instance) setUpRecovery() error {
+	if !db.inited {
+		return errors.New("Database is not initialized")
+	}
+	db.recoveryTableMux.Lock()
+	defer db.recoveryTableMux.Unlock()
+	if db.recoveryTable {
+		return nil
+	}
+	if _, err := db.exec("CREATE TABLE IF NOT EXISTS " + tableRecovery + " (" +
+		recoveryColumnID + " INTEGER NOT NULL AUTO_INCREMENT, " +
+		recoveryColumnSaved + " BIGINT NOT NULL, " +
+		recoveryColumnData + " LONGBLOB NOT NULL, " +
+		"PRIMARY KEY (" + recoveryColumnID + "));"); err != nil {
+		return err
+	}
+	db.recoveryTable = true
+	return nil
+}
+
+// SaveRecoveryState is only for internal Gopher Game Server mechanics.
+func (db *Instance) SaveRecoveryState(data []byte) (RecoveryState, error) {
+	if err := db.setUpRecovery(); err != nil {
+		return RecoveryState{}, err
+	}
+	saved := time.Now()
+	res, err := db.exec("INSERT INTO "+tableRecovery+" ("+recoveryColumnSaved+", "+recoveryColumnData+") VALUES (?, ?);", saved.Unix(), data)
+	if err != nil {
+		return RecoveryState{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return RecoveryState{}, err
+	}
+	return RecoveryState{ID: int(id), Saved: saved, Size: len(data)}, nil
+}
+
+// GetRecoveryState is only for internal Gopher Game Server mechanics. An id of 0 gets the newest state.
+func (db *Instance) GetRecoveryState(id int) ([]byte, error) {
+	if err := db.setUpRecovery(); err != nil {
+		return nil, err
+	}
+	var rows *sql.Rows
+	var err error
+	if id == 0 {
+		rows, err = db.query("SELECT " + recoveryColumnData + " FROM " + tableRecovery + " ORDER BY " + recoveryColumnID + " DESC LIMIT 1;")
+	} else {
+		rows, err = db.query("SELECT "+recoveryColumnData+" FROM "+tableRecovery+" WHERE "+recoveryColumnID+"=? LIMIT 1;", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	if !rows.Next() {
+		return nil, errors.New("Recovery state does not exist")
+	}
+	var data []byte
+	if err = rows.Scan(&data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// GetRecoveryStates is only for internal Gopher Game Server mechanics. The states are listed newest first.
+func (db *Instance) GetRecoveryStates() ([]RecoveryState, error) {
+	if err := db.setUpRecovery(); err != nil {
+		return nil, err
+	}
+	rows, err := db.query("SELECT " + recoveryColumnID + ", " + recoveryColumnSaved + ", LENGTH(" + recoveryColumnData + ") FROM " + tableRecovery +
+		" ORDER BY " + recoveryColumnID + " DESC;")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var states []RecoveryState
+	for rows.Next() {
+		var state RecoveryState
+		var saved int64
+		if err = rows.Scan(&state.ID, &saved, &state.Size); err != nil {
+			return nil, err
+		}
+		state.Saved = time.Unix(saved, 0)
+		states = append(states, state)
+	}
+	return states, rows.Err()
+}
+
+// DeleteRecoveryState is only for internal Gopher Game Server mechanics.
+func (db *Instance) DeleteRecoveryState(id int) error {
+	if err := db.setUpRecovery(); err != nil {
+		return err
+	}
+	_, err := db.exec("DELETE FROM "+tableRecovery+" WHERE "+recoveryColumnID+"=?;", id)
+	return err
+}
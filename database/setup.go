@@ -1,39 +1,38 @@
 package database
 
 import (
-	"fmt"
 	"strconv"
 )
 
 // Configures the SQL database for Gopher Game Server
-func setUp() error {
+func (db *Instance) setUp() error {
 	// Check if the users table has been created
-	_, checkErr := database.Exec("SELECT " + usersColumnName + " FROM " + tableUsers + " WHERE " + usersColumnID + "=1;")
+	_, checkErr := db.exec("SELECT " + usersColumnName + " FROM " + tableUsers + " WHERE " + usersColumnID + "=1;")
 	if checkErr != nil {
-		fmt.Println("Creating \"" + tableUsers + "\" table...")
+		db.logger.Info("Creating \"" + tableUsers + "\" table...")
 		// Make the users table
-		if cErr := createUserTableSQL(); cErr != nil {
+		if cErr := db.createUserTableSQL(); cErr != nil {
 			return cErr
 		}
 	}
 	// Check for new custom AccountInfoColumn items
-	if newItemsErr := addNewCustomItemsSQL(); newItemsErr != nil {
+	if newItemsErr := db.addNewCustomItemsSQL(); newItemsErr != nil {
 		return newItemsErr
 	}
 
-	if rememberMe {
+	if db.rememberMe {
 		// Check if autologs table has been made
-		_, checkErr := database.Exec("SELECT " + autologsColumnID + " FROM " + tableAutologs + " WHERE " + autologsColumnID + "=1;")
+		_, checkErr := db.exec("SELECT " + autologsColumnID + " FROM " + tableAutologs + " WHERE " + autologsColumnID + "=1;")
 		if checkErr != nil {
-			fmt.Println("Making autologs table...")
-			if cErr := createAutologsTableSQL(); cErr != nil {
+			db.logger.Info("Making autologs table...")
+			if cErr := db.createAutologsTableSQL(); cErr != nil {
 				return cErr
 			}
 		}
 	}
 	// Make sure customLoginColumn is unique if it is set
-	if len(customLoginColumn) > 0 {
-		_, alterErr := database.Exec("ALTER TABLE " + tableUsers + " ADD UNIQUE (" + customLoginColumn + ");")
+	if len(db.customLoginColumn) > 0 {
+		_, alterErr := db.exec("ALTER TABLE " + tableUsers + " ADD UNIQUE (" + db.customLoginColumn + ");")
 		if alterErr != nil {
 			return alterErr
 		}
@@ -43,14 +42,14 @@ func setUp() error {
 	return nil
 }
 
-func createUserTableSQL() error {
+func (db *Instance) createUserTableSQL() error {
 	createQuery := "CREATE TABLE " + tableUsers + " (" +
 	usersColumnID + " INTEGER NOT NULL AUTO_INCREMENT, " +
 	usersColumnName + " VARCHAR(255) UNIQUE NOT NULL, " +
 	usersColumnPassword + " VARCHAR(255) NOT NULL, "
 
 	// Append custom AccountInfoColumn items
-	for key, val := range customAccountInfo {
+	for key, val := range db.customAccountInfo {
 		createQuery = createQuery + key + " " + dataTypes[val.dataType]
 		// Check for maxSize/precision
 		if isSizeDataType(val.dataType) {
@@ -73,19 +72,19 @@ func createUserTableSQL() error {
 	createQuery = createQuery + "PRIMARY KEY (" + usersColumnID + "));"
 
 	// Execute users table query
-	_, createErr := database.Exec(createQuery)
+	_, createErr := db.exec(createQuery)
 	if createErr != nil {
 		return createErr
 	}
 
 	// Adjust auto-increment to 1
-	_, adjustErr := database.Exec("ALTER TABLE " + tableUsers + " AUTO_INCREMENT=1;")
+	_, adjustErr := db.exec("ALTER TABLE " + tableUsers + " AUTO_INCREMENT=1;")
 	if adjustErr != nil {
 		return adjustErr
 	}
 
 	// Make friends table
-	if _, friendsErr := database.Exec("CREATE TABLE " + tableFriends + " (" +
+	if _, friendsErr := db.exec("CREATE TABLE " + tableFriends + " (" +
 		friendsColumnUser + " INTEGER NOT NULL, " +
 		friendsColumnFriend + " INTEGER NOT NULL, " +
 		friendsColumnStatus + " INTEGER NOT NULL" +
@@ -94,8 +93,8 @@ func createUserTableSQL() error {
 		return friendsErr
 	}
 
-	if rememberMe {
-		if cErr := createAutologsTableSQL(); cErr != nil {
+	if db.rememberMe {
+		if cErr := db.createAutologsTableSQL(); cErr != nil {
 			return cErr
 		}
 	}
@@ -103,8 +102,8 @@ func createUserTableSQL() error {
 	return nil
 }
 
-func createAutologsTableSQL() error {
-	if _, aErr := database.Exec("CREATE TABLE " + tableAutologs + " (" +
+func (db *Instance) createAutologsTableSQL() error {
+	if _, aErr := db.exec("CREATE TABLE " + tableAutologs + " (" +
 		autologsColumnID + " INTEGER NOT NULL, " +
 		autologsColumnDevicePass + " VARCHAR(255) NOT NULL, " +
 		autologsColumnDeviceTag + " VARCHAR(255) NOT NULL, " +
@@ -115,13 +114,13 @@ func createAutologsTableSQL() error {
 	return nil
 }
 
-func addNewCustomItemsSQL() error {
+func (db *Instance) addNewCustomItemsSQL() error {
 	query := "ALTER TABLE " + tableUsers + " "
 	var execQuery bool
 	//
-	for key, val := range customAccountInfo {
+	for key, val := range db.customAccountInfo {
 		// Check if item exists
-		checkRows, err := database.Query("SHOW COLUMNS FROM " + tableUsers + " LIKE '" + key + "';")
+		checkRows, err := db.query("SHOW COLUMNS FROM " + tableUsers + " LIKE '" + key + "';")
 		if err != nil {
 			return err
 		}
@@ -130,7 +129,7 @@ func addNewCustomItemsSQL() error {
 		_, colsErr := checkRows.Columns()
 		if colsErr != nil {
 			// The item doesn't exist yet...
-			fmt.Println("Adding AccountInfoColumn '" + key + "'...")
+			db.logger.Info("Adding AccountInfoColumn '" + key + "'...")
 			query = query + "ADD COLUMN " + key + " " + dataTypes[val.dataType]
 			if isSizeDataType(val.dataType) {
 				query = query + "(" + strconv.Itoa(val.maxSize) + ")"
@@ -154,7 +153,7 @@ func addNewCustomItemsSQL() error {
 	if execQuery {
 		// Make new columns
 		query = query[0:len(query)-2] + ";"
-		_, colsErr := database.Exec(query)
+		_, colsErr := db.exec(query)
 		if colsErr != nil {
 			return colsErr
 		}
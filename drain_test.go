@@ -0,0 +1,101 @@
+package gopher
+
+import (
+	"encoding/json"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"testing"
+	"time"
+)
+
+func TestDrainAndShutdown(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		UserRoomControl:  true,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	server.Core().NewRoomType("game", false)
+	runTestServer(t, server)
+	stopped := server.stoppedChan()
+	if _, err := server.Core().NewRoom("match", "game", false, 0, ""); err != nil {
+		t.Fatal(err)
+	}
+
+	// next gets the next message from a client with the key, skipping any others
+	next := func(conn *testConn, key string) interface{} {
+		t.Helper()
+		for {
+			select {
+			case message := <-conn.out:
+				var m map[string]interface{}
+				j, _ := json.Marshal(message)
+				json.Unmarshal(j, &m)
+				if val, ok := m[key]; ok {
+					return val
+				}
+			case <-time.After(time.Second * 5):
+				t.Fatal("Didn't get a message with", key)
+				return nil
+			}
+		}
+	}
+
+	// Log in, and play in a Room
+	conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 16), closed: make(chan struct{})}
+	go server.ServeClient(conn)
+	defer conn.Close()
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bot", "g": true}}
+	next(conn, helpers.ServerActionClientActionResponse)
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionJoinRoom, "P": "match"}
+	next(conn, helpers.ServerActionClientActionResponse)
+	if server.Core().ActiveRoomCount() != 1 {
+		t.Fatal("Client didn't join the Room")
+	}
+
+	// The drain macro returns right away, and clients are told about the shut-down
+	result, shutdown, err := server.runMacro("drain 30")
+	if err != nil || shutdown != nil {
+		t.Fatalf("Drain macro got %v, %v", result, err)
+	}
+	if notice := next(conn, helpers.ServerActionShutdownNotice); notice != float64(30) {
+		t.Errorf("Got shut-down notice %v", notice)
+	}
+	if state := server.State(); state != ServerStateDraining {
+		t.Errorf("Server is %v while draining", state)
+	}
+	if _, _, err = server.runMacro("drain 30"); err == nil {
+		t.Error("Draining twice should fail")
+	}
+
+	// New logins and Rooms from clients are refused, but the server can still make Rooms
+	late := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 16), closed: make(chan struct{})}
+	go server.ServeClient(late)
+	late.in <- map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "late", "g": true}}
+	res, _ := next(late, helpers.ServerActionClientActionResponse).(map[string]interface{})
+	if res["e"] == nil {
+		t.Errorf("Login while draining got %v", res)
+	}
+	late.Close()
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionCreateRoom, "P": map[string]interface{}{"n": "rematch", "t": "game", "m": 2}}
+	res, _ = next(conn, helpers.ServerActionClientActionResponse).(map[string]interface{})
+	if e, _ := res["e"].(map[string]interface{}); e["id"] != float64(helpers.ErrorServerDraining) {
+		t.Errorf("Client made a Room while draining, got %v", res)
+	}
+	if _, err = server.Core().NewRoom("rematch", "game", false, 0, ""); err != nil {
+		t.Error(err)
+	}
+
+	// Shuts down once the last Room is empty
+	select {
+	case <-stopped:
+		t.Fatal("Server shut down with a Room in play")
+	case <-time.After(time.Millisecond * 1500):
+	}
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionLeaveRoom}
+	select {
+	case <-stopped:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Server didn't shut down after the last Room emptied")
+	}
+}
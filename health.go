@@ -0,0 +1,117 @@
+package gopher
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+const (
+	healthPath = "/healthz"
+	readyPath  = "/readyz"
+)
+
+// Server states reported by the health check endpoints
+const (
+	ServerStateStopped  = "stopped"
+	ServerStateRunning  = "running"
+	ServerStatePaused   = "paused"
+	ServerStateDraining = "draining"
+	ServerStateStopping = "stopping"
+)
+
+var (
+	// HOW LONG THE READINESS CHECK WAITS FOR THE DATABASE
+	healthPingTimeout time.Duration = time.Second * 2
+)
+
+// HealthStatus is the JSON body sent by the health check endpoints.
+type HealthStatus struct {
+	Status   string `json:"status"`             // "ok" or "unavailable"
+	State    string `json:"state"`              // One of the ServerState constants
+	Database string `json:"database,omitempty"` // "ok", or the database error. Empty when SQL features are disabled.
+}
+
+// State gets the Server's lifecycle state, which is one of the ServerState constants.
+func (s *Server) State() string {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	if s.serverStopping {
+		return ServerStateStopping
+	} else if s.serverDraining {
+		return ServerStateDraining
+	} else if s.serverPaused {
+		return ServerStatePaused
+	} else if s.serverStarted {
+		return ServerStateRunning
+	}
+	return ServerStateStopped
+}
+
+// State gets the default server's lifecycle state. See *Server.State() for more details.
+func State() string {
+	return defaultServer.State()
+}
+
+// HealthHandler gets an http.Handler for the Server's liveness check. It responds with 200 (OK) while the Server is running, paused, or
+// draining, and 503 (Service Unavailable) when it is stopped or shutting down. The handler is served on '/healthz' automatically when
+// EnableHealthChecks is set in ServerSettings, but you can also use it with your own HTTP server.
+func (s *Server) HealthHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := HealthStatus{State: s.State()}
+		ok := status.State != ServerStateStopped && status.State != ServerStateStopping
+		writeHealthStatus(w, status, ok)
+	})
+}
+
+// HealthHandler gets the liveness check http.Handler for the default server. See *Server.HealthHandler() for more details.
+func HealthHandler() http.Handler {
+	return defaultServer.HealthHandler()
+}
+
+// ReadinessHandler gets an http.Handler for the Server's readiness check. It responds with 200 (OK) only when the Server is running and,
+// with SQL features enabled, the database answers a ping. A paused, draining, or stopping Server responds with 503 (Service Unavailable)
+// so new players are routed to other servers. The handler is served on '/readyz' automatically when EnableHealthChecks is set in
+// ServerSettings, but you can also use it with your own HTTP server.
+func (s *Server) ReadinessHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := HealthStatus{State: s.State()}
+		ok := status.State == ServerStateRunning
+		if settings := s.getSettings(); settings != nil && settings.EnableSqlFeatures {
+			ctx, cancel := context.WithTimeout(r.Context(), healthPingTimeout)
+			err := s.database.Ping(ctx)
+			cancel()
+			if err != nil {
+				status.Database = err.Error()
+				ok = false
+			} else {
+				status.Database = "ok"
+			}
+		}
+		writeHealthStatus(w, status, ok)
+	})
+}
+
+// ReadinessHandler gets the readiness check http.Handler for the default server. See *Server.ReadinessHandler() for more details.
+func ReadinessHandler() http.Handler {
+	return defaultServer.ReadinessHandler()
+}
+
+func (s *Server) handleHealthChecks(mux *http.ServeMux) {
+	mux.Handle(healthPath, s.HealthHandler())
+	mux.Handle(readyPath, s.ReadinessHandler())
+}
+
+func writeHealthStatus(w http.ResponseWriter, status HealthStatus, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if ok {
+		status.Status = "ok"
+		w.WriteHeader(http.StatusOK)
+	} else {
+		status.Status = "unavailable"
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(status)
+}
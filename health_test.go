@@ -0,0 +1,37 @@
+package gopher
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestHealthChecks(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:         "!server!",
+		HostName:           "localhost",
+		IP:                 "localhost",
+		EnableHealthChecks: true,
+		AdminLogin:         "admin",
+		AdminPassword:      "password"})
+	addr := runTestServer(t, server)
+
+	check := func(path string, want int) {
+		res, err := http.Get("http://" + addr + path)
+		if err != nil {
+			t.Error(err)
+			return
+		}
+		res.Body.Close()
+		if res.StatusCode != want {
+			t.Errorf("%v responded with %v while %v, expected %v", path, res.StatusCode, server.State(), want)
+		}
+	}
+	check("/healthz", http.StatusOK)
+	check("/readyz", http.StatusOK)
+	server.Pause()
+	check("/healthz", http.StatusOK)
+	check("/readyz", http.StatusServiceUnavailable)
+	server.Resume()
+	check("/readyz", http.StatusOK)
+
+}
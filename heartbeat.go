@@ -0,0 +1,177 @@
+package gopher
+
+import (
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"sync"
+	"time"
+)
+
+var (
+	// HOW OFTEN CONNECTIONS ARE CHECKED FOR PINGS AND TIMEOUTS
+	heartbeatCheckInterval time.Duration = time.Second
+)
+
+// PingTransport is a ClientTransport with it's own way of pinging clients, like websocket ping and pong control messages. The Server
+// pings clients every PingInterval (see ServerSettings) with Ping, and the transport must call the function given to OnPong when the
+// client answers. Clients of a ClientTransport that isn't a PingTransport are sent a helpers.ServerActionPing message instead, and
+// must answer with a helpers.ClientActionPong client action.
+type PingTransport interface {
+	ClientTransport
+	Ping() error
+	OnPong(pong func())
+}
+
+// connState is the heartbeat state of a client connection.
+type connState struct {
+	lastRead   time.Time // LAST CLIENT ACTION OR PONG
+	lastAction time.Time // LAST CLIENT ACTION
+	lastPing   time.Time
+	pingSent   time.Time // ZERO WHEN NO PING IS WAITING FOR A PONG
+	rtt        time.Duration
+	mux        sync.Mutex
+}
+
+func newConnState() *connState {
+	now := time.Now()
+	return &connState{lastRead: now, lastAction: now, lastPing: now}
+}
+
+// read records a client action from the client.
+func (c *connState) read() {
+	now := time.Now()
+	c.mux.Lock()
+	c.lastRead = now
+	c.lastAction = now
+	c.mux.Unlock()
+}
+
+// heard records that the client sent something other than a client action, like an empty UDP datagram.
+func (c *connState) heard() {
+	now := time.Now()
+	c.mux.Lock()
+	c.lastRead = now
+	c.mux.Unlock()
+}
+
+// pong records a pong from the client, and gets the measured round-trip time.
+func (c *connState) pong() time.Duration {
+	now := time.Now()
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.lastRead = now
+	if c.pingSent.IsZero() {
+		return 0
+	}
+	c.rtt = now.Sub(c.pingSent)
+	c.pingSent = time.Time{}
+	return c.rtt
+}
+
+// receive reads the next client action from a client, and records it and any pongs that come before it.
+func (s *Server) receive(conn ClientTransport, state *connState, action *clientAction) error {
+	for {
+		if err := conn.Receive(action); err != nil {
+			return err
+		}
+		if action.A != helpers.ClientActionPong {
+			break
+		}
+		s.pong(state)
+		*action = clientAction{}
+	}
+	state.read()
+	return nil
+}
+
+func (s *Server) pong(state *connState) {
+	if rtt := state.pong(); rtt > 0 {
+		s.metrics.Observe(helpers.MetricClientRTT, rtt.Seconds())
+	}
+}
+
+// heartbeat pings clients every PingInterval, and drops the connections of clients that don't answer within PongTimeout, or are past
+// the ReadTimeout or IdleTimeout. Dropped clients are logged out the same way as clients that disconnect.
+func (s *Server) heartbeat(done chan struct{}) {
+	ticker := time.NewTicker(heartbeatCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+		settings := s.getSettings()
+		pingInterval := time.Duration(settings.PingInterval) * time.Second
+		pongTimeout := time.Duration(settings.PongTimeout) * time.Second
+		if pongTimeout == 0 {
+			pongTimeout = pingInterval
+		}
+		readTimeout := time.Duration(settings.ReadTimeout) * time.Second
+		idleTimeout := time.Duration(settings.IdleTimeout) * time.Second
+		if pingInterval == 0 && readTimeout == 0 && idleTimeout == 0 {
+			continue
+		}
+
+		now := time.Now()
+		for conn, state := range s.conns.states() {
+			var reason string
+			ping := false
+			state.mux.Lock()
+			if readTimeout > 0 && now.Sub(state.lastRead) > readTimeout {
+				reason = "read timeout"
+			} else if idleTimeout > 0 && now.Sub(state.lastAction) > idleTimeout {
+				reason = "idle timeout"
+			} else if pingInterval > 0 && !state.pingSent.IsZero() && now.Sub(state.pingSent) > pongTimeout {
+				reason = "pong timeout"
+			} else if pingInterval > 0 && state.pingSent.IsZero() && now.Sub(state.lastPing) >= pingInterval {
+				state.pingSent = now
+				state.lastPing = now
+				ping = true
+			}
+			state.mux.Unlock()
+
+			if reason != "" {
+				// Closing the connection ends it's clientActionListener, which logs the User out
+				s.logger.Info("Dropping client connection", "address", conn.RemoteAddr(), "reason", reason)
+				s.metrics.Inc(helpers.MetricConnectionsDropped, "reason", reason)
+				go conn.Close()
+			} else if ping {
+				go s.ping(conn)
+			}
+		}
+	}
+}
+
+func (s *Server) ping(conn ClientTransport) {
+	var err error
+	if p, ok := conn.(PingTransport); ok {
+		err = p.Ping()
+	} else {
+		err = s.writeMessage(conn, map[string]interface{}{helpers.ServerActionPing: nil})
+	}
+	if err != nil {
+		s.logger.Warn("Error pinging client", "address", conn.RemoteAddr(), "error", err)
+	}
+}
+
+// ClientRTT gets the round-trip time to a client measured by the last ping, or 0 if the client hasn't answered a ping yet. Clients
+// are only pinged when PingInterval is set in ServerSettings. You can get a User's ClientConn with *User.Socket().
+func (s *Server) ClientRTT(conn core.ClientConn) time.Duration {
+	transport, ok := conn.(ClientTransport)
+	if !ok {
+		return 0
+	}
+	state := s.conns.state(transport)
+	if state == nil {
+		return 0
+	}
+	state.mux.Lock()
+	defer state.mux.Unlock()
+	return state.rtt
+}
+
+// ClientRTT gets the round-trip time to a client of the default server. See *Server.ClientRTT() for more details.
+func ClientRTT(conn core.ClientConn) time.Duration {
+	return defaultServer.ClientRTT(conn)
+}
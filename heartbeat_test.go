@@ -0,0 +1,48 @@
+package gopher
+
+import (
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"testing"
+	"time"
+)
+
+func TestHeartbeat(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		PingInterval:     1,
+		PongTimeout:      1,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	runTestServer(t, server)
+
+	conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 1), closed: make(chan struct{})}
+	go server.ServeClient(conn)
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bot", "g": true}}
+	<-conn.out
+
+	// Answer a ping
+	ping, _ := (<-conn.out).(map[string]interface{})
+	if _, ok := ping[helpers.ServerActionPing]; !ok {
+		t.Fatalf("Expected a ping, got %v", ping)
+	}
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionPong}
+	time.Sleep(time.Millisecond * 100)
+	user, _ := server.Core().GetUser("bot")
+	if server.ClientRTT(user.Socket("")) <= 0 {
+		t.Error("Round-trip time wasn't measured")
+	}
+
+	// Stop answering, and get dropped
+	select {
+	case <-conn.closed:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Dead connection wasn't dropped")
+	}
+	time.Sleep(time.Millisecond * 100)
+	if server.Core().UserCount() != 0 || server.ClientsConnected() != 0 {
+		t.Error("Dead connection wasn't logged out")
+	}
+
+}
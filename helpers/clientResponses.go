@@ -25,6 +25,8 @@ const (
 	ClientActionRemoveFriend      = "fr"
 	ClientActionSetVariable       = "vs"
 	ClientActionSetVariables      = "vx"
+	ClientActionUDPToken          = "u"
+	ClientActionPong              = "po"
 )
 
 //BUILT-IN SERVER ACTION RESPONSES
@@ -49,6 +51,8 @@ const (
 	ServerActionAutoLoginFailed            = "af"
 	ServerActionAutoLoginNotFiled          = "ai"
 	ServerActionWebRTCOffer                = "wo"
+	ServerActionShutdownNotice             = "sd"
+	ServerActionPing                       = "pi"
 )
 
 // MakeClientResponse is used for Gopher Game Server inner mechanics only.
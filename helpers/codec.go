@@ -0,0 +1,89 @@
+package helpers
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+)
+
+// Codec encodes and decodes the messages sent between the server and it's clients. Clients pick a Codec by it's name with the
+// websocket subprotocol (ex: `new WebSocket(url, ["msgpack"])`), and clients that don't ask for one use CodecJSON.
+//
+// Messages decode the same way with every Codec: objects as map[string]interface{}, arrays as []interface{}, and numbers
+// as float64, so CustomClientActions and callbacks work unchanged over any Codec.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+	// Binary tells if the Codec's messages are sent as binary websocket messages, rather than text.
+	Binary() bool
+}
+
+// Built-in Codec names
+const (
+	CodecJSON        = "json"
+	CodecMessagePack = "msgpack"
+)
+
+var (
+	codecs map[string]Codec = map[string]Codec{
+		CodecMessagePack: MessagePackCodec{},
+		CodecJSON:        JSONCodec{},
+	}
+	codecNames []string = []string{CodecMessagePack, CodecJSON} // IN ORDER OF PREFERENCE
+	codecsMux  sync.Mutex
+)
+
+// RegisterCodec adds a Codec that clients can ask for with the websocket subprotocol. Codecs must be registered before starting the server.
+func RegisterCodec(name string, codec Codec) error {
+	if len(name) == 0 || codec == nil {
+		return errors.New("RegisterCodec() requires a name and a Codec")
+	}
+	codecsMux.Lock()
+	defer codecsMux.Unlock()
+	if _, ok := codecs[name]; ok {
+		return errors.New("The Codec '" + name + "' is already registered")
+	}
+	codecs[name] = codec
+	codecNames = append(codecNames, name)
+	return nil
+}
+
+// CodecNames gets the names of all Codecs, in order of preference.
+func CodecNames() []string {
+	codecsMux.Lock()
+	defer codecsMux.Unlock()
+	return append([]string(nil), codecNames...)
+}
+
+// GetCodec gets a Codec by it's name. Unknown names (including the empty name of connections without a subprotocol) get the JSON Codec.
+func GetCodec(name string) Codec {
+	codecsMux.Lock()
+	codec, ok := codecs[name]
+	codecsMux.Unlock()
+	if !ok {
+		return JSONCodec{}
+	}
+	return codec
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   JSON   //////////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// JSONCodec is the default Codec.
+type JSONCodec struct{}
+
+// Marshal encodes a message as JSON.
+func (JSONCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes a JSON message.
+func (JSONCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+// Binary is false, JSON messages are sent as text.
+func (JSONCodec) Binary() bool {
+	return false
+}
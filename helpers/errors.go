@@ -60,8 +60,11 @@ const (
 	ErrorAuthConversion         // 1048. There was an error while converting data to be stored on the database
 
 	// Misc errors
-	ErrorActionDenied // 1049. A callback has denied the server action
-	ErrorServerPaused // 1050. The server is paused
+	ErrorActionDenied   // 1049. A callback has denied the server action
+	ErrorServerPaused   // 1050. The server is paused
+	ErrorServerDraining // 1051. The server is shutting down
+	ErrorAuthBanned     // 1052. The user name is banned from the server
+	ErrorAuthMaxConns   // 1053. The User is already logged in on the maximum amount of connections (see MaxUserConns in ServerSettings)
 )
 
 // NewError creates a new GopherError.
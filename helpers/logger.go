@@ -0,0 +1,99 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Log levels
+const (
+	LogLevelDebug = iota
+	LogLevelInfo
+	LogLevelWarn
+	LogLevelError
+)
+
+var logLevelNames []string = []string{"DEBUG", "INFO", "WARN", "ERROR"}
+
+// Logger is used by the server to log diagnostics and errors. The keyVals are key/value pairs that give more detail about the message,
+// with keys being strings, for instance:
+//
+//	logger.Error("Error saving state", "location", location, "error", err)
+//
+// You can set your own Logger with gopher.SetLogger() or *Server.SetLogger(). The default is a TextLogger writing to stdout.
+type Logger interface {
+	Debug(msg string, keyVals ...interface{})
+	Info(msg string, keyVals ...interface{})
+	Warn(msg string, keyVals ...interface{})
+	Error(msg string, keyVals ...interface{})
+}
+
+// TextLogger is the default Logger. It writes one line of plain text for each message at or above it's level, like:
+//
+//	2006-01-02 15:04:05 [ERROR] Error saving state location=/recovery error="permission denied"
+type TextLogger struct {
+	out   io.Writer
+	level int
+	mux   sync.Mutex
+}
+
+// NewTextLogger makes a TextLogger that writes messages at or above the given level (ex: helpers.LogLevelInfo) to out.
+func NewTextLogger(out io.Writer, level int) *TextLogger {
+	return &TextLogger{out: out, level: level}
+}
+
+// DefaultLogger makes the Logger used when none is set, which writes Info and higher level messages to stdout.
+func DefaultLogger() Logger {
+	return NewTextLogger(os.Stdout, LogLevelInfo)
+}
+
+// Debug logs a debug message.
+func (l *TextLogger) Debug(msg string, keyVals ...interface{}) {
+	l.log(LogLevelDebug, msg, keyVals)
+}
+
+// Info logs an informational message.
+func (l *TextLogger) Info(msg string, keyVals ...interface{}) {
+	l.log(LogLevelInfo, msg, keyVals)
+}
+
+// Warn logs a warning message.
+func (l *TextLogger) Warn(msg string, keyVals ...interface{}) {
+	l.log(LogLevelWarn, msg, keyVals)
+}
+
+// Error logs an error message.
+func (l *TextLogger) Error(msg string, keyVals ...interface{}) {
+	l.log(LogLevelError, msg, keyVals)
+}
+
+func (l *TextLogger) log(level int, msg string, keyVals []interface{}) {
+	if level < l.level {
+		return
+	}
+	var b strings.Builder
+	b.WriteString(time.Now().Format("2006-01-02 15:04:05"))
+	b.WriteString(" [" + logLevelNames[level] + "] ")
+	b.WriteString(msg)
+	for i := 0; i < len(keyVals); i += 2 {
+		b.WriteString(" " + fmt.Sprint(keyVals[i]) + "=")
+		if i+1 < len(keyVals) {
+			val := fmt.Sprint(keyVals[i+1])
+			// QUOTE VALUES THAT WOULD BREAK UP THE LINE, OR THE KEY/VALUE PAIRS
+			if strings.ContainsAny(val, " \"=") || strconv.Quote(val) != "\""+val+"\"" {
+				val = strconv.Quote(val)
+			}
+			b.WriteString(val)
+		}
+	}
+	b.WriteString("\n")
+
+	l.mux.Lock()
+	io.WriteString(l.out, b.String())
+	l.mux.Unlock()
+}
@@ -0,0 +1,32 @@
+package helpers
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestTextLogger(t *testing.T) {
+	var out bytes.Buffer
+	logger := NewTextLogger(&out, LogLevelWarn)
+	logger.Debug("Checking connections")
+	logger.Info("Server started")
+	logger.Warn("Low disk space", "free", 5)
+	logger.Error("Error saving state", "location", "/my recovery", "error", errors.New(`bad "file"`), "query", "a=b", "input", "line\nbreak", "odd")
+
+	lines := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	expected := []string{
+		`[WARN] Low disk space free=5`,
+		`[ERROR] Error saving state location="/my recovery" error="bad \"file\"" query="a=b" input="line\nbreak" odd=`,
+	}
+	if len(lines) != len(expected) {
+		t.Fatalf("Logged %q", out.String())
+	}
+	for i, line := range lines {
+		// Skip the time
+		if len(line) < 20 || line[20:] != expected[i] {
+			t.Errorf("Logged %q, expected %q", line, expected[i])
+		}
+	}
+}
@@ -0,0 +1,187 @@
+package helpers
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Metric names collected by the server
+const (
+	MetricClientActions        = "gopher_client_actions_total"
+	MetricClientRTT            = "gopher_client_rtt_seconds"
+	MetricConnectionsDropped   = "gopher_connections_dropped_total"
+	MetricCustomActionDuration = "gopher_custom_action_duration_seconds"
+	MetricDBQueryDuration      = "gopher_db_query_duration_seconds"
+	MetricDBQueryErrors        = "gopher_db_query_errors_total"
+	MetricMessagesSent         = "gopher_messages_sent_total"
+)
+
+var metricHelp map[string]string = map[string]string{
+	MetricClientActions:        "Client actions received, by action type and error ID (0 means no error).",
+	MetricClientRTT:            "Round-trip times to clients, measured with heartbeat pings.",
+	MetricConnectionsDropped:   "Client connections dropped by the heartbeat, by reason.",
+	MetricCustomActionDuration: "Time taken to run CustomClientAction callbacks, by action.",
+	MetricDBQueryDuration:      "Time taken by SQL queries, by statement type.",
+	MetricDBQueryErrors:        "SQL queries that returned an error, by statement type.",
+	MetricMessagesSent:         "Messages sent to clients, by ServerAction type.",
+}
+
+// Histogram buckets in seconds
+var metricBuckets []float64 = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+type histogram struct {
+	counts []uint64
+	sum    float64
+	count  uint64
+}
+
+// Metrics collects the counters and histograms of a server, and writes them in the Prometheus text format. A nil *Metrics
+// can be used safely, and collects nothing.
+type Metrics struct {
+	counters   map[string]map[string]float64
+	histograms map[string]map[string]*histogram
+	mux        sync.Mutex
+}
+
+// NewMetrics makes a new, empty Metrics.
+func NewMetrics() *Metrics {
+	return &Metrics{counters: make(map[string]map[string]float64), histograms: make(map[string]map[string]*histogram)}
+}
+
+// Inc adds one to a counter. The labels are key/value pairs (ex: "action", "li").
+func (m *Metrics) Inc(name string, labels ...string) {
+	if m == nil {
+		return
+	}
+	l := formatLabels(labels)
+	m.mux.Lock()
+	if m.counters[name] == nil {
+		m.counters[name] = make(map[string]float64)
+	}
+	m.counters[name][l]++
+	m.mux.Unlock()
+}
+
+// Observe adds a value (in seconds) to a histogram. The labels are key/value pairs (ex: "query", "SELECT").
+func (m *Metrics) Observe(name string, seconds float64, labels ...string) {
+	if m == nil {
+		return
+	}
+	l := formatLabels(labels)
+	m.mux.Lock()
+	if m.histograms[name] == nil {
+		m.histograms[name] = make(map[string]*histogram)
+	}
+	h := m.histograms[name][l]
+	if h == nil {
+		h = &histogram{counts: make([]uint64, len(metricBuckets))}
+		m.histograms[name][l] = h
+	}
+	for i, b := range metricBuckets {
+		if seconds <= b {
+			h.counts[i]++
+		}
+	}
+	h.sum += seconds
+	h.count++
+	m.mux.Unlock()
+}
+
+// WritePrometheus writes all counters and histograms to w in the Prometheus text format.
+func (m *Metrics) WritePrometheus(w io.Writer) {
+	if m == nil {
+		return
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+
+	for _, name := range sortedKeys(m.counters) {
+		writeMetricHeader(w, name, "counter")
+		for _, l := range sortedKeys(m.counters[name]) {
+			fmt.Fprintf(w, "%s%s %s\n", name, wrapLabels(l), formatFloat(m.counters[name][l]))
+		}
+	}
+	for _, name := range sortedKeys(m.histograms) {
+		writeMetricHeader(w, name, "histogram")
+		for _, l := range sortedKeys(m.histograms[name]) {
+			h := m.histograms[name][l]
+			for i, b := range metricBuckets {
+				fmt.Fprintf(w, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(l, "le=\""+formatFloat(b)+"\"")), h.counts[i])
+			}
+			fmt.Fprintf(w, "%s_bucket%s %d\n", name, wrapLabels(joinLabels(l, "le=\"+Inf\"")), h.count)
+			fmt.Fprintf(w, "%s_sum%s %s\n", name, wrapLabels(l), formatFloat(h.sum))
+			fmt.Fprintf(w, "%s_count%s %d\n", name, wrapLabels(l), h.count)
+		}
+	}
+}
+
+// WriteGauge writes a single gauge in the Prometheus text format. The values map label strings made with FormatLabels to values.
+func WriteGauge(w io.Writer, name string, help string, values map[string]float64) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s gauge\n", name, help, name)
+	for _, l := range sortedKeys(values) {
+		fmt.Fprintf(w, "%s%s %s\n", name, wrapLabels(l), formatFloat(values[l]))
+	}
+}
+
+// FormatLabels turns key/value pairs into a Prometheus label string (ex: `type="lobby"`).
+func FormatLabels(labels ...string) string {
+	return formatLabels(labels)
+}
+
+func formatLabels(labels []string) string {
+	parts := make([]string, 0, len(labels)/2)
+	for i := 0; i+1 < len(labels); i += 2 {
+		v := strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "\n", "\\n").Replace(labels[i+1])
+		parts = append(parts, labels[i]+"=\""+v+"\"")
+	}
+	return strings.Join(parts, ",")
+}
+
+func joinLabels(a string, b string) string {
+	if a == "" {
+		return b
+	}
+	return a + "," + b
+}
+
+func wrapLabels(l string) string {
+	if l == "" {
+		return ""
+	}
+	return "{" + l + "}"
+}
+
+func writeMetricHeader(w io.Writer, name string, metricType string) {
+	if help, ok := metricHelp[name]; ok {
+		fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	}
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func sortedKeys(m interface{}) []string {
+	v := reflect.ValueOf(m)
+	keys := make([]string, 0, v.Len())
+	for _, k := range v.MapKeys() {
+		keys = append(keys, k.String())
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// MessageType gets the ServerAction type of a message sent to a client, which is the message's only key.
+func MessageType(message interface{}) string {
+	v := reflect.ValueOf(message)
+	if v.Kind() == reflect.Map && v.Len() == 1 && v.Type().Key().Kind() == reflect.String {
+		return v.MapKeys()[0].String()
+	}
+	return "unknown"
+}
@@ -0,0 +1,65 @@
+package helpers
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	metrics := NewMetrics()
+	metrics.Inc(MetricClientActions, "action", "li", "error", "0")
+	metrics.Inc(MetricClientActions, "action", "li", "error", "0")
+	metrics.Inc(MetricClientActions, "action", "j", "error", "1030")
+	metrics.Inc(MetricMessagesSent, "type", "say \"hi\"\\\n")
+	metrics.Observe(MetricClientRTT, 0.5)
+	metrics.Observe(MetricClientRTT, 3)
+	metrics.Observe(MetricClientRTT, 20)
+
+	var out bytes.Buffer
+	metrics.WritePrometheus(&out)
+	expected := `# HELP gopher_client_actions_total Client actions received, by action type and error ID (0 means no error).
+# TYPE gopher_client_actions_total counter
+gopher_client_actions_total{action="j",error="1030"} 1
+gopher_client_actions_total{action="li",error="0"} 2
+# HELP gopher_messages_sent_total Messages sent to clients, by ServerAction type.
+# TYPE gopher_messages_sent_total counter
+gopher_messages_sent_total{type="say \"hi\"\\\n"} 1
+# HELP gopher_client_rtt_seconds Round-trip times to clients, measured with heartbeat pings.
+# TYPE gopher_client_rtt_seconds histogram
+gopher_client_rtt_seconds_bucket{le="0.005"} 0
+gopher_client_rtt_seconds_bucket{le="0.01"} 0
+gopher_client_rtt_seconds_bucket{le="0.025"} 0
+gopher_client_rtt_seconds_bucket{le="0.05"} 0
+gopher_client_rtt_seconds_bucket{le="0.1"} 0
+gopher_client_rtt_seconds_bucket{le="0.25"} 0
+gopher_client_rtt_seconds_bucket{le="0.5"} 1
+gopher_client_rtt_seconds_bucket{le="1"} 1
+gopher_client_rtt_seconds_bucket{le="2.5"} 1
+gopher_client_rtt_seconds_bucket{le="5"} 2
+gopher_client_rtt_seconds_bucket{le="10"} 2
+gopher_client_rtt_seconds_bucket{le="+Inf"} 3
+gopher_client_rtt_seconds_sum 23.5
+gopher_client_rtt_seconds_count 3
+`
+	if out.String() != expected {
+		t.Errorf("Wrote:\n%s\nExpected:\n%s", out.String(), expected)
+	}
+
+	// Gauges
+	out.Reset()
+	WriteGauge(&out, "gopher_rooms", "Rooms, by type.", map[string]float64{FormatLabels("type", "lobby"): 2, FormatLabels("type", "game"): 10})
+	if !strings.HasSuffix(out.String(), "gopher_rooms{type=\"game\"} 10\ngopher_rooms{type=\"lobby\"} 2\n") {
+		t.Errorf("Wrote gauge:\n%s", out.String())
+	}
+
+	// A nil *Metrics collects nothing
+	var none *Metrics
+	none.Inc(MetricClientActions)
+	none.Observe(MetricClientRTT, 1)
+	out.Reset()
+	none.WritePrometheus(&out)
+	if out.Len() != 0 {
+		t.Errorf("Nil Metrics wrote %q", out.String())
+	}
+}
@@ -0,0 +1,471 @@
+package helpers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// MessagePackCodec is a Codec for MessagePack (https://msgpack.org), which makes smaller messages that are faster to encode and
+// decode than JSON. Structs and types with a MarshalJSON method are encoded the way encoding/json would, and []byte is sent as
+// MessagePack binary. The MessagePack extension types are not supported.
+type MessagePackCodec struct{}
+
+const msgpackMaxDepth = 1000
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	jsonNumberType    = reflect.TypeOf(json.Number(""))
+
+	errorMsgpackTruncated = errors.New("msgpack: message is truncated")
+	errorMsgpackDepth     = errors.New("msgpack: message is nested too deep")
+)
+
+// Marshal encodes a message as MessagePack.
+func (MessagePackCodec) Marshal(v interface{}) ([]byte, error) {
+	var e msgpackEncoder
+	if err := e.encode(reflect.ValueOf(v)); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+// Unmarshal decodes a MessagePack message. Decoding into anything other than an interface{}, a map[string]interface{}, or a struct
+// with interface{} and string fields goes through encoding/json, so it follows the same rules.
+func (MessagePackCodec) Unmarshal(data []byte, v interface{}) error {
+	d := msgpackDecoder{data: data}
+	val, err := d.decode(0)
+	if err != nil {
+		return err
+	} else if d.pos != len(data) {
+		return errors.New("msgpack: unexpected data after the message")
+	}
+
+	switch t := v.(type) {
+	case *interface{}:
+		*t = val
+		return nil
+	case *map[string]interface{}:
+		if m, ok := val.(map[string]interface{}); ok || val == nil {
+			*t = m
+			return nil
+		}
+	}
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Ptr || rv.IsNil() {
+		return errors.New("msgpack: Unmarshal() requires a non-nil pointer")
+	}
+	if m, ok := val.(map[string]interface{}); ok && rv.Elem().Kind() == reflect.Struct && msgpackSetStruct(rv.Elem(), m) {
+		return nil
+	}
+	j, err := json.Marshal(val)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(j, v)
+}
+
+// Binary is true, MessagePack messages are sent as binary.
+func (MessagePackCodec) Binary() bool {
+	return true
+}
+
+// msgpackSetStruct sets a struct's fields from a decoded map, matching keys like encoding/json does. Returns false if a value
+// can't be set directly, so the struct needs to go through encoding/json.
+func msgpackSetStruct(rv reflect.Value, m map[string]interface{}) bool {
+	t := rv.Type()
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if f.Anonymous {
+			return false
+		} else if f.PkgPath != "" {
+			continue
+		}
+		name := f.Name
+		if tag := f.Tag.Get("json"); tag == "-" {
+			continue
+		} else if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+			name = tagName
+		}
+		val, ok := m[name]
+		if !ok {
+			for key, v := range m {
+				if strings.EqualFold(key, name) {
+					val, ok = v, true
+					break
+				}
+			}
+		}
+		if !ok || val == nil {
+			continue
+		}
+		fv := reflect.ValueOf(val)
+		if !fv.Type().AssignableTo(f.Type) {
+			return false
+		}
+		rv.Field(i).Set(fv)
+	}
+	return true
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Encoding   //////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type msgpackEncoder struct {
+	buf []byte
+}
+
+func (e *msgpackEncoder) encode(v reflect.Value) error {
+	if !v.IsValid() {
+		e.buf = append(e.buf, 0xc0)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface, reflect.Map, reflect.Slice:
+		if v.IsNil() {
+			e.buf = append(e.buf, 0xc0)
+			return nil
+		}
+	}
+	if v.Type() == jsonNumberType {
+		if i, err := strconv.ParseInt(v.String(), 10, 64); err == nil {
+			e.int(i)
+			return nil
+		}
+		f, err := strconv.ParseFloat(v.String(), 64)
+		if err != nil {
+			return err
+		}
+		e.float(f)
+		return nil
+	} else if v.Type().Implements(jsonMarshalerType) {
+		return e.encodeJSON(v)
+	}
+
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		return e.encode(v.Elem())
+	case reflect.Bool:
+		if v.Bool() {
+			e.buf = append(e.buf, 0xc3)
+		} else {
+			e.buf = append(e.buf, 0xc2)
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		e.int(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		e.uint(v.Uint())
+	case reflect.Float32:
+		e.buf = append(e.buf, 0xca)
+		e.buf = binary.BigEndian.AppendUint32(e.buf, math.Float32bits(float32(v.Float())))
+	case reflect.Float64:
+		e.float(v.Float())
+	case reflect.String:
+		e.str(v.String())
+	case reflect.Slice, reflect.Array:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			var b []byte
+			if v.Kind() == reflect.Slice {
+				b = v.Bytes()
+			} else {
+				b = make([]byte, v.Len())
+				reflect.Copy(reflect.ValueOf(b), v)
+			}
+			e.bin(b)
+			return nil
+		}
+		e.header(v.Len(), 0x90, 0xdc, 0xdd)
+		for i := 0; i < v.Len(); i++ {
+			if err := e.encode(v.Index(i)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		e.header(v.Len(), 0x80, 0xde, 0xdf)
+		iter := v.MapRange()
+		for iter.Next() {
+			// KEYS ARE ALWAYS STRINGS, LIKE JSON
+			key := iter.Key()
+			switch key.Kind() {
+			case reflect.String:
+				e.str(key.String())
+			case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+				e.str(strconv.FormatInt(key.Int(), 10))
+			case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+				e.str(strconv.FormatUint(key.Uint(), 10))
+			default:
+				return errors.New("msgpack: unsupported map key type " + key.Type().String())
+			}
+			if err := e.encode(iter.Value()); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		return e.encodeJSON(v)
+	default:
+		return errors.New("msgpack: unsupported type " + v.Type().String())
+	}
+	return nil
+}
+
+// encodeJSON encodes a value the way encoding/json would, by encoding it's JSON.
+func (e *msgpackEncoder) encodeJSON(v reflect.Value) error {
+	j, err := json.Marshal(v.Interface())
+	if err != nil {
+		return err
+	}
+	d := json.NewDecoder(bytes.NewReader(j))
+	d.UseNumber()
+	var val interface{}
+	if err = d.Decode(&val); err != nil {
+		return err
+	}
+	return e.encode(reflect.ValueOf(val))
+}
+
+func (e *msgpackEncoder) int(i int64) {
+	switch {
+	case i >= 0:
+		e.uint(uint64(i))
+	case i >= -32:
+		e.buf = append(e.buf, byte(i))
+	case i >= math.MinInt8:
+		e.buf = append(e.buf, 0xd0, byte(i))
+	case i >= math.MinInt16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xd1), uint16(i))
+	case i >= math.MinInt32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xd2), uint32(i))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xd3), uint64(i))
+	}
+}
+
+func (e *msgpackEncoder) uint(u uint64) {
+	switch {
+	case u <= 0x7f:
+		e.buf = append(e.buf, byte(u))
+	case u <= math.MaxUint8:
+		e.buf = append(e.buf, 0xcc, byte(u))
+	case u <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xcd), uint16(u))
+	case u <= math.MaxUint32:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xce), uint32(u))
+	default:
+		e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcf), u)
+	}
+}
+
+func (e *msgpackEncoder) float(f float64) {
+	e.buf = binary.BigEndian.AppendUint64(append(e.buf, 0xcb), math.Float64bits(f))
+}
+
+func (e *msgpackEncoder) str(s string) {
+	n := len(s)
+	switch {
+	case n <= 31:
+		e.buf = append(e.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xda), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xdb), uint32(n))
+	}
+	e.buf = append(e.buf, s...)
+}
+
+func (e *msgpackEncoder) bin(b []byte) {
+	n := len(b)
+	switch {
+	case n <= math.MaxUint8:
+		e.buf = append(e.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, 0xc5), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, 0xc6), uint32(n))
+	}
+	e.buf = append(e.buf, b...)
+}
+
+// header writes an array or map header, with the "fix" format for up to 15 items
+func (e *msgpackEncoder) header(n int, fix byte, format16 byte, format32 byte) {
+	switch {
+	case n <= 15:
+		e.buf = append(e.buf, fix|byte(n))
+	case n <= math.MaxUint16:
+		e.buf = binary.BigEndian.AppendUint16(append(e.buf, format16), uint16(n))
+	default:
+		e.buf = binary.BigEndian.AppendUint32(append(e.buf, format32), uint32(n))
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Decoding   //////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type msgpackDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *msgpackDecoder) next(n int) ([]byte, error) {
+	if n < 0 || n > len(d.data)-d.pos {
+		return nil, errorMsgpackTruncated
+	}
+	b := d.data[d.pos : d.pos+n]
+	d.pos += n
+	return b, nil
+}
+
+// uintN reads a big endian unsigned integer of n bytes
+func (d *msgpackDecoder) uintN(n int) (uint64, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return 0, err
+	}
+	var u uint64
+	for _, c := range b {
+		u = u<<8 | uint64(c)
+	}
+	return u, nil
+}
+
+func (d *msgpackDecoder) decode(depth int) (interface{}, error) {
+	if depth > msgpackMaxDepth {
+		return nil, errorMsgpackDepth
+	}
+	b, err := d.next(1)
+	if err != nil {
+		return nil, err
+	}
+	c := b[0]
+	switch {
+	case c <= 0x7f:
+		return float64(c), nil
+	case c >= 0xe0:
+		return float64(int8(c)), nil
+	case c >= 0x80 && c <= 0x8f:
+		return d.decodeMap(int(c&0x0f), depth)
+	case c >= 0x90 && c <= 0x9f:
+		return d.decodeArray(int(c&0x0f), depth)
+	case c >= 0xa0 && c <= 0xbf:
+		return d.decodeString(int(c & 0x1f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xc5, 0xc6:
+		n, err := d.uintN(1 << (c - 0xc4))
+		if err != nil {
+			return nil, err
+		}
+		b, err := d.next(int(n))
+		if err != nil {
+			return nil, err
+		}
+		return append([]byte(nil), b...), nil
+	case 0xca:
+		u, err := d.uintN(4)
+		return float64(math.Float32frombits(uint32(u))), err
+	case 0xcb:
+		u, err := d.uintN(8)
+		return math.Float64frombits(u), err
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		u, err := d.uintN(1 << (c - 0xcc))
+		return float64(u), err
+	case 0xd0:
+		u, err := d.uintN(1)
+		return float64(int8(u)), err
+	case 0xd1:
+		u, err := d.uintN(2)
+		return float64(int16(u)), err
+	case 0xd2:
+		u, err := d.uintN(4)
+		return float64(int32(u)), err
+	case 0xd3:
+		u, err := d.uintN(8)
+		return float64(int64(u)), err
+	case 0xd9, 0xda, 0xdb:
+		n, err := d.uintN(1 << (c - 0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeString(int(n))
+	case 0xdc, 0xdd:
+		n, err := d.uintN(2 << (c - 0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeArray(int(n), depth)
+	case 0xde, 0xdf:
+		n, err := d.uintN(2 << (c - 0xde))
+		if err != nil {
+			return nil, err
+		}
+		return d.decodeMap(int(n), depth)
+	}
+	return nil, errors.New("msgpack: unsupported format 0x" + strconv.FormatUint(uint64(c), 16))
+}
+
+func (d *msgpackDecoder) decodeString(n int) (interface{}, error) {
+	b, err := d.next(n)
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+func (d *msgpackDecoder) decodeArray(n int, depth int) (interface{}, error) {
+	// EVERY ITEM IS AT LEAST ONE BYTE, SO DON'T TRUST LENGTHS LONGER THAN THE MESSAGE
+	if n > len(d.data)-d.pos {
+		return nil, errorMsgpackTruncated
+	}
+	a := make([]interface{}, n)
+	for i := range a {
+		val, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		a[i] = val
+	}
+	return a, nil
+}
+
+func (d *msgpackDecoder) decodeMap(n int, depth int) (interface{}, error) {
+	if n*2 > len(d.data)-d.pos {
+		return nil, errorMsgpackTruncated
+	}
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		key, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		var name string
+		switch k := key.(type) {
+		case string:
+			name = k
+		case []byte:
+			name = string(k)
+		default:
+			return nil, errors.New("msgpack: map keys must be strings")
+		}
+		val, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		m[name] = val
+	}
+	return m, nil
+}
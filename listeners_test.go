@@ -0,0 +1,72 @@
+package gopher
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"github.com/gorilla/websocket"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestListeners(t *testing.T) {
+	dir := t.TempDir()
+	certFile, keyFile := testCert(t, dir)
+	plainPort := freePort(t, "tcp")
+	tlsPort := freePort(t, "tcp")
+	file := filepath.Join(dir, "settings.json")
+	data := `{"ServerName": "!server!", "HostName": "localhost", "AdminLogin": "admin", "AdminPassword": "password", "Listeners": [
+		{"IP": "localhost", "Port": ` + strconv.Itoa(plainPort) + `, "Path": "/play"},
+		{"IP": "localhost", "Port": ` + strconv.Itoa(tlsPort) + `, "TLS": true, "CertFile": ` + strconv.Quote(certFile) + `, "PrivKeyFile": ` + strconv.Quote(keyFile) + `}]}`
+	if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+		t.Fatal(err)
+	}
+	settings, err := LoadSettings(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(settings)
+	server.SetSettingsFile(file)
+	runTestServer(t, server)
+	if settings.Listeners[1].Path != "" {
+		t.Errorf("Starting the server changed it's Listeners to %+v", settings.Listeners)
+	}
+
+	// Each Listener takes clients on it's own path
+	certPEM, err := ioutil.ReadFile(certFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	roots := x509.NewCertPool()
+	roots.AppendCertsFromPEM(certPEM)
+	tlsDialer := websocket.Dialer{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	dials := []struct {
+		dialer *websocket.Dialer
+		url    string
+		ok     bool
+	}{
+		{websocket.DefaultDialer, "ws://localhost:" + strconv.Itoa(plainPort) + "/play", true},
+		{websocket.DefaultDialer, "ws://localhost:" + strconv.Itoa(plainPort) + "/ws", false},
+		{&tlsDialer, "wss://localhost:" + strconv.Itoa(tlsPort) + "/wss", true},
+		{&tlsDialer, "wss://localhost:" + strconv.Itoa(tlsPort) + "/play", false},
+	}
+	for _, d := range dials {
+		conn, _, dialErr := d.dialer.Dial(d.url, nil)
+		if dialErr == nil {
+			conn.Close()
+		}
+		if (dialErr == nil) != d.ok {
+			t.Errorf("Dialing %v got error %v", d.url, dialErr)
+		}
+	}
+
+	// Default paths aren't changes to reload
+	report, err := server.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Applied) > 0 || len(report.NeedRestart) > 0 {
+		t.Errorf("Reloading the same settings got report %+v", report)
+	}
+}
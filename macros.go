@@ -2,157 +2,632 @@ package gopher
 
 import (
 	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/hewiefreeman/GopherGameServer/core"
+	"io"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 )
 
-func macroListener() {
+// MacroHandler runs a macro registered with RegisterMacro. The returned result is printed on the command-line (strings as they are,
+// anything else as JSON), and sent as JSON to remote consoles.
+type MacroHandler func(args *MacroArgs) (interface{}, error)
+
+// MacroArgs are the arguments a macro was called with. Arguments are separated by spaces, and can be quoted with double or single quotes
+// to include spaces (ex: broadcast "Server restarting soon"). Arguments starting with "--" are flags, and can have a value
+// (ex: --for=1h), or not (ex: --force, which has the value "true").
+type MacroArgs struct {
+	Name  string            // The macro's name
+	Args  []string          // Positional arguments
+	Flags map[string]string // Flags, without the leading "--"
+
+	usage string
+}
+
+type macro struct {
+	usage   string
+	handler MacroHandler
+}
+
+// macroShutdown is returned by macros that shut the server down. The shut-down runs after the result has been delivered.
+type macroShutdown struct {
+	message string
+	run     func()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Macro registry   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RegisterMacro adds a macro to the Server's command-line and remote console. The usage describes the macro's arguments and what it does,
+// and is shown by the help macro (ex: "<name> [--for=duration] Bans a User"). Returns an error if the name is invalid or already taken.
+func (s *Server) RegisterMacro(name string, usage string, handler MacroHandler) error {
+	if name == "" || strings.ContainsAny(name, " \t\"'") || strings.HasPrefix(name, "-") {
+		return errors.New("Invalid macro name '" + name + "'")
+	} else if handler == nil {
+		return errors.New("RegisterMacro() requires a handler")
+	}
+	s.macrosMux.Lock()
+	defer s.macrosMux.Unlock()
+	if s.macros == nil {
+		s.macros = make(map[string]macro)
+	}
+	if _, ok := s.macros[name]; ok {
+		return errors.New("The macro '" + name + "' is already registered")
+	}
+	s.macros[name] = macro{usage: usage, handler: handler}
+	return nil
+}
+
+// RegisterMacro adds a macro to the default server. See *Server.RegisterMacro() for more details.
+func RegisterMacro(name string, usage string, handler MacroHandler) error {
+	return defaultServer.RegisterMacro(name, usage, handler)
+}
+
+func (s *Server) registerBuiltInMacros() {
+	s.RegisterMacro("help", "[macro] Lists all macros, or shows how to use one", s.macroHelp)
+	s.RegisterMacro("version", "Shows the server's version", func(*MacroArgs) (interface{}, error) { return version, nil })
+	s.RegisterMacro("pause", "Pauses the server, logging all Users off", s.macroPause)
+	s.RegisterMacro("resume", "Resumes a paused server", s.macroResume)
+	s.RegisterMacro("shutdown", "Shuts the server down", s.macroShutdown)
+	s.RegisterMacro("drain", "<seconds> Refuses new logins, and shuts down when all Rooms are empty or the time is up", s.macroDrain)
+	s.RegisterMacro("reload", "Reloads the server's settings", s.macroReload)
+	s.RegisterMacro("save", "Saves the server's state for recovery", s.macroSave)
+	s.RegisterMacro("snapshots", "Lists the recovery snapshots, newest first", s.macroSnapshots)
+	s.RegisterMacro("diffsnapshot", "<id> Shows what restoring a recovery snapshot would change", s.macroDiffSnapshot)
+	s.RegisterMacro("restoresnapshot", "<id> [--replace] Restores a recovery snapshot into the running server. Merges into the live Rooms, unless --replace is set", s.macroRestoreSnapshot)
+	s.RegisterMacro("roomcount", "Shows the number of Rooms", s.macroRoomCount)
+	s.RegisterMacro("usercount", "Shows the number of Users", s.macroUserCount)
+	s.RegisterMacro("listrooms", "[--type=roomType] Lists all Rooms", s.macroListRooms)
+	s.RegisterMacro("listusers", "[--guests] Lists all Users", s.macroListUsers)
+	s.RegisterMacro("getroom", "<name> Shows a Room's Users, invite list and variables", s.macroGetRoom)
+	s.RegisterMacro("getuser", "<name> Shows a User's connections, Rooms, variables and friends", s.macroGetUser)
+	s.RegisterMacro("newroom", "<name> <roomType> <isPrivate> <maxUsers> Makes a Room", s.macroNewRoom)
+	s.RegisterMacro("deleteroom", "<name> Deletes a Room", s.macroDeleteRoom)
+	s.RegisterMacro("setroomvar", "<room> <key> <value> Sets a Room variable. The value is read as JSON when possible, or a string otherwise", s.macroSetRoomVar)
+	s.RegisterMacro("broadcast", "<message> [--room=name] [--type=game|notice|important] Sends a server message to every Room, or one Room", s.macroBroadcast)
+	s.RegisterMacro("kick", "<name> Logs a User off", s.macroKick)
+	s.RegisterMacro("ban", "<name> [--for=duration] Kicks a User, and stops them logging in for a duration (ex: 30m, 12h), or until restart", s.macroBan)
+	s.RegisterMacro("unban", "<name> Lets a banned User log in again", s.macroUnban)
+	s.RegisterMacro("listbans", "Lists all banned Users", s.macroListBans)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Running macros   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SetMacroConsole sets where gopher.Start() reads command-line macros from, and writes their results to, instead of stdin and stdout
+// (ex: a Unix control socket connection). You can only set the macro console before starting the server. To turn the macro console off,
+// set DisableMacroConsole in ServerSettings.
+func (s *Server) SetMacroConsole(in io.Reader, out io.Writer) error {
+	if s.isStarted() {
+		return errors.New(ErrorServerRunning)
+	} else if in == nil || out == nil {
+		return errors.New("SetMacroConsole() requires a Reader and a Writer")
+	}
+	s.macroIn = in
+	s.macroOut = out
+	return nil
+}
+
+// SetMacroConsole sets where the default server reads command-line macros from. See *Server.SetMacroConsole() for more details.
+func SetMacroConsole(in io.Reader, out io.Writer) error {
+	return defaultServer.SetMacroConsole(in, out)
+}
+
+// macroListener serves the macro console until in reaches EOF, or stopped is closed. Takes the stopped channel from before the Server
+// starts, so it also ends when the start-up fails.
+func (s *Server) macroListener(stopped chan struct{}) {
+	in, out := s.macroIn, s.macroOut
+	if in == nil {
+		in, out = os.Stdin, os.Stdout
+	}
+	if err := s.serveMacros(in, out, stopped); err != nil {
+		s.logger.Error("Macro console error", "error", err)
+	}
+}
+
+// ServeMacros reads macros from in, one per line, and writes their results to out, until in reaches EOF or the Server shuts down. Use it to
+// serve macros on more than one stream at once, like each connection to a Unix control socket. Returns nil on EOF and shut-down, or the
+// error from reading in otherwise.
+func (s *Server) ServeMacros(in io.Reader, out io.Writer) error {
+	return s.serveMacros(in, out, s.stoppedChan())
+}
+
+func (s *Server) serveMacros(in io.Reader, out io.Writer, stopped chan struct{}) error {
+	lines := make(chan string)
+	readErr := make(chan error, 1)
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			select {
+			case lines <- scanner.Text():
+			case <-done:
+				return
+			}
+		}
+		readErr <- scanner.Err()
+	}()
+
 	for {
-		reader := bufio.NewReader(os.Stdin)
-		fmt.Print("[Gopher] Command: ")
-		text, _ := reader.ReadString('\n')
-		text = strings.TrimSpace(text)
-		stop := handleMacro(text)
-		if stop {
-			return
+		fmt.Fprint(out, "[Gopher] Command: ")
+		select {
+		case line := <-lines:
+			if s.handleMacro(strings.TrimSpace(line), out) {
+				return nil
+			}
+		case err := <-readErr:
+			// EOF gives a nil error
+			fmt.Fprintln(out)
+			return err
+		case <-stopped:
+			fmt.Fprintln(out)
+			return nil
 		}
 	}
 }
 
-func handleMacro(macro string) bool {
-	if macro == "pause" {
-		Pause()
-	} else if macro == "resume" {
-		Resume()
-	} else if macro == "shutdown" {
-		ShutDown()
+func (s *Server) handleMacro(text string, out io.Writer) bool {
+	result, shutdown, err := s.runMacro(text)
+	if err != nil {
+		fmt.Fprintln(out, "Error:", err)
+		return false
+	}
+	printMacroResult(out, result)
+	if shutdown != nil {
+		shutdown()
 		return true
-	} else if macro == "version" {
-		fmt.Println(version)
-	} else if macro == "roomcount" {
-		fmt.Println("Room count: ", core.RoomCount())
-	} else if macro == "usercount" {
-		fmt.Println("User count: ", core.UserCount())
-	} else if len(macro) >= 12 && macro[0:10] == "deleteroom" {
-		macroDeleteRoom(macro)
-	} else if len(macro) >= 9 && macro[0:7] == "newroom" {
-		macroNewRoom(macro)
-	} else if len(macro) >= 9 && macro[0:7] == "getuser" {
-		macroGetUser(macro)
-	} else if len(macro) >= 9 && macro[0:7] == "getroom" {
-		macroGetRoom(macro)
-	} else if len(macro) >= 6 && macro[0:4] == "kick" {
-		macroKick(macro)
 	}
 	return false
 }
 
-func macroKick(macro string) {
-	userName := macro[5:]
-	user, userErr := core.GetUser(userName)
-	if userErr != nil {
-		fmt.Println(userErr)
-		return
+func printMacroResult(out io.Writer, result interface{}) {
+	switch r := result.(type) {
+	case nil:
+	case string:
+		fmt.Fprintln(out, r)
+	default:
+		data, _ := json.MarshalIndent(r, "", "    ")
+		fmt.Fprintln(out, string(data))
 	}
-	user.Kick()
-	fmt.Println("Kicked user '" + userName + "'")
 }
 
-func macroNewRoom(macro string) {
-	s := strings.Split(macro, " ")
-	if len(s) != 5 {
-		fmt.Println("newroom expects 4 parameters (name string, rType string, isPrivate bool, maxUsers int)")
-		return
+// runMacro runs a macro, and returns it's result. Shut-down macros don't shut the server down themselves, but return a function
+// that does, so the caller can first deliver the result.
+func (s *Server) runMacro(text string) (interface{}, func(), error) {
+	args, err := parseMacro(text)
+	if err != nil || args == nil {
+		return nil, nil, err
 	}
-	isPrivate := false
-	if s[3] == "true" || s[3] == "t" {
-		isPrivate = true
+	s.macrosMux.Lock()
+	m, ok := s.macros[args.Name]
+	s.macrosMux.Unlock()
+	if !ok {
+		return nil, nil, errors.New("Unknown macro '" + args.Name + "'. Use 'help' for a list of macros")
 	}
-	maxUsers, err := strconv.Atoi(s[4])
+	args.usage = args.Name + " " + m.usage
+
+	result, err := m.handler(args)
 	if err != nil {
-		fmt.Println("maxUsers must be an integer")
-		return
+		return nil, nil, err
 	}
-	_, roomErr := core.NewRoom(s[1], s[2], isPrivate, maxUsers, "")
-	if roomErr != nil {
-		fmt.Println(roomErr)
-		return
+	if sd, ok := result.(macroShutdown); ok {
+		return sd.message, sd.run, nil
 	}
-	fmt.Println("Created room '" + s[1] + "'")
+	return result, nil, nil
 }
 
-func macroDeleteRoom(macro string) {
-	s := strings.Split(macro, " ")
-	if len(s) != 2 {
-		fmt.Println("deleteroom expects 1 parameter (name string)")
-		return
+// parseMacro splits a macro into it's name, arguments and flags. Returns nil MacroArgs for an empty line.
+func parseMacro(text string) (*MacroArgs, error) {
+	var tokens []string
+	var quoted []bool // WHETHER EACH TOKEN STARTED WITH A QUOTE. QUOTED TOKENS ARE NEVER FLAGS
+	var token strings.Builder
+	inToken, tokenQuoted, escaped := false, false, false
+	var quote rune
+	endToken := func() {
+		if inToken {
+			tokens = append(tokens, token.String())
+			quoted = append(quoted, tokenQuoted)
+			token.Reset()
+			inToken, tokenQuoted = false, false
+		}
 	}
-	room, roomErr := core.GetRoom(s[1])
-	if roomErr != nil {
-		fmt.Println(roomErr)
-		return
+	for _, c := range text {
+		switch {
+		case escaped:
+			token.WriteRune(c)
+			escaped = false
+		case c == '\\' && quote != '\'':
+			escaped = true
+			inToken = true
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			} else {
+				token.WriteRune(c)
+			}
+		case c == '"' || c == '\'':
+			if !inToken {
+				tokenQuoted = true
+			}
+			quote = c
+			inToken = true
+		case c == ' ' || c == '\t':
+			endToken()
+		default:
+			inToken = true
+			token.WriteRune(c)
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("Unclosed quote")
+	}
+	endToken()
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	args := &MacroArgs{Name: tokens[0], Args: []string{}, Flags: make(map[string]string)}
+	for i := 1; i < len(tokens); i++ {
+		if !quoted[i] && strings.HasPrefix(tokens[i], "--") && len(tokens[i]) > 2 {
+			flag := strings.SplitN(tokens[i][2:], "=", 2)
+			if len(flag) == 2 {
+				args.Flags[flag[0]] = flag[1]
+			} else {
+				args.Flags[flag[0]] = "true"
+			}
+			continue
+		}
+		args.Args = append(args.Args, tokens[i])
+	}
+	return args, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   MacroArgs   /////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Expect returns an error showing the macro's usage if it wasn't called with exactly n positional arguments.
+func (a *MacroArgs) Expect(n int) error {
+	if len(a.Args) != n {
+		return errors.New(a.Name + " expects " + strconv.Itoa(n) + " argument(s). Usage: " + a.usage)
+	}
+	return nil
+}
+
+// Arg gets a positional argument, or an empty string if there aren't enough arguments.
+func (a *MacroArgs) Arg(i int) string {
+	if i < 0 || i >= len(a.Args) {
+		return ""
+	}
+	return a.Args[i]
+}
+
+// Int gets a positional argument as an int.
+func (a *MacroArgs) Int(i int) (int, error) {
+	n, err := strconv.Atoi(a.Arg(i))
+	if err != nil {
+		return 0, errors.New("Argument " + strconv.Itoa(i+1) + " of " + a.Name + " must be an integer. Usage: " + a.usage)
+	}
+	return n, nil
+}
+
+// Bool gets a positional argument as a bool. Accepts the same values as strconv.ParseBool.
+func (a *MacroArgs) Bool(i int) (bool, error) {
+	b, err := strconv.ParseBool(a.Arg(i))
+	if err != nil {
+		return false, errors.New("Argument " + strconv.Itoa(i+1) + " of " + a.Name + " must be true or false. Usage: " + a.usage)
+	}
+	return b, nil
+}
+
+// Flag gets the value of a flag, or def if the flag wasn't given.
+func (a *MacroArgs) Flag(name string, def string) string {
+	if val, ok := a.Flags[name]; ok {
+		return val
+	}
+	return def
+}
+
+// HasFlag returns true if the flag was given.
+func (a *MacroArgs) HasFlag(name string) bool {
+	_, ok := a.Flags[name]
+	return ok
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Built-in macros   ///////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) macroHelp(args *MacroArgs) (interface{}, error) {
+	s.macrosMux.Lock()
+	defer s.macrosMux.Unlock()
+	if len(args.Args) > 0 {
+		m, ok := s.macros[args.Arg(0)]
+		if !ok {
+			return nil, errors.New("Unknown macro '" + args.Arg(0) + "'")
+		}
+		return args.Arg(0) + " " + m.usage, nil
+	}
+	names := make([]string, 0, len(s.macros))
+	for name := range s.macros {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	lines := make([]string, 0, len(names))
+	for _, name := range names {
+		lines = append(lines, "  "+name+" "+s.macros[name].usage)
+	}
+	return "Macros:\n" + strings.Join(lines, "\n"), nil
+}
+
+func (s *Server) macroPause(*MacroArgs) (interface{}, error) {
+	s.Pause()
+	return map[string]interface{}{"state": s.State()}, nil
+}
+
+func (s *Server) macroResume(*MacroArgs) (interface{}, error) {
+	s.Resume()
+	return map[string]interface{}{"state": s.State()}, nil
+}
+
+func (s *Server) macroShutdown(*MacroArgs) (interface{}, error) {
+	return macroShutdown{message: "Shutting down...", run: func() { s.Shutdown(context.Background()) }}, nil
+}
+
+func (s *Server) macroDrain(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	seconds, err := args.Int(0)
+	if err != nil {
+		return nil, err
+	}
+	switch s.State() {
+	case ServerStateDraining:
+		return nil, errors.New("Server is already draining")
+	case ServerStateRunning:
+	default:
+		return nil, errors.New("Server is not running")
+	}
+	// DRAIN IN THE BACKGROUND, SO THE CONSOLE CAN STILL BE USED. PROGRESS IS LOGGED, AND THE CONSOLE ENDS WHEN THE SERVER STOPS
+	go s.DrainAndShutdown(context.Background(), time.Duration(seconds)*time.Second)
+	return "Draining for " + args.Arg(0) + " seconds. Use \"roomcount\" to check on it", nil
+}
+
+func (s *Server) macroReload(*MacroArgs) (interface{}, error) {
+	return s.Reload()
+}
+
+func (s *Server) macroSave(*MacroArgs) (interface{}, error) {
+	if settings := s.getSettings(); settings == nil || !settings.EnableRecovery {
+		return nil, errors.New("EnableRecovery is not set in ServerSettings")
 	}
-	deleteErr := room.Delete()
-	if deleteErr != nil {
-		fmt.Println(deleteErr)
-		return
+	if err := s.saveState(); err != nil {
+		return nil, err
+	}
+	return "Saved server state", nil
+}
+
+func (s *Server) macroSnapshots(*MacroArgs) (interface{}, error) {
+	return s.listSnapshots()
+}
+
+func (s *Server) macroDiffSnapshot(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
 	}
-	fmt.Println("Deleted room '" + s[1] + "'")
+	return s.diffSnapshot(args.Arg(0))
 }
 
-func macroGetUser(macro string) {
-	s := strings.Split(macro, " ")
-	if len(s) != 2 {
-		fmt.Println("getuser expects 1 parameter (name string)")
-		return
+func (s *Server) macroRestoreSnapshot(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
 	}
+	return s.restoreSnapshot(args.Arg(0), args.HasFlag("replace"))
+}
 
-	user, userErr := core.GetUser(s[1])
+func (s *Server) macroRoomCount(*MacroArgs) (interface{}, error) {
+	return map[string]interface{}{"rooms": s.core.RoomCount()}, nil
+}
+
+func (s *Server) macroUserCount(*MacroArgs) (interface{}, error) {
+	return map[string]interface{}{"users": s.core.UserCount()}, nil
+}
+
+func (s *Server) macroListRooms(args *MacroArgs) (interface{}, error) {
+	rType := args.Flag("type", "")
+	rooms := make([]adminRoom, 0)
+	for _, room := range s.core.GetRooms() {
+		if rType != "" && room.Type() != rType {
+			continue
+		}
+		rooms = append(rooms, adminRoom{Name: room.Name(), Type: room.Type(), Private: room.IsPrivate(), Owner: room.Owner(),
+			MaxUsers: room.MaxUsers(), NumUsers: room.NumUsers()})
+	}
+	sort.Slice(rooms, func(i, j int) bool { return rooms[i].Name < rooms[j].Name })
+	return rooms, nil
+}
+
+func (s *Server) macroListUsers(args *MacroArgs) (interface{}, error) {
+	guestsOnly := args.HasFlag("guests")
+	users := make([]adminUser, 0)
+	for _, user := range s.core.GetUsers() {
+		if guestsOnly && !user.IsGuest() {
+			continue
+		}
+		users = append(users, adminUser{Name: user.Name(), Guest: user.IsGuest(), Status: user.Status(), DatabaseID: user.DatabaseID()})
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Name < users[j].Name })
+	return users, nil
+}
+
+func (s *Server) macroGetRoom(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	room, roomErr := s.core.GetRoom(args.Arg(0))
+	if roomErr != nil {
+		return nil, roomErr
+	}
+	return adminRoomInfo(room), nil
+}
+
+func (s *Server) macroGetUser(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	user, userErr := s.core.GetUser(args.Arg(0))
 	if userErr != nil {
-		fmt.Println(userErr)
-		return
+		return nil, userErr
 	}
+	return adminUserInfo(user), nil
+}
 
-	fmt.Println("-- User '" + s[1] + "' --")
-	fmt.Println("Status:", user.Status())
-	fmt.Println("Guest:", user.IsGuest())
-	fmt.Println("Connections:")
-	conns := user.ConnectionIDs()
-	for i := 0; i < len(conns); i++ {
-		fmt.Println("    [ ID: '"+conns[i]+"', Room: '"+user.RoomIn(conns[i]).Name()+"', Vars:", user.GetVariables(nil, conns[i]), "]")
+func (s *Server) macroNewRoom(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(4); err != nil {
+		return nil, err
 	}
-	fmt.Println("Friends:", user.Friends())
-	fmt.Println("Database ID:", user.DatabaseID())
+	isPrivate, err := args.Bool(2)
+	if err != nil {
+		return nil, err
+	}
+	maxUsers, err := args.Int(3)
+	if err != nil {
+		return nil, err
+	}
+	owner := ""
+	if isPrivate {
+		owner = s.getSettings().ServerName
+	}
+	if _, roomErr := s.core.NewRoom(args.Arg(0), args.Arg(1), isPrivate, maxUsers, owner); roomErr != nil {
+		return nil, roomErr
+	}
+	return "Created room '" + args.Arg(0) + "'", nil
 }
 
-func macroGetRoom(macro string) {
-	s := strings.Split(macro, " ")
-	if len(s) != 2 {
-		fmt.Println("getroom expects 1 parameter (name string)")
-		return
+func (s *Server) macroDeleteRoom(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
 	}
+	room, roomErr := s.core.GetRoom(args.Arg(0))
+	if roomErr != nil {
+		return nil, roomErr
+	}
+	if deleteErr := room.Delete(); deleteErr != nil {
+		return nil, deleteErr
+	}
+	return "Deleted room '" + args.Arg(0) + "'", nil
+}
 
-	room, roomErr := core.GetRoom(s[1])
+func (s *Server) macroSetRoomVar(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(3); err != nil {
+		return nil, err
+	}
+	room, roomErr := s.core.GetRoom(args.Arg(0))
 	if roomErr != nil {
-		fmt.Println(roomErr)
-		return
+		return nil, roomErr
+	}
+	var value interface{}
+	if json.Unmarshal([]byte(args.Arg(2)), &value) != nil {
+		value = args.Arg(2)
+	}
+	room.SetVariable(args.Arg(1), value)
+	return adminRoomInfo(room), nil
+}
+
+func (s *Server) macroBroadcast(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	var messageType int
+	switch args.Flag("type", "notice") {
+	case "game":
+		messageType = core.ServerMessageGame
+	case "notice":
+		messageType = core.ServerMessageNotice
+	case "important":
+		messageType = core.ServerMessageImportant
+	default:
+		return nil, errors.New("--type must be game, notice, or important")
+	}
+
+	var rooms []*core.Room
+	if name := args.Flag("room", ""); name != "" {
+		room, roomErr := s.core.GetRoom(name)
+		if roomErr != nil {
+			return nil, roomErr
+		}
+		rooms = append(rooms, room)
+	} else {
+		for _, room := range s.core.GetRooms() {
+			rooms = append(rooms, room)
+		}
 	}
+	for _, room := range rooms {
+		room.ServerMessage(args.Arg(0), messageType, nil)
+	}
+	return "Sent to " + strconv.Itoa(len(rooms)) + " room(s)", nil
+}
 
-	invList, _ := room.InviteList()
-	usrMap, _ := room.GetUserMap()
+func (s *Server) macroKick(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	user, userErr := s.core.GetUser(args.Arg(0))
+	if userErr != nil {
+		return nil, userErr
+	}
+	user.Kick()
+	return "Kicked user '" + args.Arg(0) + "'", nil
+}
 
-	fmt.Println("-- Room '" + s[1] + "' --")
-	fmt.Println("Type:", room.Type())
-	fmt.Println("Private:", room.IsPrivate())
-	fmt.Println("Owner:", room.Owner())
-	fmt.Println("Max Users:", room.MaxUsers())
-	users := make([]string, 0, len(usrMap))
-	for name := range usrMap {
-		users = append(users, name)
+func (s *Server) macroBan(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	var duration time.Duration
+	if val := args.Flag("for", ""); val != "" {
+		var err error
+		if duration, err = time.ParseDuration(val); err != nil || duration <= 0 {
+			return nil, errors.New("--for must be a positive duration (ex: 30m, 12h)")
+		}
+	}
+	if err := s.core.Ban(args.Arg(0), duration); err != nil {
+		return nil, err
+	}
+	if duration == 0 {
+		return "Banned user '" + args.Arg(0) + "' until restart", nil
+	}
+	return "Banned user '" + args.Arg(0) + "' for " + duration.String(), nil
+}
+
+func (s *Server) macroUnban(args *MacroArgs) (interface{}, error) {
+	if err := args.Expect(1); err != nil {
+		return nil, err
+	}
+	if err := s.core.Unban(args.Arg(0)); err != nil {
+		return nil, err
+	}
+	return "Unbanned user '" + args.Arg(0) + "'", nil
+}
+
+func (s *Server) macroListBans(*MacroArgs) (interface{}, error) {
+	bans := make(map[string]string)
+	for name, until := range s.core.GetBans() {
+		if until.IsZero() {
+			bans[name] = "until restart"
+		} else {
+			bans[name] = until.Format(time.RFC3339)
+		}
 	}
-	fmt.Println("Users:", "("+strconv.Itoa(room.NumUsers())+")", users)
-	fmt.Println("Invite List:", invList)
+	return bans, nil
 }
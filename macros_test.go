@@ -0,0 +1,62 @@
+package gopher
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseMacro(t *testing.T) {
+	args, err := parseMacro(`broadcast "Server restarting soon" --type=important '--room' --force it\'s`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if args.Name != "broadcast" {
+		t.Errorf("Name is %q", args.Name)
+	}
+	if want := []string{"Server restarting soon", "--room", "it's"}; !reflect.DeepEqual(args.Args, want) {
+		t.Errorf("Args are %q, expected %q", args.Args, want)
+	}
+	if want := map[string]string{"type": "important", "force": "true"}; !reflect.DeepEqual(args.Flags, want) {
+		t.Errorf("Flags are %v, expected %v", args.Flags, want)
+	}
+	if _, err = parseMacro(`kick "bob`); err == nil {
+		t.Error("Unclosed quote didn't return an error")
+	}
+}
+
+func TestRunMacro(t *testing.T) {
+	server := NewServer(nil)
+	server.Core().NewRoomType("lobby", false)
+	if err := server.RegisterMacro("kick", "", func(*MacroArgs) (interface{}, error) { return nil, nil }); err == nil {
+		t.Error("Registered a macro over a built-in macro")
+	}
+	if _, _, err := server.runMacro("nosuchmacro"); err == nil {
+		t.Error("Unknown macro didn't return an error")
+	}
+	if _, _, err := server.runMacro("newroom lobby"); err == nil {
+		t.Error("Macro with missing arguments didn't return an error")
+	}
+	if _, _, err := server.runMacro("newroom room lobby false 4"); err != nil {
+		t.Error(err)
+	}
+	if _, _, err := server.runMacro(`setroomvar room round 2`); err != nil {
+		t.Error(err)
+	}
+	room, _ := server.Core().GetRoom("room")
+	if val, _ := room.GetVariable("round"); val != 2.0 {
+		t.Errorf("Room variable is %v, expected 2", val)
+	}
+}
+
+func TestServeMacros(t *testing.T) {
+	server := NewServer(nil)
+	var out bytes.Buffer
+	if err := server.ServeMacros(strings.NewReader("version\nnosuchmacro\n"), &out); err != nil {
+		t.Error(err)
+	}
+	if !strings.Contains(out.String(), version) || !strings.Contains(out.String(), "Error: Unknown macro") {
+		t.Errorf("Unexpected macro console output %q", out.String())
+	}
+}
@@ -0,0 +1,56 @@
+package gopher
+
+import (
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net/http"
+	"strconv"
+)
+
+// MetricsHandler gets an http.Handler that serves the Server's metrics in the Prometheus text format. The handler is served automatically
+// when EnableMetrics is set in ServerSettings, but you can also use it with your own HTTP server.
+//
+// Exported metrics are the number of connections, logged in Users, guests, and Rooms per RoomType, along with client actions by type and error ID,
+// CustomClientAction latency, SQL query latency and errors, and messages sent to clients by ServerAction type.
+func (s *Server) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		helpers.WriteGauge(w, "gopher_connections", "Clients connected to the server.", map[string]float64{"": float64(s.ClientsConnected())})
+		helpers.WriteGauge(w, "gopher_users", "Users logged into the server.", map[string]float64{"": float64(s.core.UserCount())})
+		helpers.WriteGauge(w, "gopher_guests", "Guest Users logged into the server.", map[string]float64{"": float64(s.core.GuestCount())})
+		rooms := make(map[string]float64)
+		for rType := range s.core.GetRoomTypes() {
+			rooms[helpers.FormatLabels("type", rType)] = 0
+		}
+		for rType, count := range s.core.RoomCountByType() {
+			rooms[helpers.FormatLabels("type", rType)] = float64(count)
+		}
+		helpers.WriteGauge(w, "gopher_rooms", "Rooms on the server, by RoomType.", rooms)
+		s.metrics.WritePrometheus(w)
+	})
+}
+
+// MetricsHandler gets the metrics http.Handler for the default server. See *Server.MetricsHandler() for more details.
+func MetricsHandler() http.Handler {
+	return defaultServer.MetricsHandler()
+}
+
+func (settings *ServerSettings) metricsPath() string {
+	if settings.MetricsPath == "" {
+		return "/metrics"
+	}
+	return settings.MetricsPath
+}
+
+func (s *Server) makeMetricsServer() *http.Server {
+	mux := http.NewServeMux()
+	settings := s.getSettings()
+	mux.Handle(settings.metricsPath(), s.MetricsHandler())
+	if settings.EnableHealthChecks {
+		s.handleHealthChecks(mux)
+	}
+	server := &http.Server{Addr: settings.MetricsIP + ":" + strconv.Itoa(settings.MetricsPort), Handler: mux}
+	go func() {
+		s.endServer(server.ListenAndServe())
+	}()
+	return server
+}
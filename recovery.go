@@ -0,0 +1,443 @@
+package gopher
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"reflect"
+	"sort"
+	"time"
+)
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Saving and recovery   ///////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) saveState() error {
+	s.logger.Info("Saving server state...")
+	state, err := encodeState(s.getState())
+	if err != nil {
+		s.logger.Error("Error saving state", "error", err)
+		return err
+	}
+	snapshot, err := s.recoveryStore().Save(state)
+	if err != nil {
+		s.logger.Error("Error saving state", "error", err)
+		return err
+	}
+	s.logger.Info("Save state successful", "snapshot", snapshot.ID)
+	s.pruneMux.Lock()
+	s.validSnapshots[snapshot.ID] = true
+	s.pruneMux.Unlock()
+	s.pruneState()
+	return nil
+}
+
+// autosave saves the server's state every RecoveryAutosave seconds, until done is closed.
+func (s *Server) autosave(done chan struct{}) {
+	ticker := time.NewTicker(time.Duration(s.getSettings().RecoveryAutosave) * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if !s.isStopping() {
+				s.saveState()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// pruneState deletes the snapshots that are not among the newest RecoveryKeep valid snapshots, and older than RecoveryMaxAge hours. Corrupt
+// snapshots don't count towards RecoveryKeep, so they can't push out the snapshots that can still be recovered. The newest valid snapshot
+// is never deleted. Snapshots are only loaded to check them while the RecoveryKeep window is filling up, and the ones that decode are
+// remembered, so an autosave doesn't load every stored snapshot again.
+func (s *Server) pruneState() {
+	settings := s.getSettings()
+	keep := settings.RecoveryKeep
+	maxAge := time.Duration(settings.RecoveryMaxAge) * time.Hour
+	if keep <= 0 && maxAge <= 0 {
+		return
+	}
+	s.pruneMux.Lock()
+	defer s.pruneMux.Unlock()
+	store := s.recoveryStore()
+	snapshots, err := store.List()
+	if err != nil {
+		s.logger.Warn("Error pruning recovery snapshots", "error", err)
+		return
+	}
+	valid := 0
+	listed := make(map[string]bool, len(snapshots))
+	for _, sn := range snapshots {
+		listed[sn.ID] = true
+		if (valid == 0 || valid < keep) && s.validSnapshot(store, sn.ID) {
+			valid++
+			continue
+		}
+		if maxAge > 0 && time.Since(sn.Time) < maxAge {
+			continue
+		}
+		if err := store.Delete(sn.ID); err != nil {
+			s.logger.Warn("Error deleting recovery snapshot", "snapshot", sn.ID, "error", err)
+			continue
+		}
+		delete(listed, sn.ID)
+		s.logger.Debug("Deleted recovery snapshot", "snapshot", sn.ID)
+	}
+	// Forget the snapshots that are gone
+	for id := range s.validSnapshots {
+		if !listed[id] {
+			delete(s.validSnapshots, id)
+		}
+	}
+}
+
+// validSnapshot reports whether a snapshot can be recovered. Snapshots never change once saved, so the ones that decode are remembered
+// in validSnapshots. MUST LOCK pruneMux BEFORE CALLING.
+func (s *Server) validSnapshot(store RecoveryStore, id string) bool {
+	if s.validSnapshots[id] {
+		return true
+	}
+	data, err := store.Load(id)
+	if err == nil {
+		_, err = decodeState(data)
+	}
+	if err != nil {
+		return false
+	}
+	s.validSnapshots[id] = true
+	return true
+}
+
+func (s *Server) getState() serverRestore {
+	return serverRestore{
+		R: s.core.GetRoomsState(),
+		S: s.saveSections(),
+	}
+}
+
+func (s *Server) recoverState() {
+	s.logger.Info("Recovering previous state...")
+
+	store := s.recoveryStore()
+	snapshots, err := store.List()
+	if err != nil {
+		s.logger.Error("Error recovering state", "error", err)
+		return
+	} else if len(snapshots) == 0 {
+		s.logger.Info("No recovery snapshots to restore!")
+		return
+	}
+
+	// Use the newest snapshot that isn't corrupt
+	var recovery serverRestore
+	var snapshot string
+	for _, sn := range snapshots {
+		data, loadErr := store.Load(sn.ID)
+		if loadErr == nil {
+			recovery, loadErr = decodeState(data)
+		}
+		if loadErr != nil {
+			s.logger.Warn("Skipping invalid recovery snapshot", "snapshot", sn.ID, "error", loadErr)
+			continue
+		}
+		snapshot = sn.ID
+		break
+	}
+	if snapshot == "" {
+		s.logger.Error("Error recovering state", "error", "no valid recovery snapshots")
+		return
+	}
+	s.logger.Info("Restoring recovery snapshot", "snapshot", snapshot, "version", recovery.version)
+
+	// Recover rooms
+	if len(recovery.R) == 0 {
+		s.logger.Info("No rooms to restore!")
+	}
+	for name, val := range recovery.R {
+		if roomErr := s.restoreRoom(name, val); roomErr != nil {
+			s.logger.Error("Error recovering room", "room", name, "error", roomErr)
+		}
+	}
+
+	// Recover custom sections
+	s.restoreSections(recovery)
+
+	//
+	s.logger.Info("State recovery successful")
+}
+
+// restoreRoom makes a Room from it's recovery state.
+func (s *Server) restoreRoom(name string, val core.RoomRecoveryState) error {
+	room, roomErr := s.core.NewRoom(name, val.T, val.P, val.M, val.O)
+	if roomErr != nil {
+		return roomErr
+	}
+	for _, userName := range val.I {
+		invErr := room.AddInvite(userName)
+		if invErr != nil {
+			s.logger.Error("Error recovering room invite", "room", name, "user", userName, "error", invErr)
+		}
+	}
+	room.SetVariables(val.V)
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Snapshots   /////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+type snapshotInfo struct {
+	RecoverySnapshot
+	Version int    `json:"version,omitempty"` // The recovery format version the snapshot was saved with
+	Rooms   int    `json:"rooms"`
+	Error   string `json:"error,omitempty"` // Why the snapshot can't be restored
+}
+
+// snapshotDiff lists what restoring a snapshot would change in the live state.
+type snapshotDiff struct {
+	Added    []string            `json:"added"`              // Rooms in the snapshot that aren't live
+	Removed  []string            `json:"removed"`            // Live Rooms that aren't in the snapshot
+	Changed  map[string][]string `json:"changed"`            // Rooms in both, with their differences as "<what>: <live> -> <snapshot>"
+	Sections []string            `json:"sections,omitempty"` // RecoverySections with different data
+}
+
+type snapshotRestore struct {
+	Snapshot string   `json:"snapshot"`
+	Replace  bool     `json:"replace"`
+	Created  []string `json:"created"`
+	Updated  []string `json:"updated"`
+	Deleted  []string `json:"deleted"`
+	Sections []string `json:"sections,omitempty"`
+}
+
+func (s *Server) snapshotStore() (RecoveryStore, error) {
+	if settings := s.getSettings(); settings == nil || !settings.EnableRecovery {
+		return nil, errors.New("EnableRecovery is not set in ServerSettings")
+	}
+	return s.recoveryStore(), nil
+}
+
+// listSnapshots lists the recovery snapshots, newest first, with the number of Rooms in each.
+func (s *Server) listSnapshots() ([]snapshotInfo, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return nil, err
+	}
+	snapshots, err := store.List()
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]snapshotInfo, len(snapshots))
+	for i, snapshot := range snapshots {
+		infos[i].RecoverySnapshot = snapshot
+		data, loadErr := store.Load(snapshot.ID)
+		if loadErr == nil {
+			var state serverRestore
+			if state, loadErr = decodeState(data); loadErr == nil {
+				infos[i].Version = state.version
+				infos[i].Rooms = len(state.R)
+			}
+		}
+		if loadErr != nil {
+			infos[i].Error = loadErr.Error()
+		}
+	}
+	return infos, nil
+}
+
+func (s *Server) loadSnapshot(id string) (serverRestore, error) {
+	store, err := s.snapshotStore()
+	if err != nil {
+		return serverRestore{}, err
+	}
+	data, err := store.Load(id)
+	if err != nil {
+		return serverRestore{}, err
+	}
+	return decodeState(data)
+}
+
+// liveState gets the live state the same way it would come out of a snapshot, so the two can be compared.
+func (s *Server) liveState() (serverRestore, error) {
+	data, err := encodeState(s.getState())
+	if err != nil {
+		return serverRestore{}, err
+	}
+	return decodeState(data)
+}
+
+// diffSnapshot compares a snapshot with the live state.
+func (s *Server) diffSnapshot(id string) (snapshotDiff, error) {
+	snapshot, err := s.loadSnapshot(id)
+	if err != nil {
+		return snapshotDiff{}, err
+	}
+	live, err := s.liveState()
+	if err != nil {
+		return snapshotDiff{}, err
+	}
+	return diffStates(live, snapshot), nil
+}
+
+func diffStates(live serverRestore, snapshot serverRestore) snapshotDiff {
+	diff := snapshotDiff{Added: []string{}, Removed: []string{}, Changed: make(map[string][]string)}
+	for name, val := range snapshot.R {
+		liveVal, ok := live.R[name]
+		if !ok {
+			diff.Added = append(diff.Added, name)
+			continue
+		}
+		if changes := diffRooms(liveVal, val); len(changes) > 0 {
+			diff.Changed[name] = changes
+		}
+	}
+	for name := range live.R {
+		if _, ok := snapshot.R[name]; !ok {
+			diff.Removed = append(diff.Removed, name)
+		}
+	}
+	for name, val := range snapshot.S {
+		if liveVal, ok := live.S[name]; !ok || liveVal.Version != val.Version || !bytes.Equal(liveVal.Data, val.Data) {
+			diff.Sections = append(diff.Sections, name)
+		}
+	}
+	sort.Strings(diff.Added)
+	sort.Strings(diff.Removed)
+	sort.Strings(diff.Sections)
+	return diff
+}
+
+func diffRooms(live core.RoomRecoveryState, snapshot core.RoomRecoveryState) []string {
+	var changes []string
+	change := func(what string, from interface{}, to interface{}) {
+		changes = append(changes, what+": "+diffValue(from)+" -> "+diffValue(to))
+	}
+	if live.T != snapshot.T {
+		change("type", live.T, snapshot.T)
+	}
+	if live.P != snapshot.P {
+		change("private", live.P, snapshot.P)
+	}
+	if live.O != snapshot.O {
+		change("owner", live.O, snapshot.O)
+	}
+	if live.M != snapshot.M {
+		change("maxUsers", live.M, snapshot.M)
+	}
+	liveInvites, snapshotInvites := append([]string(nil), live.I...), append([]string(nil), snapshot.I...)
+	sort.Strings(liveInvites)
+	sort.Strings(snapshotInvites)
+	if !reflect.DeepEqual(liveInvites, snapshotInvites) && (len(liveInvites) > 0 || len(snapshotInvites) > 0) {
+		change("invites", liveInvites, snapshotInvites)
+	}
+	var varChanges []string
+	for key, val := range snapshot.V {
+		if liveVal, ok := live.V[key]; !ok {
+			varChanges = append(varChanges, "vars."+key+": (none) -> "+diffValue(val))
+		} else if !reflect.DeepEqual(liveVal, val) {
+			varChanges = append(varChanges, "vars."+key+": "+diffValue(liveVal)+" -> "+diffValue(val))
+		}
+	}
+	for key, val := range live.V {
+		if _, ok := snapshot.V[key]; !ok {
+			varChanges = append(varChanges, "vars."+key+": "+diffValue(val)+" -> (none)")
+		}
+	}
+	sort.Strings(varChanges)
+	return append(changes, varChanges...)
+}
+
+func diffValue(val interface{}) string {
+	j, err := json.Marshal(val)
+	if err != nil {
+		return "?"
+	}
+	return string(j)
+}
+
+// restoreSnapshot restores a snapshot into the running server. Merging makes the snapshot's missing Rooms, and sets the variables
+// and invites of the Rooms that are still live. Replacing also deletes the Rooms that aren't in the snapshot, removes the variables
+// and invites that aren't in it, and remakes the Rooms whose type, owner, privacy or max Users changed. Users in deleted or remade
+// Rooms are removed from them. Either way, the snapshot's registered RecoverySections are restored.
+func (s *Server) restoreSnapshot(id string, replace bool) (snapshotRestore, error) {
+	snapshot, err := s.loadSnapshot(id)
+	if err != nil {
+		return snapshotRestore{}, err
+	}
+	live, err := s.liveState()
+	if err != nil {
+		return snapshotRestore{}, err
+	}
+	diff := diffStates(live, snapshot)
+	result := snapshotRestore{Snapshot: id, Replace: replace, Created: []string{}, Updated: []string{}, Deleted: []string{}}
+
+	for _, name := range diff.Added {
+		if roomErr := s.restoreRoom(name, snapshot.R[name]); roomErr != nil {
+			s.logger.Error("Error restoring room", "room", name, "error", roomErr)
+			continue
+		}
+		result.Created = append(result.Created, name)
+	}
+	for name := range diff.Changed {
+		room, roomErr := s.core.GetRoom(name)
+		if roomErr != nil {
+			continue
+		}
+		val, liveVal := snapshot.R[name], live.R[name]
+		if replace && (val.T != liveVal.T || val.P != liveVal.P || val.O != liveVal.O || val.M != liveVal.M) {
+			room.Delete()
+			if roomErr = s.restoreRoom(name, val); roomErr != nil {
+				s.logger.Error("Error restoring room", "room", name, "error", roomErr)
+				continue
+			}
+		} else {
+			if replace {
+				for key := range liveVal.V {
+					if _, ok := val.V[key]; !ok {
+						room.DeleteVariable(key)
+					}
+				}
+				for _, userName := range liveVal.I {
+					room.RemoveInvite(userName)
+				}
+			}
+			if room.IsPrivate() {
+				invites, _ := room.InviteList()
+				for _, userName := range val.I {
+					if !containsString(invites, userName) {
+						room.AddInvite(userName)
+					}
+				}
+			}
+			room.SetVariables(val.V)
+		}
+		result.Updated = append(result.Updated, name)
+	}
+	if replace {
+		for _, name := range diff.Removed {
+			if room, roomErr := s.core.GetRoom(name); roomErr == nil && room.Delete() == nil {
+				result.Deleted = append(result.Deleted, name)
+			}
+		}
+	}
+	sort.Strings(result.Updated)
+	result.Sections = s.restoreSections(snapshot)
+	sort.Strings(result.Sections)
+
+	s.logger.Info("Restored recovery snapshot", "snapshot", id, "replace", replace, "created", len(result.Created),
+		"updated", len(result.Updated), "deleted", len(result.Deleted))
+	return result, nil
+}
+
+func containsString(list []string, str string) bool {
+	for _, item := range list {
+		if item == str {
+			return true
+		}
+	}
+	return false
+}
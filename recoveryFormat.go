@@ -0,0 +1,232 @@
+package gopher
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"strconv"
+	"strings"
+)
+
+// Recovery snapshots start with a header line holding the format version and the SHA-256 checksum of the JSON state that follows, like:
+//
+//	GOPHER-RECOVERY 2 9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08
+//	{"rooms":{...},"sections":{...}}
+//
+// Snapshots from before the header was added have no header, are read as version 1, and without a checksum. Snapshots from older
+// versions are upgraded with the registered RecoveryMigrations when they're loaded.
+const (
+	recoveryHeader  = "GOPHER-RECOVERY"
+	recoveryVersion = 2
+)
+
+var (
+	errorRecoveryHeader   = errors.New("Recovery snapshot has an invalid header")
+	errorRecoveryVersion  = errors.New("Recovery snapshot version is not supported")
+	errorRecoveryChecksum = errors.New("Recovery snapshot checksum does not match, the snapshot is corrupt or truncated")
+)
+
+type serverRestore struct {
+	R map[string]core.RoomRecoveryState `json:"rooms"`
+	S map[string]recoverySection        `json:"sections,omitempty"`
+
+	version int // The version the snapshot was saved with
+}
+
+type recoverySection struct {
+	Version int             `json:"version"`
+	Data    json.RawMessage `json:"data"`
+}
+
+// encodeState encodes a state as a recovery snapshot.
+func encodeState(stateObj serverRestore) ([]byte, error) {
+	state, err := json.Marshal(stateObj)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(state)
+	header := recoveryHeader + " " + strconv.Itoa(recoveryVersion) + " " + hex.EncodeToString(sum[:]) + "\n"
+	return append([]byte(header), state...), nil
+}
+
+// decodeState decodes a recovery snapshot, checks it's checksum, and upgrades it to the current version.
+func decodeState(r []byte) (serverRestore, error) {
+	var recovery serverRestore
+	version := 1
+
+	// Snapshots from before the header was added are plain JSON
+	if len(r) > 0 && r[0] != '{' {
+		newLine := bytes.IndexByte(r, '\n')
+		if newLine == -1 {
+			return recovery, errorRecoveryHeader
+		}
+		header := strings.Fields(string(r[:newLine]))
+		if len(header) != 3 || header[0] != recoveryHeader {
+			return recovery, errorRecoveryHeader
+		}
+		var vErr error
+		if version, vErr = strconv.Atoi(header[1]); vErr != nil || version < 1 || version > recoveryVersion {
+			return recovery, errorRecoveryVersion
+		}
+		r = r[newLine+1:]
+		sum := sha256.Sum256(r)
+		if hex.EncodeToString(sum[:]) != header[2] {
+			return recovery, errorRecoveryChecksum
+		}
+	}
+
+	if version < recoveryVersion {
+		var err error
+		if r, err = migrateState(r, version); err != nil {
+			return recovery, err
+		}
+	}
+	err := json.Unmarshal(r, &recovery)
+	recovery.version = version
+	return recovery, err
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Migrations   ////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RecoveryMigration upgrades a recovery snapshot's decoded JSON from one version of the recovery format to the next.
+type RecoveryMigration func(state map[string]interface{}) error
+
+// RECOVERY FORMAT VERSION -> MIGRATION TO THE NEXT VERSION
+var recoveryMigrations map[int]RecoveryMigration = map[int]RecoveryMigration{
+	1: migrateRecoveryV1,
+}
+
+// RegisterRecoveryMigration sets the RecoveryMigration that upgrades recovery snapshots from the given version of the recovery format
+// to the next one. The server comes with the migrations for it's own versions, so you only need this to replace one of them (ex: to
+// also upgrade your own data), or to read snapshots written by a fork of the server. Migrations are shared by every Server, and
+// must be registered before starting any of them.
+func RegisterRecoveryMigration(from int, migration RecoveryMigration) error {
+	if from < 1 || from >= recoveryVersion {
+		return errors.New("Recovery format version " + strconv.Itoa(from) + " has no next version")
+	} else if migration == nil {
+		return errors.New("RegisterRecoveryMigration() requires a RecoveryMigration")
+	}
+	recoveryMigrations[from] = migration
+	return nil
+}
+
+func migrateState(r []byte, version int) ([]byte, error) {
+	var state map[string]interface{}
+	if err := json.Unmarshal(r, &state); err != nil {
+		return nil, err
+	}
+	for ; version < recoveryVersion; version++ {
+		migration := recoveryMigrations[version]
+		if migration == nil {
+			return nil, errors.New("There is no recovery migration from version " + strconv.Itoa(version))
+		}
+		if err := migration(state); err != nil {
+			return nil, errors.New("Recovery migration from version " + strconv.Itoa(version) + " failed: " + err.Error())
+		}
+	}
+	return json.Marshal(state)
+}
+
+// Version 1 used the core.RoomRecoveryState field names as JSON keys, and had no custom sections
+func migrateRecoveryV1(state map[string]interface{}) error {
+	rooms, _ := state["R"].(map[string]interface{})
+	for name, val := range rooms {
+		room, ok := val.(map[string]interface{})
+		if !ok {
+			return errors.New("Room '" + name + "' is not an object")
+		}
+		for from, to := range map[string]string{"T": "type", "P": "private", "O": "owner", "M": "maxUsers", "I": "invites", "V": "vars"} {
+			if v, ok := room[from]; ok {
+				room[to] = v
+				delete(room, from)
+			}
+		}
+	}
+	delete(state, "R")
+	state["rooms"] = rooms
+	return nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Custom sections   ///////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// RecoverySection saves and restores your own data (ex: game-level data that isn't in a Room) along with the Rooms in recovery snapshots.
+type RecoverySection struct {
+	// Version is the version of your section's data. It's saved with the data, and given back to Restore, so you can upgrade
+	// data saved by older versions of your game.
+	Version int
+	// Save gets the section's data. The data is saved as JSON.
+	Save func() (interface{}, error)
+	// Restore restores the section's JSON data, and the Version it was saved with. Restore is called after the Rooms are restored,
+	// when the server starts with EnableRecovery set in ServerSettings, or when a snapshot is restored with the `restoresnapshot` macro
+	// or admin API.
+	Restore func(version int, data json.RawMessage) error
+}
+
+// RegisterRecoverySection adds a RecoverySection to the Server's recovery snapshots. You can only register sections before starting the Server.
+func (s *Server) RegisterRecoverySection(name string, section RecoverySection) error {
+	if s.isStarted() {
+		return errors.New(ErrorServerRunning)
+	} else if len(name) == 0 {
+		return errors.New("RegisterRecoverySection() requires a name")
+	} else if section.Save == nil || section.Restore == nil {
+		return errors.New("RecoverySection requires a Save and Restore function")
+	} else if _, ok := s.recoverySections[name]; ok {
+		return errors.New("The recovery section '" + name + "' is already registered")
+	}
+	if s.recoverySections == nil {
+		s.recoverySections = make(map[string]RecoverySection)
+	}
+	s.recoverySections[name] = section
+	return nil
+}
+
+// RegisterRecoverySection adds a RecoverySection to the default server's recovery snapshots. See *Server.RegisterRecoverySection() for more details.
+func RegisterRecoverySection(name string, section RecoverySection) error {
+	return defaultServer.RegisterRecoverySection(name, section)
+}
+
+// saveSections gets the data of every registered RecoverySection.
+func (s *Server) saveSections() map[string]recoverySection {
+	if len(s.recoverySections) == 0 {
+		return nil
+	}
+	sections := make(map[string]recoverySection, len(s.recoverySections))
+	for name, section := range s.recoverySections {
+		data, err := section.Save()
+		var j []byte
+		if err == nil {
+			j, err = json.Marshal(data)
+		}
+		if err != nil {
+			s.logger.Error("Error saving recovery section", "section", name, "error", err)
+			continue
+		}
+		sections[name] = recoverySection{Version: section.Version, Data: j}
+	}
+	return sections
+}
+
+// restoreSections restores the registered RecoverySections in a snapshot, and gets the names of the ones that were restored.
+func (s *Server) restoreSections(recovery serverRestore) []string {
+	restored := []string{}
+	for name, val := range recovery.S {
+		section, ok := s.recoverySections[name]
+		if !ok {
+			s.logger.Warn("Recovery section is not registered", "section", name)
+			continue
+		}
+		if err := section.Restore(val.Version, val.Data); err != nil {
+			s.logger.Error("Error restoring recovery section", "section", name, "error", err)
+			continue
+		}
+		restored = append(restored, name)
+	}
+	return restored
+}
@@ -0,0 +1,310 @@
+package gopher
+
+import (
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/database"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RecoveryStore stores the snapshots of a Server's state used for recovery. The Server picks a RecoveryStore with RecoveryStorage in
+// ServerSettings, or you can use your own with *Server.SetRecoveryStore().
+//
+// The snapshots are opaque to a RecoveryStore. The Server checks their integrity when loading them, and falls back to older snapshots
+// when the newest one is corrupt.
+type RecoveryStore interface {
+	// Save stores a new snapshot.
+	Save(data []byte) (RecoverySnapshot, error)
+	// Load gets the snapshot with the given ID. An empty ID loads the newest snapshot.
+	Load(id string) ([]byte, error)
+	// List lists the stored snapshots, newest first.
+	List() ([]RecoverySnapshot, error)
+	// Delete deletes the snapshot with the given ID.
+	Delete(id string) error
+}
+
+// RecoverySnapshot describes a snapshot in a RecoveryStore.
+type RecoverySnapshot struct {
+	ID   string    `json:"id"`
+	Time time.Time `json:"time"`
+	Size int       `json:"size"`
+}
+
+// RecoveryStorage options for ServerSettings
+const (
+	RecoveryStorageFile   = "file"
+	RecoveryStorageSql    = "sql"
+	RecoveryStorageMemory = "memory"
+)
+
+var errorRecoveryNoSnapshots = errors.New("There are no recovery snapshots")
+
+// SetRecoveryStore sets the RecoveryStore the Server saves and recovers it's state with, overriding RecoveryStorage in ServerSettings.
+// You can only set the RecoveryStore before starting the Server.
+func (s *Server) SetRecoveryStore(store RecoveryStore) error {
+	if s.isStarted() {
+		return errors.New(ErrorServerRunning)
+	}
+	s.recovery = store
+	return nil
+}
+
+// SetRecoveryStore is the same as *Server.SetRecoveryStore, but for the default server.
+func SetRecoveryStore(store RecoveryStore) error {
+	return defaultServer.SetRecoveryStore(store)
+}
+
+// recoveryStore gets the RecoveryStore set with SetRecoveryStore(), or the one picked by RecoveryStorage in ServerSettings.
+func (s *Server) recoveryStore() RecoveryStore {
+	if s.recovery != nil {
+		return s.recovery
+	}
+	switch s.getSettings().RecoveryStorage {
+	case RecoveryStorageSql:
+		return NewSqlRecoveryStore(s.database)
+	case RecoveryStorageMemory:
+		return s.memoryRecovery
+	}
+	return NewFileRecoveryStore(s.getSettings().RecoveryLocation)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   File storage   //////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// FileRecoveryStore stores snapshots as "Gopher Recovery - <time>.grf" files in a folder. A snapshot's ID is it's file name.
+type FileRecoveryStore struct {
+	folder string
+}
+
+// NewFileRecoveryStore makes a FileRecoveryStore that stores snapshots in the given folder.
+func NewFileRecoveryStore(folder string) *FileRecoveryStore {
+	return &FileRecoveryStore{folder: folder}
+}
+
+// Save writes the snapshot to a temporary file, syncs it to disk, then renames it into place, so a crash while saving never
+// leaves a partly written recovery file behind.
+func (f *FileRecoveryStore) Save(data []byte) (RecoverySnapshot, error) {
+	saved := time.Now()
+	// NANOSECONDS, SO SNAPSHOTS SAVED WITHIN THE SAME SECOND DON'T REPLACE EACH OTHER
+	name := "Gopher Recovery - " + saved.Format("2006-01-02 15-04-05.000000000") + ".grf"
+
+	tmp, err := ioutil.TempFile(f.folder, ".gopher-recovery-*.tmp")
+	if err != nil {
+		return RecoverySnapshot{}, err
+	}
+	if _, err = tmp.Write(data); err == nil {
+		err = tmp.Sync()
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0644)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), filepath.Join(f.folder, name))
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return RecoverySnapshot{}, err
+	}
+
+	// Sync the folder so the rename itself survives a crash. Not every system can sync a folder, so errors are ignored
+	if dir, dirErr := os.Open(f.folder); dirErr == nil {
+		dir.Sync()
+		dir.Close()
+	}
+
+	return RecoverySnapshot{ID: name, Time: saved, Size: len(data)}, nil
+}
+
+// Load reads a recovery file.
+func (f *FileRecoveryStore) Load(id string) ([]byte, error) {
+	if id == "" {
+		snapshots, err := f.List()
+		if err != nil {
+			return nil, err
+		} else if len(snapshots) == 0 {
+			return nil, errorRecoveryNoSnapshots
+		}
+		id = snapshots[0].ID
+	} else if !isRecoveryFile(id) {
+		return nil, errors.New("Invalid recovery file name")
+	}
+	return ioutil.ReadFile(filepath.Join(f.folder, id))
+}
+
+// List lists the recovery files in the folder, newest first.
+func (f *FileRecoveryStore) List() ([]RecoverySnapshot, error) {
+	infos, err := ioutil.ReadDir(f.folder)
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]RecoverySnapshot, 0, len(infos))
+	for _, info := range infos {
+		if info.IsDir() || !isRecoveryFile(info.Name()) {
+			continue
+		}
+		snapshots = append(snapshots, RecoverySnapshot{ID: info.Name(), Time: info.ModTime(), Size: int(info.Size())})
+	}
+	// Files saved closer together than the file system's time resolution are sorted by their names
+	sort.Slice(snapshots, func(i, j int) bool {
+		if snapshots[i].Time.Equal(snapshots[j].Time) {
+			return snapshots[i].ID > snapshots[j].ID
+		}
+		return snapshots[i].Time.After(snapshots[j].Time)
+	})
+	return snapshots, nil
+}
+
+// Delete deletes a recovery file.
+func (f *FileRecoveryStore) Delete(id string) error {
+	if !isRecoveryFile(id) {
+		return errors.New("Invalid recovery file name")
+	}
+	return os.Remove(filepath.Join(f.folder, id))
+}
+
+func isRecoveryFile(name string) bool {
+	return strings.HasPrefix(name, "Gopher Recovery") && strings.HasSuffix(name, ".grf") && filepath.Base(name) == name
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   SQL storage   ///////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// SqlRecoveryStore stores snapshots in the "recovery" table of a database Instance, so servers without a persistent disk
+// can still recover. The table is made the first time a snapshot is saved. The Instance must be initialized, which the
+// Server does on start-up with EnableSqlFeatures set in ServerSettings.
+type SqlRecoveryStore struct {
+	db *database.Instance
+}
+
+// NewSqlRecoveryStore makes a SqlRecoveryStore using the given database Instance.
+func NewSqlRecoveryStore(db *database.Instance) *SqlRecoveryStore {
+	return &SqlRecoveryStore{db: db}
+}
+
+// Save inserts the snapshot into the recovery table.
+func (q *SqlRecoveryStore) Save(data []byte) (RecoverySnapshot, error) {
+	state, err := q.db.SaveRecoveryState(data)
+	if err != nil {
+		return RecoverySnapshot{}, err
+	}
+	return RecoverySnapshot{ID: strconv.Itoa(state.ID), Time: state.Saved, Size: state.Size}, nil
+}
+
+// Load gets a snapshot from the recovery table.
+func (q *SqlRecoveryStore) Load(id string) ([]byte, error) {
+	if id == "" {
+		return q.db.GetRecoveryState(0)
+	}
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 {
+		return nil, errors.New("Invalid recovery snapshot ID")
+	}
+	return q.db.GetRecoveryState(n)
+}
+
+// List lists the snapshots in the recovery table, newest first.
+func (q *SqlRecoveryStore) List() ([]RecoverySnapshot, error) {
+	states, err := q.db.GetRecoveryStates()
+	if err != nil {
+		return nil, err
+	}
+	snapshots := make([]RecoverySnapshot, len(states))
+	for i, state := range states {
+		snapshots[i] = RecoverySnapshot{ID: strconv.Itoa(state.ID), Time: state.Saved, Size: state.Size}
+	}
+	return snapshots, nil
+}
+
+// Delete deletes a snapshot from the recovery table.
+func (q *SqlRecoveryStore) Delete(id string) error {
+	n, err := strconv.Atoi(id)
+	if err != nil || n < 1 {
+		return errors.New("Invalid recovery snapshot ID")
+	}
+	return q.db.DeleteRecoveryState(n)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Memory storage   ////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// MemoryRecoveryStore keeps snapshots in memory. They only survive restarting a Server within the same process, which makes
+// it mostly useful for tests.
+type MemoryRecoveryStore struct {
+	snapshots []RecoverySnapshot // oldest first
+	data      map[string][]byte
+	lastID    int
+	mux       sync.Mutex
+}
+
+// NewMemoryRecoveryStore makes an empty MemoryRecoveryStore.
+func NewMemoryRecoveryStore() *MemoryRecoveryStore {
+	return &MemoryRecoveryStore{data: make(map[string][]byte)}
+}
+
+// Save keeps a copy of the snapshot.
+func (m *MemoryRecoveryStore) Save(data []byte) (RecoverySnapshot, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.lastID++
+	snapshot := RecoverySnapshot{ID: strconv.Itoa(m.lastID), Time: time.Now(), Size: len(data)}
+	m.snapshots = append(m.snapshots, snapshot)
+	m.data[snapshot.ID] = append([]byte(nil), data...)
+	return snapshot, nil
+}
+
+// Load gets a copy of a snapshot.
+func (m *MemoryRecoveryStore) Load(id string) ([]byte, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if id == "" {
+		if len(m.snapshots) == 0 {
+			return nil, errorRecoveryNoSnapshots
+		}
+		id = m.snapshots[len(m.snapshots)-1].ID
+	}
+	data, ok := m.data[id]
+	if !ok {
+		return nil, errors.New("Recovery snapshot does not exist")
+	}
+	return append([]byte(nil), data...), nil
+}
+
+// List lists the snapshots, newest first.
+func (m *MemoryRecoveryStore) List() ([]RecoverySnapshot, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	snapshots := make([]RecoverySnapshot, len(m.snapshots))
+	for i, snapshot := range m.snapshots {
+		snapshots[len(m.snapshots)-1-i] = snapshot
+	}
+	return snapshots, nil
+}
+
+// Delete deletes a snapshot.
+func (m *MemoryRecoveryStore) Delete(id string) error {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if _, ok := m.data[id]; !ok {
+		return errors.New("Recovery snapshot does not exist")
+	}
+	delete(m.data, id)
+	for i, snapshot := range m.snapshots {
+		if snapshot.ID == id {
+			m.snapshots = append(m.snapshots[:i], m.snapshots[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
@@ -0,0 +1,311 @@
+package gopher
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestPruneState(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	write := func(name string, data []byte, age time.Duration) {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+		modTime := now.Add(-age)
+		os.Chtimes(file, modTime, modTime)
+	}
+	valid, err := encodeState(serverRestore{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Five recovery files, one hour apart. "Gopher Recovery 0.grf" is the newest, and a corrupt file is newer than all but one
+	for i := 0; i < 5; i++ {
+		write("Gopher Recovery "+strconv.Itoa(i)+".grf", valid, time.Duration(i)*time.Hour)
+	}
+	write("Gopher Recovery corrupt.grf", valid[:len(valid)-4], time.Minute*30)
+	ioutil.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+
+	store := NewFileRecoveryStore(dir)
+	kept := func() []string {
+		files, err := store.List()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var names []string
+		for _, f := range files {
+			names = append(names, f.ID)
+		}
+		return names
+	}
+
+	// The corrupt file doesn't count towards RecoveryKeep
+	server := NewServer(&ServerSettings{EnableRecovery: true, RecoveryLocation: dir, RecoveryKeep: 2})
+	server.pruneState()
+	if names := kept(); !reflect.DeepEqual(names, []string{"Gopher Recovery 0.grf", "Gopher Recovery 1.grf"}) {
+		t.Errorf("Kept %q", names)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Error("Deleted a file that isn't a recovery file")
+	}
+
+	// Files younger than RecoveryMaxAge are kept too
+	for i := 2; i < 5; i++ {
+		write("Gopher Recovery "+strconv.Itoa(i)+".grf", valid, time.Duration(i)*time.Hour)
+	}
+	server.settings.RecoveryMaxAge = 3
+	server.pruneState()
+	if names := kept(); !reflect.DeepEqual(names, []string{"Gopher Recovery 0.grf", "Gopher Recovery 1.grf", "Gopher Recovery 2.grf"}) {
+		t.Errorf("Kept %q", names)
+	}
+
+	// With only RecoveryMaxAge, the newest valid file is kept no matter how old it is
+	server.settings.RecoveryKeep = 0
+	server.settings.RecoveryMaxAge = 1
+	for i, name := range kept() {
+		write(name, valid, time.Hour*10+time.Duration(i)*time.Hour)
+	}
+	write("Gopher Recovery corrupt.grf", valid[:len(valid)-4], time.Hour*5)
+	server.pruneState()
+	if names := kept(); !reflect.DeepEqual(names, []string{"Gopher Recovery 0.grf"}) {
+		t.Errorf("Kept %q", names)
+	}
+}
+
+// loadCounter is a RecoveryStore that counts how many snapshots are loaded
+type loadCounter struct {
+	RecoveryStore
+	loads int
+}
+
+func (c *loadCounter) Load(id string) ([]byte, error) {
+	c.loads++
+	return c.RecoveryStore.Load(id)
+}
+
+func TestPruneStateLoads(t *testing.T) {
+	store := &loadCounter{RecoveryStore: NewMemoryRecoveryStore()}
+	server := NewServer(&ServerSettings{EnableRecovery: true, RecoveryKeep: 3, RecoveryMaxAge: 1})
+	server.SetRecoveryStore(store)
+	valid, _ := encodeState(serverRestore{})
+	for i := 0; i < 10; i++ {
+		store.Save(valid)
+	}
+
+	// Only the snapshots in the RecoveryKeep window are loaded, and only once
+	server.pruneState()
+	if store.loads != 3 {
+		t.Errorf("Loaded %v snapshots, expected 3", store.loads)
+	}
+	for i := 0; i < 5; i++ {
+		if err := server.saveState(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if store.loads != 3 {
+		t.Errorf("Saving loaded %v more snapshots", store.loads-3)
+	}
+	if snapshots, _ := store.List(); len(snapshots) != 15 {
+		t.Errorf("Kept %v snapshots younger than RecoveryMaxAge", len(snapshots))
+	}
+}
+
+func TestRecoverState(t *testing.T) {
+	dir := t.TempDir()
+	settings := &ServerSettings{EnableRecovery: true, RecoveryLocation: dir}
+	server := NewServer(settings)
+	server.Core().NewRoomType("lobby", false)
+	room, _ := server.Core().NewRoom("main", "lobby", false, 0, "server")
+	room.SetVariable("round", 3)
+	if err := server.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again right away makes a second file, and leaves no temporary files behind
+	if err := server.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	infos, _ := ioutil.ReadDir(dir)
+	files, _ := NewFileRecoveryStore(dir).List()
+	if len(infos) != 2 || len(files) != 2 {
+		t.Fatalf("Saved %d files, %d of them recovery files. Expected 2", len(infos), len(files))
+	}
+
+	// A newer file cut off half way through writing
+	saved, _ := ioutil.ReadFile(filepath.Join(dir, files[0].ID))
+	truncated := filepath.Join(dir, "Gopher Recovery - truncated.grf")
+	ioutil.WriteFile(truncated, saved[:len(saved)-4], 0644)
+	later := time.Now().Add(time.Minute)
+	os.Chtimes(truncated, later, later)
+	if _, err := decodeState(saved[:len(saved)-4]); err != errorRecoveryChecksum {
+		t.Errorf("Decoding truncated file returned %v", err)
+	}
+
+	recovered := NewServer(settings)
+	recovered.Core().NewRoomType("lobby", false)
+	recovered.recoverState()
+	room, err := recovered.Core().GetRoom("main")
+	if err != nil {
+		t.Fatal("Room wasn't recovered from the older file")
+	}
+	if round, _ := room.GetVariable("round"); round != float64(3) {
+		t.Errorf("Room variable 'round' is %v", round)
+	}
+}
+
+func TestMemoryRecoveryStore(t *testing.T) {
+	store := NewMemoryRecoveryStore()
+	if _, err := store.Load(""); err != errorRecoveryNoSnapshots {
+		t.Errorf("Loading from an empty store returned %v", err)
+	}
+	first, _ := store.Save([]byte("first"))
+	second, _ := store.Save([]byte("second"))
+	snapshots, err := store.List()
+	if err != nil || len(snapshots) != 2 || snapshots[0].ID != second.ID || snapshots[1].ID != first.ID {
+		t.Fatalf("Listed %+v, %v", snapshots, err)
+	}
+	if data, _ := store.Load(""); string(data) != "second" {
+		t.Errorf("Loaded newest snapshot %q", data)
+	}
+	if data, _ := store.Load(first.ID); string(data) != "first" {
+		t.Errorf("Loaded snapshot %q", data)
+	}
+	if err = store.Delete(second.ID); err != nil {
+		t.Fatal(err)
+	}
+	if snapshots, _ = store.List(); len(snapshots) != 1 || snapshots[0].ID != first.ID {
+		t.Errorf("Listed %+v after deleting", snapshots)
+	}
+
+	// RecoveryStorage picks the Server's own memory store, which falls back past a corrupt snapshot like the others
+	server := NewServer(&ServerSettings{EnableRecovery: true, RecoveryStorage: RecoveryStorageMemory})
+	server.Core().NewRoomType("lobby", false)
+	room, _ := server.Core().NewRoom("main", "lobby", false, 0, "server")
+	room.SetVariable("round", 3)
+	if err = server.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	if server.recoveryStore() != server.recoveryStore() {
+		t.Fatal("Got a different memory store")
+	}
+	saved, _ := server.recoveryStore().Load("")
+	server.recoveryStore().Save(saved[:len(saved)-4])
+	room.Delete()
+	server.recoverState()
+	if room, err = server.Core().GetRoom("main"); err != nil {
+		t.Fatal("Room wasn't recovered from the older snapshot")
+	}
+	if round, _ := room.GetVariable("round"); round != float64(3) {
+		t.Errorf("Room variable 'round' is %v", round)
+	}
+}
+
+func TestRestoreSnapshot(t *testing.T) {
+	server := NewServer(&ServerSettings{EnableRecovery: true, RecoveryStorage: RecoveryStorageMemory})
+	server.Core().NewRoomType("lobby", false)
+	world, _ := server.Core().NewRoom("world", "lobby", false, 0, "server")
+	world.SetVariable("blocks", 10)
+	server.Core().NewRoom("arena", "lobby", false, 0, "server")
+	if err := server.saveState(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Grief the world
+	world.SetVariable("blocks", 0)
+	world.SetVariable("graffiti", true)
+	arena, _ := server.Core().GetRoom("arena")
+	arena.Delete()
+	server.Core().NewRoom("spam", "lobby", false, 0, "bob")
+
+	snapshots, err := server.listSnapshots()
+	if err != nil || len(snapshots) != 1 || snapshots[0].Rooms != 2 {
+		t.Fatalf("Listed %+v, %v", snapshots, err)
+	}
+	id := snapshots[0].ID
+	diff, err := server.diffSnapshot(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := snapshotDiff{Added: []string{"arena"}, Removed: []string{"spam"},
+		Changed: map[string][]string{"world": {"vars.blocks: 0 -> 10", "vars.graffiti: true -> (none)"}}}
+	if !reflect.DeepEqual(diff, want) {
+		t.Errorf("Diff is %+v, expected %+v", diff, want)
+	}
+
+	// Merging keeps the live Rooms and variables that aren't in the snapshot
+	if _, err = server.restoreSnapshot(id, false); err != nil {
+		t.Fatal(err)
+	}
+	if blocks, _ := world.GetVariable("blocks"); blocks != float64(10) {
+		t.Errorf("Variable 'blocks' is %v after merging", blocks)
+	}
+	if graffiti, _ := world.GetVariable("graffiti"); graffiti != true {
+		t.Error("Merging removed variable 'graffiti'")
+	}
+	if _, err = server.Core().GetRoom("spam"); err != nil {
+		t.Error("Merging deleted Room 'spam'")
+	}
+
+	// Replacing matches the snapshot exactly
+	result, err := server.restoreSnapshot(id, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result.Deleted, []string{"spam"}) {
+		t.Errorf("Replacing deleted %v", result.Deleted)
+	}
+	if diff, _ = server.diffSnapshot(id); len(diff.Added)+len(diff.Removed)+len(diff.Changed) != 0 {
+		t.Errorf("Diff after replacing is %+v", diff)
+	}
+}
+
+func TestRecoveryFormat(t *testing.T) {
+	// A version 1 snapshot, with the old field names
+	v1 := []byte(`{"R":{"main":{"T":"lobby","P":true,"O":"bob","M":4,"I":["bill"],"V":{"round":3}}}}`)
+	sum := sha256.Sum256(v1)
+	v1 = append([]byte("GOPHER-RECOVERY 1 "+hex.EncodeToString(sum[:])+"\n"), v1...)
+	recovery, err := decodeState(v1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := core.RoomRecoveryState{T: "lobby", P: true, O: "bob", M: 4, I: []string{"bill"}, V: map[string]interface{}{"round": float64(3)}}
+	if recovery.version != 1 || !reflect.DeepEqual(recovery.R["main"], want) {
+		t.Errorf("Migrated version %d snapshot to %+v", recovery.version, recovery.R["main"])
+	}
+	if _, err = decodeState([]byte("GOPHER-RECOVERY 99 " + hex.EncodeToString(sum[:]) + "\n{}")); err != errorRecoveryVersion {
+		t.Errorf("Decoding a newer version returned %v", err)
+	}
+
+	// Custom sections
+	server := NewServer(&ServerSettings{EnableRecovery: true, RecoveryStorage: RecoveryStorageMemory})
+	score := 10
+	var restoredVersion int
+	err = server.RegisterRecoverySection("scores", RecoverySection{
+		Version: 2,
+		Save:    func() (interface{}, error) { return score, nil },
+		Restore: func(version int, data json.RawMessage) error {
+			restoredVersion = version
+			return json.Unmarshal(data, &score)
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = server.saveState(); err != nil {
+		t.Fatal(err)
+	}
+	score = 0
+	server.recoverState()
+	if score != 10 || restoredVersion != 2 {
+		t.Errorf("Restored score %d with version %d", score, restoredVersion)
+	}
+}
@@ -0,0 +1,138 @@
+package gopher
+
+import (
+	"errors"
+	"os"
+	"os/signal"
+	"reflect"
+	"strings"
+	"syscall"
+)
+
+// ReloadReport describes the outcome of reloading a Server's settings.
+type ReloadReport struct {
+	Applied     []string // ServerSettings fields that changed and were applied to the running Server
+	NeedRestart []string // ServerSettings fields that changed, but will only take effect after restarting the Server
+}
+
+// The ServerSettings fields that can safely change while a Server is running. All other fields need a restart.
+var runtimeSettings map[string]bool = map[string]bool{
+	"MaxConnections":    true,
+	"HostName":          true,
+	"HostAlias":         true,
+	"OriginOnly":        true,
+	"PingInterval":      true,
+	"PongTimeout":       true,
+	"ReadTimeout":       true,
+	"IdleTimeout":       true,
+	"MaxUserConns":      true,
+	"KickDupOnLogin":    true,
+	"UserRoomControl":   true,
+	"RoomDeleteOnLeave": true,
+	"RecoveryKeep":      true,
+	"RecoveryMaxAge":    true,
+	"AdminLogin":        true,
+	"AdminPassword":     true,
+}
+
+// SetSettingsFile sets the file that the Server re-reads its ServerSettings from when reloading. Environment variable overrides
+// (see SettingsEnvPrefix) are always re-read. You can only set the settings file before starting the Server.
+func (s *Server) SetSettingsFile(path string) error {
+	if s.isStarted() {
+		return errors.New(ErrorServerRunning)
+	}
+	s.settingsFile = path
+	return nil
+}
+
+// SetSettingsFile is the same as *Server.SetSettingsFile, but for the default server.
+func SetSettingsFile(path string) error {
+	return defaultServer.SetSettingsFile(path)
+}
+
+// Reload re-reads the Server's settings file (see *Server.SetSettingsFile) and environment variables, then applies every setting that can
+// change at runtime. A running Server also reloads when the process receives a SIGHUP signal, or with the `reload` macro.
+//
+// The returned ReloadReport lists which changes were applied, and which changes need a restart to take effect. If the new settings
+// fail to load or validate, nothing is applied and the error is returned.
+func (s *Server) Reload() (ReloadReport, error) {
+	var report ReloadReport
+	if !s.wasRun() {
+		return report, errors.New("Cannot reload settings when the server is not running")
+	}
+	s.reloadMux.Lock()
+	defer s.reloadMux.Unlock()
+	current := s.getSettings()
+
+	// Load new settings
+	var newSettings *ServerSettings
+	var err error
+	if s.settingsFile != "" {
+		newSettings, err = LoadSettings(s.settingsFile)
+	} else {
+		copied := *current
+		newSettings = &copied
+		if err = newSettings.ApplyEnv(); err == nil {
+			err = newSettings.Validate()
+		}
+	}
+	if err != nil {
+		return report, err
+	}
+
+	// Compare with current settings
+	applied := *current
+	oldVal := reflect.ValueOf(current).Elem()
+	newVal := reflect.ValueOf(newSettings).Elem()
+	appliedVal := reflect.ValueOf(&applied).Elem()
+	for i := 0; i < oldVal.NumField(); i++ {
+		if reflect.DeepEqual(oldVal.Field(i).Interface(), newVal.Field(i).Interface()) {
+			continue
+		}
+		name := oldVal.Type().Field(i).Name
+		if runtimeSettings[name] {
+			appliedVal.Field(i).Set(newVal.Field(i))
+			report.Applied = append(report.Applied, name)
+		} else {
+			report.NeedRestart = append(report.NeedRestart, name)
+		}
+	}
+
+	// Apply runtime settings
+	s.setSettings(&applied)
+	s.core.SettingsUpdate(applied.KickDupOnLogin, applied.RoomDeleteOnLeave, applied.MaxUserConns)
+
+	return report, nil
+}
+
+// Reload reloads the default server's settings. See *Server.Reload for more details.
+func Reload() (ReloadReport, error) {
+	return defaultServer.Reload()
+}
+
+func (s *Server) reloadAndLog() {
+	s.logger.Info("Reloading settings...")
+	report, err := s.Reload()
+	if err != nil {
+		s.logger.Error("Error reloading settings", "error", err)
+		return
+	}
+	if len(report.NeedRestart) > 0 {
+		s.logger.Warn("Some setting changes need a restart", "settings", strings.Join(report.NeedRestart, ","))
+	}
+	s.logger.Info("Settings reloaded", "applied", strings.Join(report.Applied, ","))
+}
+
+func (s *Server) reloadListener(done chan struct{}) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	defer signal.Stop(sig)
+	for {
+		select {
+		case <-sig:
+			s.reloadAndLog()
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package gopher
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// reloadServer makes a Server that can be reloaded without listening on any ports
+func reloadServer(settings *ServerSettings, file string) *Server {
+	server := NewServer(settings)
+	server.SetSettingsFile(file)
+	server.serverStarted = true
+	return server
+}
+
+func TestReload(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	write := func(json string) {
+		if err := ioutil.WriteFile(file, []byte(json), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"ServerName": "!server!", "HostName": "localhost", "DisableListeners": true, "AdminLogin": "admin", "AdminPassword": "password"}`)
+	settings, err := LoadSettings(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := reloadServer(settings, file)
+
+	// Runtime settings are applied, the others wait for a restart
+	write(`{"ServerName": "!renamed!", "HostName": "localhost", "DisableListeners": true, "MaxConnections": 10, "MaxUserConns": 2,
+		"AdminLogin": "admin", "AdminPassword": "password"}`)
+	report, err := server.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Applied, []string{"MaxConnections", "MaxUserConns"}) || !reflect.DeepEqual(report.NeedRestart, []string{"ServerName"}) {
+		t.Errorf("Got report %+v", report)
+	}
+	if current := server.getSettings(); current.MaxConnections != 10 || current.ServerName != "!server!" {
+		t.Errorf("Settings are %+v after reloading", current)
+	}
+
+	// Invalid settings leave the current ones untouched
+	before := server.getSettings()
+	write(`{"ServerName": "!server!", "MaxConnections": 20}`)
+	if _, err = server.Reload(); err == nil {
+		t.Error("Reloading invalid settings should fail")
+	}
+	if server.getSettings() != before || before.MaxConnections != 10 {
+		t.Error("Invalid settings were applied")
+	}
+}
+
+func TestReloadEnv(t *testing.T) {
+	server := reloadServer(&ServerSettings{ServerName: "!server!", HostName: "localhost", DisableListeners: true, AdminLogin: "admin", AdminPassword: "password"}, "")
+
+	// Environment variable overrides are re-read on every reload
+	t.Setenv("GOPHER_MAX_CONNECTIONS", "50")
+	t.Setenv("GOPHER_ENABLE_RECOVERY", "true")
+	t.Setenv("GOPHER_RECOVERY_LOCATION", t.TempDir())
+	report, err := server.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(report.Applied, []string{"MaxConnections"}) || !reflect.DeepEqual(report.NeedRestart, []string{"EnableRecovery", "RecoveryLocation"}) {
+		t.Errorf("Got report %+v", report)
+	}
+	t.Setenv("GOPHER_MAX_CONNECTIONS", "60")
+	if _, err = server.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	if server.getSettings().MaxConnections != 60 {
+		t.Errorf("MaxConnections is %v, expected 60", server.getSettings().MaxConnections)
+	}
+
+	// A bad override fails the reload
+	t.Setenv("GOPHER_MAX_CONNECTIONS", "lots")
+	if _, err = server.Reload(); err == nil || server.getSettings().MaxConnections != 60 {
+		t.Error("Bad environment variable was applied")
+	}
+}
+
+func TestReloadMaxConnections(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "settings.json")
+	write := func(maxConns string) {
+		data := `{"ServerName": "!server!", "HostName": "localhost", "DisableListeners": true, "MaxConnections": ` + maxConns + `,
+			"AdminLogin": "admin", "AdminPassword": "password"}`
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("3")
+	settings, err := LoadSettings(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer(settings)
+	server.SetSettingsFile(file)
+	runTestServer(t, server)
+
+	// connect serves a client in the background, and waits until it's counted
+	connect := func() *testConn {
+		conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 1), closed: make(chan struct{})}
+		want := server.ClientsConnected() + 1
+		go server.ServeClient(conn)
+		deadline := time.Now().Add(time.Second * 5)
+		for server.ClientsConnected() != want {
+			if time.Now().After(deadline) {
+				t.Fatal("Client didn't connect")
+			}
+			time.Sleep(time.Millisecond * 10)
+		}
+		return conn
+	}
+	conns := []*testConn{connect(), connect(), connect()}
+	defer func() {
+		for _, conn := range conns {
+			conn.Close()
+		}
+	}()
+
+	// Lowering MaxConnections below the live count refuses new clients until enough of them leave
+	write("1")
+	if _, err = server.Reload(); err != nil {
+		t.Fatal(err)
+	}
+	// refused checks that a new client is turned away, instead of being served
+	refused := func() bool {
+		conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 1), closed: make(chan struct{})}
+		defer conn.Close()
+		served := make(chan error, 1)
+		go func() { served <- server.ServeClient(conn) }()
+		select {
+		case err := <-served:
+			return err != nil
+		case <-time.After(time.Millisecond * 500):
+			return false
+		}
+	}
+	if !refused() {
+		t.Fatal("Client connected over MaxConnections")
+	}
+	conns[0].Close()
+	conns[1].Close()
+	for server.ClientsConnected() != 1 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	if !refused() {
+		t.Fatal("Client connected at MaxConnections")
+	}
+	conns[2].Close()
+	for server.ClientsConnected() != 0 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	conns = append(conns, connect())
+}
@@ -6,21 +6,23 @@ package gopher
 
 import (
 	"context"
-	"encoding/json"
+	"crypto/tls"
+	"errors"
 	"fmt"
 	"github.com/hewiefreeman/GopherGameServer/actions"
 	"github.com/hewiefreeman/GopherGameServer/core"
 	"github.com/hewiefreeman/GopherGameServer/database"
-	"io/ioutil"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"io"
+	"net"
 	"net/http"
-	"os"
 	"strconv"
+	"sync"
 	"time"
 )
 
 /////////// TO DOs:
 ///////////    - Make authentication for GopherDB
-///////////	- Admin tools
 ///////////    - More useful command-line macros
 
 // ServerSettings are the core settings for the Gopher Game Server. You must fill one of these out to customize
@@ -31,14 +33,27 @@ type ServerSettings struct {
 
 	HostName  string // Server's host name. Use 'https://' for TLS connections. (ex: 'https://example.com') (Required)
 	HostAlias string // Server's host alias name. Use 'https://' for TLS connections. (ex: 'https://www.example.com')
-	IP        string // Server's IP address. (Required)
-	Port      int    // Server's port. (Required)
+	IP        string // Server's IP address. (Required when Listeners is empty, unless DisableListeners is set)
+	Port      int    // Server's port. (Required when Listeners is empty, unless DisableListeners is set)
 
 	TLS         bool   // Enables TLS/SSL connections.
 	CertFile    string // SSL/TLS certificate file location (starting from system's root folder). (Required for TLS)
 	PrivKeyFile string // SSL/TLS private key file location (starting from system's root folder). (Required for TLS)
 
-	OriginOnly bool // When enabled, the server declines connections made from outside the origin server (Admin logins always check origin). IMPORTANT: Enable this for web apps and LAN servers.
+	Listeners        []Listener // Serves clients on several addresses at once, each with it's own path and TLS settings. When empty, the server makes one Listener from IP, Port, TLS, CertFile and PrivKeyFile.
+	DisableListeners bool       // Runs the server without any Listeners of it's own. Use *Server.SocketHandler() to serve clients from your own HTTP server instead.
+
+	UDPIP   string // The IP address for the unreliable UDP channel. When empty, it listens on every address. (Only used when UDPPort is set)
+	UDPPort int    // Enables the unreliable UDP channel for realtime state on this port. See *Room.UnreliableDataMessage(). When 0, there is no UDP channel.
+
+	TCPListeners []TCPListener // Also serves native clients over raw TCP on these addresses, with length-prefixed frames instead of websockets. TCPListeners are started even when DisableListeners is set.
+
+	PingInterval int // Seconds between pings to each client, to find dead connections and measure round-trip times (see ClientRTT()). When 0, clients are not pinged.
+	PongTimeout  int // Seconds to wait for a client to answer a ping before dropping it's connection. Defaults to PingInterval.
+	ReadTimeout  int // Seconds a client can go without sending anything (client actions or pongs) before it's connection is dropped. When 0, there is no limit.
+	IdleTimeout  int // Seconds a client can go without sending a client action (pongs don't count) before it's connection is dropped. When 0, there is no limit.
+
+	OriginOnly bool // When enabled, the server declines connections made from outside the origin server: the Origin must be HostName or HostAlias, with the scheme and port the client connected on unless they have their own (Admin logins always check origin). IMPORTANT: Enable this for web apps and LAN servers.
 
 	MultiConnect   bool  // Enables multiple connections under the same User. When enabled, will override KickDupOnLogin's functionality.
 	MaxUserConns   uint8 // Overrides the default (255) of maximum simultaneous connections on a single User
@@ -59,64 +74,227 @@ type ServerSettings struct {
 	RememberMe        bool   // Enables the "Remember Me" login feature. You can read more about this in project's wiki.
 
 	EnableRecovery   bool   // Enables the recovery of all Rooms, their settings, and their variables on start-up after terminating the server.
-	RecoveryLocation string // The folder location (starting from system's root folder) where you would like to store the recovery data. (Required for recovery)
-
-	AdminLogin    string // The login name for the Admin Tools (Required for Admin Tools)
-	AdminPassword string // The password for the Admin Tools (Required for Admin Tools)
+	RecoveryStorage  string // Where the recovery data is stored: RecoveryStorageFile ("file", the default) keeps files in RecoveryLocation, RecoveryStorageSql ("sql") keeps them in the database (needs EnableSqlFeatures), and RecoveryStorageMemory ("memory") only keeps them while the process runs. See also *Server.SetRecoveryStore()
+	RecoveryLocation string // The folder location (starting from system's root folder) where you would like to store the recovery data. (Required for file recovery storage)
+	RecoveryAutosave int    // Seconds between automatic saves of the server's state while it runs, so a crash doesn't lose every Room. When 0, the state is only saved on shut-down.
+	RecoveryKeep     int    // The number of newest valid recovery snapshots to keep. Older snapshots are deleted after each save, unless they are younger than RecoveryMaxAge. When 0, snapshots are only deleted by age.
+	RecoveryMaxAge   int    // Hours to keep recovery snapshots for. Older snapshots are deleted after each save, unless they are one of the newest RecoveryKeep snapshots. When 0, snapshots are only deleted by count.
+
+	AdminLogin           string // The login name for the Admin Tools (Required for Admin Tools)
+	AdminPassword        string // The password for the Admin Tools (Required for Admin Tools)
+	EnableAdminAPI       bool   // Enables the admin REST API, authenticated with AdminLogin and AdminPassword. IMPORTANT: Only enable this with TLS, or on a private network.
+	AdminAPIPath         string // The path of the admin REST API. Defaults to '/admin'.
+	EnableAdminDashboard bool   // Serves the admin web dashboard on the admin REST API's path + '/ui/' (ex: '/admin/ui/'). Needs EnableAdminAPI.
+
+	DisableMacroConsole bool // Stops gopher.Start() from reading command-line macros from stdin. Set this when running as a daemon or in a container without a TTY.
+
+	EnableMetrics bool   // Enables the Prometheus metrics endpoint.
+	MetricsPath   string // The path of the metrics endpoint. Defaults to '/metrics'.
+	MetricsIP     string // The IP address for a separate metrics listener. (Only used when MetricsPort is set)
+	MetricsPort   int    // The port for a separate metrics listener. When 0, the metrics endpoint is served on every Listener instead.
+
+	EnableHealthChecks bool // Enables the '/healthz' and '/readyz' endpoints for load balancers and orchestrators. They are served on every Listener, and on the metrics listener when MetricsPort is set.
 }
 
-type serverRestore struct {
-	R map[string]core.RoomRecoveryState
+// Listener is an address the server accepts client connections on. All Listeners of a server share the same Users and Rooms.
+type Listener struct {
+	IP          string // The Listener's IP address. (Required)
+	Port        int    // The Listener's port. (Required)
+	Path        string // The path clients connect to. Defaults to '/wss' for TLS Listeners, and '/ws' otherwise.
+	TLS         bool   // Enables TLS/SSL connections.
+	CertFile    string // SSL/TLS certificate file location (starting from system's root folder). (Required for TLS)
+	PrivKeyFile string // SSL/TLS private key file location (starting from system's root folder). (Required for TLS)
 }
 
-var (
-	httpServer *http.Server
-
-	settings *ServerSettings
-
-	serverStarted  bool       = false
-	serverPaused   bool       = false
-	serverStopping bool       = false
-	serverEndChan  chan error = make(chan error)
+// Server is a single Gopher Game Server. Each Server has it's own core, actions, and database Instance, so
+// several Servers can run side by side in one process without sharing any Users, Rooms, or CustomClientActions.
+// Make one with NewServer().
+type Server struct {
+	settings     *ServerSettings // REPLACED, NEVER CHANGED, WHILE RUNNING. READ WITH getSettings()
+	settingsMux  sync.RWMutex
+	settingsFile string
+	reloadMux    sync.Mutex
+
+	httpServers  []*http.Server
+	tcpListeners []net.Listener
+	udp          *udpChannel // MUST LOCK stateMux WHEN USING
+
+	core     *core.Instance
+	actions  *actions.Instance
+	database *database.Instance
+
+	conns   connections
+	logger  helpers.Logger
+	console *consoleLogger
+	metrics *helpers.Metrics
+	admin   adminSessions
+
+	macros    map[string]macro
+	macrosMux sync.Mutex
+	macroIn   io.Reader
+	macroOut  io.Writer
+
+	recovery         RecoveryStore
+	memoryRecovery   *MemoryRecoveryStore
+	recoverySections map[string]RecoverySection
+
+	// MUST LOCK pruneMux WHEN USING validSnapshots
+	validSnapshots map[string]bool // SNAPSHOT IDS ALREADY KNOWN TO DECODE, SO PRUNING DOESN'T LOAD THEM AGAIN
+	pruneMux       sync.Mutex
+
+	// MUST LOCK stateMux WHEN USING BELOW ITEMS
+	serverStarted  bool
+	serverPaused   bool
+	serverRunning  bool // THE LISTENERS ARE UP
+	serverStopping bool
+	serverDraining bool
+	stopped        chan struct{}
+	stateMux       sync.Mutex
+
+	serverEndChan chan error // ONLY SEND WITH endServer()
 
 	startCallback         func()
 	pauseCallback         func()
 	stopCallback          func()
 	resumeCallback        func()
 	clientConnectCallback func(*http.ResponseWriter, *http.Request) bool
+}
+
+var (
+	// THE Server USED BY THE PACKAGE-LEVEL FUNCTIONS
+	defaultServer *Server = newServer(nil, core.Default(), actions.Default(), database.Default())
+
+	// HOW OFTEN CLIENTS ARE REMINDED OF A SHUT-DOWN WHILE DRAINING
+	drainNoticeInterval time.Duration = time.Second * 10
 
 	//SERVER VERSION NUMBER
 	version string = "1.0-BETA.2"
 )
 
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Make a Server   /////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// NewServer makes a new Server with a pointer to your `ServerSettings` (or nil for defaults). The Server gets a fresh core, actions, and database
+// Instance, which you can retrieve with *Server.Core(), *Server.Actions(), and *Server.Database() to make RoomTypes, CustomClientActions, etc.
+// for that Server only. The server doesn't start listening until you call *Server.Run().
+func NewServer(s *ServerSettings) *Server {
+	db := database.NewInstance()
+	return newServer(s, core.NewInstance(db), actions.NewInstance(), db)
+}
+
+func newServer(s *ServerSettings, c *core.Instance, a *actions.Instance, db *database.Instance) *Server {
+	console := newConsoleLogger(helpers.DefaultLogger())
+	server := &Server{
+		settings: s,
+		core:     c,
+		actions:  a,
+		database: db,
+		logger:   console,
+		console:  console,
+		metrics:  helpers.NewMetrics(),
+
+		memoryRecovery: NewMemoryRecoveryStore(),
+		validSnapshots: make(map[string]bool),
+
+		stopped:       make(chan struct{}),
+		serverEndChan: make(chan error, 1)}
+	c.SetLogger(console)
+	a.SetLogger(console)
+	db.SetLogger(console)
+	c.SetMetrics(server.metrics)
+	a.SetMetrics(server.metrics)
+	db.SetMetrics(server.metrics)
+	c.SetUnreliableSender(server.sendUnreliable)
+	server.registerBuiltInMacros()
+	return server
+}
+
+// Core gets the core Instance of the Server.
+func (s *Server) Core() *core.Instance {
+	return s.core
+}
+
+// Actions gets the actions Instance of the Server.
+func (s *Server) Actions() *actions.Instance {
+	return s.actions
+}
+
+// Database gets the database Instance of the Server.
+func (s *Server) Database() *database.Instance {
+	return s.database
+}
+
+// SetLogger sets the Logger for the Server and it's core, actions, and database Instances. The default Logger writes Info and higher
+// level messages to stdout. You can only set the Logger before starting the Server.
+func (s *Server) SetLogger(l helpers.Logger) error {
+	if s.isStarted() {
+		return errors.New(ErrorServerRunning)
+	}
+	// The Instances log through the console logger, which also streams to remote consoles
+	s.console.setNext(l)
+	return nil
+}
+
+// SetLogger sets the Logger for the default server. See *Server.SetLogger() for more details.
+func SetLogger(l helpers.Logger) error {
+	return defaultServer.SetLogger(l)
+}
+
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 //   Server start-up   ///////////////////////////////////////////////////////////////////////////////
 //////////////////////////////////////////////////////////////////////////////////////////////////////
 
-// Start will start the server. Call with a pointer to your `ServerSettings` (or nil for defaults) to start the server. The default
+// Start will start the default server. Call with a pointer to your `ServerSettings` (or nil for defaults) to start the server. The default
 // settings are for local testing ONLY. There are security-related options in `ServerSettings`
 // for SSL/TLS, connection origin testing, administrator tools, and more. It's highly recommended to look into
 // all `ServerSettings` options to tune the server for your desired functionality and security needs.
 //
 // This function will block the thread that it is ran on until the server either errors, or is manually shut-down. To run code after the
-// server starts/stops/pauses/etc, use the provided server callback setter functions.
+// server starts/stops/pauses/etc, use the provided server callback setter functions. The default server also listens for command-line macros
+// on stdin, unless DisableMacroConsole is set in ServerSettings (see SetMacroConsole() to read them from somewhere else).
 func Start(s *ServerSettings) {
-	if serverStarted || serverPaused {
+	if defaultServer.wasRun() {
 		return
 	}
-	serverStarted = true
+	defaultServer.setSettings(s)
+	if s == nil || !s.DisableMacroConsole {
+		go defaultServer.macroListener(defaultServer.stoppedChan())
+	}
+	defaultServer.Run(context.Background())
+}
+
+// Run will start the Server. This function will block the thread that it is ran on until the Server either errors, is manually shut-down,
+// or the Context is done, in which case the Server is shut-down. Returns nil when the Server was shut-down without errors.
+func (s *Server) Run(ctx context.Context) (err error) {
+	s.stateMux.Lock()
+	if s.serverStarted || s.serverPaused {
+		s.stateMux.Unlock()
+		return errors.New(ErrorServerRunning)
+	}
+	s.serverStarted = true
+	s.stateMux.Unlock()
+
+	// Undo the start-up when it fails, so the Server can be ran again
+	listening := false
+	defer func() {
+		if err != nil && !listening {
+			s.abortStart()
+		}
+	}()
+
 	fmt.Println("  _______                __\n |   _   |.-----..-----.|  |--..-----..----.\n |.  |___||. _  ||. _  ||.    ||. -__||.  _|\n |.  |   ||:. . ||:. __||: |: ||:    ||: |\n |:  |   |'-----'|: |   '--'--''-----''--'\n |::.. . |       '--' - Game Server -\n '-------'\n\n ")
-	fmt.Println("Starting server...")
+	s.logger.Info("Starting server...")
 	// Set server settings
-	if s != nil {
-		if !s.verify() {
-			return
+	if s.settings != nil {
+		if err = s.settings.Validate(); err != nil {
+			s.logger.Error("Invalid ServerSettings. Shutting down...", "error", err)
+			return err
 		}
-		settings = s
 	} else {
 		// Default localhost settings
-		fmt.Println("Using default settings...")
-		settings = &ServerSettings{
+		s.logger.Info("Using default settings...")
+		s.setSettings(&ServerSettings{
 			ServerName:     "!server!",
 			MaxConnections: 0,
 
@@ -152,144 +330,326 @@ func Start(s *ServerSettings) {
 			RecoveryLocation: "C:/",
 
 			AdminLogin:    "admin",
-			AdminPassword: "password"}
+			AdminPassword: "password"})
+	}
+	settings := s.getSettings()
+
+	// Load TLS certificates
+	var listeners []Listener
+	if !settings.DisableListeners {
+		listeners = settings.listeners()
+	} else {
+		s.logger.Info("Listeners disabled. Serve clients with SocketHandler()")
+	}
+	certs := make([]*certReloader, len(listeners))
+	for i, l := range listeners {
+		if !l.TLS {
+			continue
+		}
+		if certs[i], err = newCertReloader(l.CertFile, l.PrivKeyFile, s.logger); err != nil {
+			s.logger.Error("TLS certificate error. Shutting down...", "certFile", l.CertFile, "error", err)
+			return err
+		}
+	}
+	tcpListeners := settings.tcpListeners()
+	tcpCerts := make([]*certReloader, len(tcpListeners))
+	for i, l := range tcpListeners {
+		if !l.TLS {
+			continue
+		}
+		if tcpCerts[i], err = newCertReloader(l.CertFile, l.PrivKeyFile, s.logger); err != nil {
+			s.logger.Error("TLS certificate error. Shutting down...", "certFile", l.CertFile, "error", err)
+			return err
+		}
 	}
 
 	// Update package settings
-	core.SettingsSet((*settings).KickDupOnLogin, (*settings).ServerName, (*settings).RoomDeleteOnLeave, (*settings).EnableSqlFeatures,
-		(*settings).RememberMe, (*settings).MultiConnect, (*settings).MaxUserConns)
+	s.core.SettingsSet(settings.KickDupOnLogin, settings.ServerName, settings.RoomDeleteOnLeave, settings.EnableSqlFeatures,
+		settings.RememberMe, settings.MultiConnect, settings.MaxUserConns)
 
 	// Notify packages of server start
-	core.SetServerStarted(true)
-	actions.SetServerStarted(true)
-	database.SetServerStarted(true)
+	s.core.SetServerStarted(true)
+	s.actions.SetServerStarted(true)
+	s.database.SetServerStarted(true)
 
 	// Start database
-	if (*settings).EnableSqlFeatures {
-		fmt.Println("Initializing database...")
-		dbErr := database.Init((*settings).SqlUser, (*settings).SqlPassword, (*settings).SqlDatabase,
-			(*settings).SqlProtocol, (*settings).SqlIP, (*settings).SqlPort, (*settings).EncryptionCost,
-			(*settings).RememberMe, (*settings).CustomLoginColumn)
-		if dbErr != nil {
-			fmt.Println("Database error:", dbErr.Error())
-			fmt.Println("Shutting down...")
-			return
+	if settings.EnableSqlFeatures {
+		s.logger.Info("Initializing database...")
+		err = s.database.Init(settings.SqlUser, settings.SqlPassword, settings.SqlDatabase,
+			settings.SqlProtocol, settings.SqlIP, settings.SqlPort, settings.EncryptionCost,
+			settings.RememberMe, settings.CustomLoginColumn)
+		if err != nil {
+			s.logger.Error("Database error. Shutting down...", "error", err)
+			return err
 		}
-		fmt.Println("Database initialized")
+		s.logger.Info("Database initialized")
 	}
 
 	// Recover state
 	if settings.EnableRecovery {
-		recoverState()
+		s.recoverState()
+	}
+	listening = true
+
+	// Start UDP channel before the listeners, so it's ready for the first client
+	runDone := make(chan struct{})
+	defer close(runDone)
+	if settings.UDPPort > 0 {
+		if udpErr := s.startUDP(runDone); udpErr != nil {
+			s.endServer(udpErr)
+		}
 	}
 
-	// Start socket listener
-	if settings.TLS {
-		httpServer = makeServer("/wss", settings.TLS)
-	} else {
-		httpServer = makeServer("/ws", settings.TLS)
+	// Start socket listeners
+	s.httpServers = make([]*http.Server, 0, len(listeners)+1)
+	for i, l := range listeners {
+		server, listenErr := s.makeServer(l, certs[i], runDone)
+		if listenErr != nil {
+			s.endServer(listenErr)
+			break
+		}
+		s.httpServers = append(s.httpServers, server)
+	}
+	s.tcpListeners = make([]net.Listener, 0, len(tcpListeners))
+	for i, l := range tcpListeners {
+		listener, listenErr := s.makeTCPServer(l, tcpCerts[i], runDone)
+		if listenErr != nil {
+			s.endServer(listenErr)
+			break
+		}
+		s.tcpListeners = append(s.tcpListeners, listener)
 	}
 
+	// Start heartbeat
+	go s.heartbeat(runDone)
+
+	// Start autosaving
+	if settings.EnableRecovery && settings.RecoveryAutosave > 0 {
+		go s.autosave(runDone)
+	}
+
+	// Start metrics listener
+	if settings.EnableMetrics && settings.MetricsPort > 0 {
+		s.httpServers = append(s.httpServers, s.makeMetricsServer())
+	}
+
+	// Take clients from ServeClient() and SocketHandler()
+	s.stateMux.Lock()
+	s.serverRunning = true
+	stopping := s.serverStopping
+	s.stateMux.Unlock()
+
 	// Run callback
-	if startCallback != nil {
-		startCallback()
+	if s.startCallback != nil {
+		s.startCallback()
 	}
 
-	// Start macro listener
-	go macroListener()
+	s.logger.Info("Startup complete")
 
-	fmt.Println("Startup complete")
+	// Finish a Shutdown() that was called during start-up
+	if stopping {
+		s.shutdown(context.Background())
+	}
+
+	// Shut down when the Context is done
+	go func() {
+		select {
+		case <-ctx.Done():
+			s.Shutdown(context.Background())
+		case <-runDone:
+		}
+	}()
+
+	// Reload settings on SIGHUP
+	go s.reloadListener(runDone)
 
 	// Wait for server shutdown
-	doneErr := <-serverEndChan
+	doneErr := <-s.serverEndChan
 
 	if doneErr != http.ErrServerClosed {
-		fmt.Println("Fatal server error:", doneErr.Error())
+		s.logger.Error("Fatal server error", "error", doneErr)
 
-		if !serverStopping {
-			fmt.Println("Disconnecting users...")
+		// Close remaining listeners
+		for _, server := range s.httpServers {
+			server.Close()
+		}
+		for _, listener := range s.tcpListeners {
+			listener.Close()
+		}
+		if u := s.getUDP(); u != nil {
+			u.conn.Close()
+		}
+
+		s.stateMux.Lock()
+		stopping = s.serverStopping
+		s.serverStopping = true
+		s.stateMux.Unlock()
+		if !stopping {
+			s.logger.Info("Disconnecting users...")
 
 			// Pause server
-			core.Pause()
-			actions.Pause()
-			database.Pause()
+			s.core.Pause()
+			s.actions.Pause()
+			s.database.Pause()
 
 			// Save state
 			if settings.EnableRecovery {
-				saveState()
+				s.saveState()
 			}
 		}
 	}
 
-	fmt.Println("Server shut-down completed")
+	s.logger.Info("Server shut-down completed")
+	s.stateMux.Lock()
+	close(s.stopped)
+	s.stateMux.Unlock()
 
-	if stopCallback != nil {
-		stopCallback()
+	if s.stopCallback != nil {
+		s.stopCallback()
 	}
-}
-
-func (settings *ServerSettings) verify() bool {
-	if settings.ServerName == "" {
-		fmt.Println("ServerName in ServerSettings is required. Shutting down...")
-		return false
 
-	} else if settings.HostName == "" || settings.IP == "" || settings.Port < 1 {
-		fmt.Println("HostName, IP, and Port in ServerSettings are required. Shutting down...")
-		return false
-
-	} else if settings.TLS == true && (settings.CertFile == "" || settings.PrivKeyFile == "") {
-		fmt.Println("CertFile and PrivKeyFile in ServerSettings are required for a TLS connection. Shutting down...")
-		return false
+	if doneErr != http.ErrServerClosed {
+		return doneErr
+	}
+	return nil
+}
 
-	} else if settings.EnableSqlFeatures == true && (settings.SqlIP == "" || settings.SqlPort < 1 || settings.SqlProtocol == "" ||
-		settings.SqlUser == "" || settings.SqlPassword == "" || settings.SqlDatabase == "") {
-		fmt.Println("SqlIP, SqlPort, SqlProtocol, SqlUser, SqlPassword, and SqlDatabase in ServerSettings are required for the SQL features. Shutting down...")
-		return false
+// abortStart undoes a failed start-up of Run(), and ends everything waiting for the Server to stop.
+func (s *Server) abortStart() {
+	s.core.SetServerStarted(false)
+	s.actions.SetServerStarted(false)
+	s.database.SetServerStarted(false)
+
+	s.stateMux.Lock()
+	s.serverStarted = false
+	s.serverStopping = false
+	close(s.stopped)
+	s.stopped = make(chan struct{})
+	s.stateMux.Unlock()
+}
 
-	} else if settings.EnableRecovery == true && settings.RecoveryLocation == "" {
-		fmt.Println("RecoveryLocation in ServerSettings is required for server recovery. Shutting down...")
-		return false
+// endServer ends Run() with an error. Only the first error is kept.
+func (s *Server) endServer(err error) {
+	select {
+	case s.serverEndChan <- err:
+	default:
+	}
+}
 
-	} else if settings.EnableRecovery {
-		// Check if invalid file location
-		if _, err := os.Stat(settings.RecoveryLocation); err != nil {
-			fmt.Println("RecoveryLocation error:", err)
-			fmt.Println("Shutting down...")
-			return false
-		}
-		var d []byte
-		if err := ioutil.WriteFile(settings.RecoveryLocation+"/test.txt", d, 0644); err != nil {
-			fmt.Println("RecoveryLocation error:", err)
-			fmt.Println("Shutting down...")
-			return false
+// listeners gets the Listeners to start, making one from IP, Port, TLS, CertFile and PrivKeyFile when none are set.
+func (settings *ServerSettings) listeners() []Listener {
+	// COPY, SO FILLING IN DEFAULTS DOESN'T CHANGE THE ServerSettings
+	listeners := make([]Listener, len(settings.Listeners))
+	copy(listeners, settings.Listeners)
+	if len(listeners) == 0 {
+		listeners = []Listener{{IP: settings.IP, Port: settings.Port, TLS: settings.TLS, CertFile: settings.CertFile, PrivKeyFile: settings.PrivKeyFile}}
+	}
+	for i := range listeners {
+		if listeners[i].Path != "" {
+			continue
+		} else if listeners[i].TLS {
+			listeners[i].Path = "/wss"
+		} else {
+			listeners[i].Path = "/ws"
 		}
-		os.Remove(settings.RecoveryLocation + "/test.txt")
-
-	} else if settings.AdminLogin == "" || settings.AdminPassword == "" {
-		fmt.Println("AdminLogin and AdminPassword in ServerSettings are required. Shutting down...")
-		return false
 	}
-
-	return true
+	return listeners
 }
 
-func makeServer(handleDir string, tls bool) *http.Server {
-	server := &http.Server{Addr: settings.IP + ":" + strconv.Itoa(settings.Port)}
-	http.HandleFunc(handleDir, socketInitializer)
-	if tls {
+// makeServer starts serving a Listener. The port is bound before it returns, so clients can connect once Run() is done starting up.
+func (s *Server) makeServer(l Listener, cert *certReloader, done chan struct{}) (*http.Server, error) {
+	listener, err := net.Listen("tcp", l.IP+":"+strconv.Itoa(l.Port))
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(l.Path, func(w http.ResponseWriter, r *http.Request) {
+		s.socketInitializer(w, r, l.Path)
+	})
+	settings := s.getSettings()
+	if settings.EnableMetrics && settings.MetricsPort == 0 {
+		mux.Handle(settings.metricsPath(), s.MetricsHandler())
+	}
+	if settings.EnableHealthChecks {
+		s.handleHealthChecks(mux)
+	}
+	if settings.EnableAdminAPI {
+		s.handleAdminAPI(mux)
+	}
+	server := &http.Server{Addr: l.IP + ":" + strconv.Itoa(l.Port), Handler: mux}
+	if l.TLS {
+		// Serve the certificate through GetCertificate so it can be swapped without a restart
+		server.TLSConfig = &tls.Config{GetCertificate: cert.getCertificate}
+		go cert.watch(done)
 		go func() {
-			err := server.ListenAndServeTLS(settings.CertFile, settings.PrivKeyFile)
-			serverEndChan <- err
+			s.endServer(server.ServeTLS(listener, "", ""))
 		}()
 	} else {
 		go func() {
-			err := server.ListenAndServe()
-			serverEndChan <- err
+			s.endServer(server.Serve(listener))
 		}()
 	}
 
 	//
-	return server
+	return server, nil
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Server state   //////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// isStarted reports whether Run() was called, and the Server isn't paused.
+func (s *Server) isStarted() bool {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.serverStarted
+}
+
+// wasRun reports whether Run() was called, and the Server didn't fail to start up.
+func (s *Server) wasRun() bool {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.serverStarted || s.serverPaused
+}
+
+// isRunning reports whether the Server's listeners are up, and it isn't shutting down.
+func (s *Server) isRunning() bool {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.serverRunning && !s.serverStopping
+}
+
+func (s *Server) isStopping() bool {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.serverStopping
+}
+
+func (s *Server) isDraining() bool {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.serverDraining
+}
+
+// getSettings gets the Server's current ServerSettings. They are replaced as a whole when reloading, so hold on to the pointer
+// when reading more than one setting.
+func (s *Server) getSettings() *ServerSettings {
+	s.settingsMux.RLock()
+	defer s.settingsMux.RUnlock()
+	return s.settings
+}
+
+func (s *Server) setSettings(settings *ServerSettings) {
+	s.settingsMux.Lock()
+	s.settings = settings
+	s.settingsMux.Unlock()
+}
+
+// stoppedChan gets the channel that is closed when the Server stops, or fails to start up.
+func (s *Server) stoppedChan() chan struct{} {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.stopped
 }
 
 //////////////////////////////////////////////////////////////////////////////////////////////////////
@@ -298,171 +658,191 @@ func makeServer(handleDir string, tls bool) *http.Server {
 
 // Pause will log all Users off and prevent anyone from logging in. All rooms and their variables created by the server will remain in memory.
 // Same goes for rooms created by Users unless RoomDeleteOnLeave in ServerSettings is set to true.
-func Pause() {
-	if !serverPaused {
-		serverPaused = true
-
-		fmt.Println("Pausing server...")
+func (s *Server) Pause() {
+	s.stateMux.Lock()
+	if s.serverPaused {
+		s.stateMux.Unlock()
+		return
+	}
+	s.serverPaused = true
+	s.stateMux.Unlock()
 
-		core.Pause()
-		actions.Pause()
-		database.Pause()
+	s.logger.Info("Pausing server...")
 
-		// Run callback
-		if pauseCallback != nil {
-			pauseCallback()
-		}
+	s.core.Pause()
+	s.actions.Pause()
+	s.database.Pause()
 
-		fmt.Println("Server paused")
-
-		serverStarted = false
+	// Run callback
+	if s.pauseCallback != nil {
+		s.pauseCallback()
 	}
 
+	s.logger.Info("Server paused")
+
+	s.stateMux.Lock()
+	s.serverStarted = false
+	s.stateMux.Unlock()
 }
 
 // Resume will allow Users to login again after pausing the server.
-func Resume() {
-	if serverPaused {
-		serverStarted = true
+func (s *Server) Resume() {
+	s.stateMux.Lock()
+	if !s.serverPaused {
+		s.stateMux.Unlock()
+		return
+	}
+	s.serverStarted = true
+	s.stateMux.Unlock()
 
-		fmt.Println("Resuming server...")
-		core.Resume()
-		actions.Resume()
-		database.Resume()
+	s.logger.Info("Resuming server...")
+	s.core.Resume()
+	s.actions.Resume()
+	s.database.Resume()
 
-		// Run callback
-		if resumeCallback != nil {
-			resumeCallback()
-		}
+	// Run callback
+	if s.resumeCallback != nil {
+		s.resumeCallback()
+	}
 
-		fmt.Println("Server resumed")
+	s.logger.Info("Server resumed")
 
-		serverPaused = false
-	}
+	s.stateMux.Lock()
+	s.serverPaused = false
+	s.stateMux.Unlock()
 }
 
-// ShutDown will log all Users off, save the state of the server if EnableRecovery in ServerSettings is set to true, then shut the server down.
-func ShutDown() error {
-	if !serverStopping {
-		serverStopping = true
-		fmt.Println("Disconnecting users...")
-
-		// Pause server
-		core.Pause()
-		actions.Pause()
-		database.Pause()
-
-		// Save state
-		if settings.EnableRecovery {
-			saveState()
-		}
-
-		// Shut server down
-		fmt.Println("Shutting server down...")
-		shutdownErr := httpServer.Shutdown(context.Background())
-		if shutdownErr != http.ErrServerClosed {
-			return shutdownErr
-		}
+// Shutdown will log all Users off, save the state of the server if EnableRecovery in ServerSettings is set to true, then shut the server down.
+// If the Context is done before all connections are closed, the Context's error is returned. When the Server is still starting up, it is shut
+// down as soon as the start-up completes.
+func (s *Server) Shutdown(ctx context.Context) error {
+	s.stateMux.Lock()
+	if s.serverStopping || (!s.serverStarted && !s.serverPaused) {
+		s.stateMux.Unlock()
+		return nil
 	}
-	//
-	return nil
+	s.serverStopping = true
+	running := s.serverRunning
+	s.stateMux.Unlock()
+	if !running {
+		// Run() finishes the shut-down after start-up
+		return nil
+	}
+	return s.shutdown(ctx)
 }
 
-//////////////////////////////////////////////////////////////////////////////////////////////////////
-//   Saving and recovery   ///////////////////////////////////////////////////////////////////////////
-//////////////////////////////////////////////////////////////////////////////////////////////////////
+func (s *Server) shutdown(ctx context.Context) error {
+	s.logger.Info("Disconnecting users...")
 
-func saveState() {
-	fmt.Println("Saving server state...")
-	saveErr := writeState(getState(), settings.RecoveryLocation)
-	if saveErr != nil {
-		fmt.Println("Error saving state:", saveErr)
-		return
+	// Pause server
+	s.core.Pause()
+	s.actions.Pause()
+	s.database.Pause()
+
+	// Save state
+	if s.getSettings().EnableRecovery {
+		s.saveState()
 	}
-	fmt.Println("Save state successful")
-}
 
-func writeState(stateObj serverRestore, saveFolder string) error {
-	state, err := json.Marshal(stateObj)
-	if err != nil {
-		return err
+	// Stop accepting TCP clients, and close the UDP channel
+	for _, listener := range s.tcpListeners {
+		listener.Close()
 	}
-	err = ioutil.WriteFile(saveFolder+"/Gopher Recovery - "+time.Now().Format("2006-01-02 15-04-05")+".grf", state, 0644)
-	if err != nil {
-		return err
+	if u := s.getUDP(); u != nil {
+		u.conn.Close()
 	}
 
-	return nil
-}
+	// Close client sockets
+	for _, conn := range s.conns.list() {
+		conn.Close()
+	}
 
-func getState() serverRestore {
-	return serverRestore{
-		R: core.GetRoomsState(),
+	// Shut server down
+	s.logger.Info("Shutting server down...")
+	var shutdownErr error
+	for _, server := range s.httpServers {
+		if err := server.Shutdown(ctx); err != nil && err != http.ErrServerClosed && shutdownErr == nil {
+			shutdownErr = err
+		}
+	}
+	if s.getSettings().DisableListeners {
+		// Nothing else ends Run() without Listeners
+		s.endServer(http.ErrServerClosed)
 	}
+	return shutdownErr
 }
 
-func recoverState() {
-	fmt.Println("Recovering previous state...")
-
-	// Get last recovery file
-	files, fileErr := ioutil.ReadDir(settings.RecoveryLocation)
-	if fileErr != nil {
-		fmt.Println("Error recovering state:", fileErr)
-		return
+// DrainAndShutdown will gracefully shut the server down. Every connected client is sent a helpers.ServerActionShutdownNotice message with the
+// number of seconds left until the shut-down (repeated every 10 seconds), and new logins and Rooms made by clients are refused. The server then waits
+// until no Rooms have any Users in them, the drain duration has passed, or the Context is done, whichever comes first. Finally, the
+// server is shut down the same way as *Server.Shutdown() does, which logs all Users off, saves the recovery state and closes the sockets.
+func (s *Server) DrainAndShutdown(ctx context.Context, drain time.Duration) error {
+	s.stateMux.Lock()
+	if !s.serverRunning || s.serverStopping || s.serverDraining {
+		s.stateMux.Unlock()
+		return nil
 	}
-	var newestFile string
-	var newestTime int64
-	for _, f := range files {
-		if len(f.Name()) < 19 || f.Name()[0:15] != "Gopher Recovery" {
-			continue
+	s.serverDraining = true
+	s.stateMux.Unlock()
+	s.core.SetDraining(true)
+
+	s.logger.Info("Draining server...", "drain", drain)
+	deadline := time.Now().Add(drain)
+	s.sendShutdownNotice(drain)
+
+	// Wait for Rooms to finish
+	ticker := time.NewTicker(time.Second)
+	lastNotice := time.Now()
+	for time.Now().Before(deadline) && s.core.ActiveRoomCount() > 0 {
+		select {
+		case <-ctx.Done():
+			ticker.Stop()
+			return s.Shutdown(ctx)
+		case <-ticker.C:
 		}
-		fi, err := os.Stat(settings.RecoveryLocation + "/" + f.Name())
-		if err != nil {
-			fmt.Println("Error recovering state:", err)
-			return
-		}
-		currTime := fi.ModTime().Unix()
-		if currTime > newestTime {
-			newestTime = currTime
-			newestFile = f.Name()
+		if time.Since(lastNotice) >= drainNoticeInterval {
+			s.logger.Info("Waiting for Rooms to empty...", "rooms", s.core.ActiveRoomCount(), "timeLeft", time.Until(deadline).Round(time.Second))
+			s.sendShutdownNotice(time.Until(deadline))
+			lastNotice = time.Now()
 		}
 	}
+	ticker.Stop()
 
-	// Read file
-	r, err := ioutil.ReadFile(settings.RecoveryLocation + "/" + newestFile)
-	if err != nil {
-		fmt.Println("Error recovering state:", err)
-		return
-	}
+	s.logger.Info("Server drained")
+	return s.Shutdown(ctx)
+}
 
-	// Convert JSON
-	var recovery serverRestore
-	if err = json.Unmarshal(r, &recovery); err != nil {
-		fmt.Println("Error recovering state:", err)
-		return
+func (s *Server) sendShutdownNotice(timeLeft time.Duration) {
+	seconds := int(timeLeft.Round(time.Second) / time.Second)
+	if seconds < 0 {
+		seconds = 0
 	}
-
-	if recovery.R == nil || len(recovery.R) == 0 {
-		fmt.Println("No rooms to restore!")
-		return
+	notice := map[string]interface{}{
+		helpers.ServerActionShutdownNotice: seconds,
 	}
-
-	// Recover rooms
-	for name, val := range recovery.R {
-		room, roomErr := core.NewRoom(name, val.T, val.P, val.M, val.O)
-		if roomErr != nil {
-			fmt.Println("Error recovering room '"+name+"':", roomErr)
-			continue
+	for _, conn := range s.conns.list() {
+		if err := s.writeMessage(conn, notice); err != nil {
+			s.logger.Warn("Error sending shut-down notice to client", "address", conn.RemoteAddr(), "error", err)
 		}
-		for _, userName := range val.I {
-			invErr := room.AddInvite(userName)
-			if invErr != nil {
-				fmt.Println("Error inviting '"+userName+"' to the room '"+name+"':", invErr)
-			}
-		}
-		room.SetVariables(val.V)
 	}
+}
 
-	//
-	fmt.Println("State recovery successful")
+// Pause will pause the default server. See *Server.Pause() for more details.
+func Pause() {
+	defaultServer.Pause()
+}
+
+// Resume will resume the default server after pausing it.
+func Resume() {
+	defaultServer.Resume()
+}
+
+// ShutDown will log all Users off, save the state of the default server if EnableRecovery in ServerSettings is set to true, then shut the server down.
+func ShutDown() error {
+	return defaultServer.Shutdown(context.Background())
+}
+
+// DrainAndShutDown will gracefully shut the default server down. See *Server.DrainAndShutdown() for more details.
+func DrainAndShutDown(drain time.Duration) error {
+	return defaultServer.DrainAndShutdown(context.Background(), drain)
 }
@@ -0,0 +1,156 @@
+package gopher
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// runTestServer runs a Server until the test ends, and returns once it takes clients. Ports left at 0 in ServerSettings get free ports,
+// and with DisableListeners the Server's SocketHandler() is served on a free port. Returns the address clients connect to.
+func runTestServer(t *testing.T, server *Server) string {
+	t.Helper()
+	settings := server.getSettings()
+	var addr string
+	if settings.DisableListeners {
+		api := httptest.NewServer(server.SocketHandler())
+		t.Cleanup(api.Close)
+		addr = api.Listener.Addr().String()
+	} else {
+		if len(settings.Listeners) == 0 && settings.Port == 0 {
+			settings.Port = freePort(t, "tcp")
+		}
+		for i := range settings.Listeners {
+			if settings.Listeners[i].Port == 0 {
+				settings.Listeners[i].Port = freePort(t, "tcp")
+			}
+		}
+		l := settings.listeners()[0]
+		addr = l.IP + ":" + strconv.Itoa(l.Port)
+	}
+	for i := range settings.TCPListeners {
+		if settings.TCPListeners[i].Port == 0 {
+			settings.TCPListeners[i].Port = freePort(t, "tcp")
+		}
+	}
+
+	ready := make(chan struct{})
+	server.SetStartCallback(func() { close(ready) })
+	ctx, cancel := context.WithCancel(context.Background())
+	errs := make(chan error, 1)
+	go func() { errs <- server.Run(ctx) }()
+	select {
+	case <-ready:
+	case err := <-errs:
+		cancel()
+		t.Fatal("Server didn't start:", err)
+	case <-time.After(time.Second * 10):
+		cancel()
+		t.Fatal("Server didn't start in time")
+	}
+	t.Cleanup(func() {
+		cancel()
+		if err := <-errs; err != nil {
+			t.Error(err)
+		}
+	})
+	return addr
+}
+
+// freePort gets a port that nothing is listening on
+func freePort(t *testing.T, network string) int {
+	t.Helper()
+	if network == "udp" {
+		conn, err := net.ListenPacket("udp", "localhost:0")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+		return conn.LocalAddr().(*net.UDPAddr).Port
+	}
+	listener, err := net.Listen("tcp", "localhost:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+func TestFailedStart(t *testing.T) {
+	settings := &ServerSettings{
+		ServerName:    "!server!",
+		HostName:      "localhost",
+		IP:            "localhost",
+		Port:          1,
+		TLS:           true,
+		CertFile:      "missing.crt",
+		PrivKeyFile:   "missing.key",
+		AdminLogin:    "admin",
+		AdminPassword: "password"}
+	server := NewServer(settings)
+	in, _ := io.Pipe()
+	macros := make(chan error, 1)
+	stopped := server.stoppedChan()
+	go func() { macros <- server.serveMacros(in, io.Discard, stopped) }()
+	if err := server.Run(context.Background()); err == nil {
+		t.Fatal("Run() should fail without the certificate")
+	}
+	if server.State() != ServerStateStopped {
+		t.Errorf("Server is %v after a failed start-up", server.State())
+	}
+	select {
+	case <-macros:
+	case <-time.After(time.Second):
+		t.Error("Macro console is still running")
+	}
+
+	// The Server can be ran again, and shuts down when the Context is done during start-up
+	settings.TLS = false
+	settings.DisableListeners = true
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	done := make(chan error, 1)
+	go func() { done <- server.Run(ctx) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Error(err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Server didn't shut down")
+	}
+}
+
+func TestIsolatedServers(t *testing.T) {
+	makeSettings := func() *ServerSettings {
+		return &ServerSettings{
+			ServerName:        "!server!",
+			HostName:          "localhost",
+			IP:                "localhost",
+			UserRoomControl:   true,
+			RoomDeleteOnLeave: true,
+			AdminLogin:        "admin",
+			AdminPassword:     "password"}
+	}
+	serverA := NewServer(makeSettings())
+	serverB := NewServer(makeSettings())
+
+	serverA.Core().NewRoomType("lobby", false)
+	if _, ok := serverB.Core().GetRoomTypes()["lobby"]; ok {
+		t.Error("RoomType made on one Server is visible on another")
+	}
+
+	runTestServer(t, serverA)
+	runTestServer(t, serverB)
+
+	if _, err := serverA.Core().NewRoom("room", "lobby", false, 0, ""); err != nil {
+		t.Error(err)
+	}
+	if serverB.Core().RoomCount() != 0 {
+		t.Error("Room made on one Server is visible on another")
+	}
+}
@@ -0,0 +1,382 @@
+package gopher
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	// SettingsEnvPrefix is the prefix for environment variables that override ServerSettings. The rest of the variable
+	// name is the ServerSettings field name in upper snake case. For instance, SqlPassword can be set with GOPHER_SQL_PASSWORD.
+	SettingsEnvPrefix = "GOPHER_"
+)
+
+// SettingsFieldError describes a single invalid ServerSettings field.
+type SettingsFieldError struct {
+	Field   string // The ServerSettings field name
+	Message string // What is wrong with the field
+}
+
+// SettingsError is returned when ServerSettings fail to load or validate. It lists every invalid field, not just the first one found.
+type SettingsError struct {
+	Fields []SettingsFieldError
+}
+
+func (e *SettingsError) Error() string {
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return ErrorInvalidSettings + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *SettingsError) add(field string, message string) {
+	e.Fields = append(e.Fields, SettingsFieldError{Field: field, Message: message})
+}
+
+func (e *SettingsError) errOrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Load settings   /////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// LoadSettings makes a ServerSettings from a JSON file, then applies any environment variable overrides (see SettingsEnvPrefix) and
+// validates the result. If path is an empty string, the settings are made from the environment variables only. Keys in the file can
+// either be the ServerSettings field names (ex: "SqlPassword") or snake case (ex: "sql_password"), and a null value leaves the option's
+// default. List options (like Listeners) are set in environment variables as a JSON list (ex: GOPHER_LISTENERS='[{"IP": "10.0.0.2", "Port": 8080}]').
+// Returns a *SettingsError listing every invalid field when the file or environment variables have bad values, or the resulting settings
+// don't pass validation.
+func LoadSettings(path string) (*ServerSettings, error) {
+	settings := &ServerSettings{}
+	if path != "" {
+		if ext := filepath.Ext(path); strings.ToLower(ext) != ".json" {
+			return nil, errors.New("Unsupported settings file type '" + ext + "', settings files must be JSON")
+		}
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		values, err := parseJSONSettings(data)
+		if err != nil {
+			return nil, err
+		}
+		if err = settings.apply(values); err != nil {
+			return nil, err
+		}
+	}
+	if err := settings.ApplyEnv(); err != nil {
+		return nil, err
+	}
+	if err := settings.Validate(); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// ApplyEnv overrides the ServerSettings with any set environment variables made from SettingsEnvPrefix and the field's name
+// in upper snake case (ex: GOPHER_SQL_PASSWORD, GOPHER_TLS, GOPHER_MAX_CONNECTIONS).
+func (settings *ServerSettings) ApplyEnv() error {
+	values := make(map[string]string)
+	t := reflect.TypeOf(*settings)
+	for i := 0; i < t.NumField(); i++ {
+		if val, ok := os.LookupEnv(SettingsEnvPrefix + envName(t.Field(i).Name)); ok {
+			values[t.Field(i).Name] = val
+		}
+	}
+	return settings.apply(values)
+}
+
+func (settings *ServerSettings) apply(values map[string]string) error {
+	errs := &SettingsError{}
+	v := reflect.ValueOf(settings).Elem()
+	t := v.Type()
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		raw := values[key]
+		var field reflect.StructField
+		found := false
+		for i := 0; i < t.NumField(); i++ {
+			if normalizeKey(t.Field(i).Name) == normalizeKey(key) {
+				field = t.Field(i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs.add(key, "unknown setting")
+			continue
+		}
+		fv := v.FieldByIndex(field.Index)
+		switch fv.Kind() {
+		case reflect.String:
+			fv.SetString(raw)
+		case reflect.Bool:
+			b, err := strconv.ParseBool(raw)
+			if err != nil {
+				errs.add(field.Name, "must be true or false")
+				continue
+			}
+			fv.SetBool(b)
+		case reflect.Int:
+			n, err := strconv.Atoi(raw)
+			if err != nil {
+				errs.add(field.Name, "must be an integer")
+				continue
+			}
+			fv.SetInt(int64(n))
+		case reflect.Uint8:
+			n, err := strconv.ParseUint(raw, 10, 8)
+			if err != nil {
+				errs.add(field.Name, "must be an integer from 0 to 255")
+				continue
+			}
+			fv.SetUint(n)
+		case reflect.Slice:
+			if err := json.Unmarshal([]byte(raw), fv.Addr().Interface()); err != nil {
+				errs.add(field.Name, "must be a JSON list")
+				continue
+			}
+		}
+	}
+	return errs.errOrNil()
+}
+
+func parseJSONSettings(data []byte) (map[string]string, error) {
+	var raw map[string]interface{}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+	values := make(map[string]string)
+	for key, val := range raw {
+		switch v := val.(type) {
+		case nil:
+			// KEEP THE DEFAULT
+		case string:
+			values[key] = v
+		case float64:
+			values[key] = strconv.FormatFloat(v, 'f', -1, 64)
+		case bool:
+			values[key] = strconv.FormatBool(v)
+		default:
+			list, _ := json.Marshal(v)
+			values[key] = string(list)
+		}
+	}
+	return values, nil
+}
+
+func normalizeKey(key string) string {
+	return strings.ToLower(strings.NewReplacer("_", "", "-", "").Replace(key))
+}
+
+// envName turns a field name into upper snake case (ex: SqlPassword -> SQL_PASSWORD, TLS -> TLS, IP -> IP)
+func envName(field string) string {
+	var b strings.Builder
+	r := []rune(field)
+	for i := 0; i < len(r); i++ {
+		if i > 0 && unicode.IsUpper(r[i]) && (unicode.IsLower(r[i-1]) || (i+1 < len(r) && unicode.IsLower(r[i+1]))) {
+			b.WriteRune('_')
+		}
+		b.WriteRune(unicode.ToUpper(r[i]))
+	}
+	return b.String()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Validate settings   /////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// Validate checks the ServerSettings for missing or invalid options. Returns a *SettingsError listing every invalid field, or nil if the
+// settings are valid.
+func (settings *ServerSettings) Validate() error {
+	errs := &SettingsError{}
+	if settings.ServerName == "" {
+		errs.add("ServerName", "required")
+	}
+	if settings.HostName == "" {
+		errs.add("HostName", "required")
+	}
+	if settings.MaxConnections < 0 {
+		errs.add("MaxConnections", "cannot be negative")
+	}
+	if len(settings.Listeners) == 0 && !settings.DisableListeners {
+		if settings.IP == "" {
+			errs.add("IP", "required")
+		}
+		if settings.Port < 1 {
+			errs.add("Port", "required")
+		}
+		if settings.TLS {
+			if settings.CertFile == "" {
+				errs.add("CertFile", "required for TLS")
+			}
+			if settings.PrivKeyFile == "" {
+				errs.add("PrivKeyFile", "required for TLS")
+			}
+		}
+	}
+	addrs := make(map[string]bool)
+	for i, l := range settings.Listeners {
+		name := "Listeners[" + strconv.Itoa(i) + "]"
+		if l.IP == "" {
+			errs.add(name+".IP", "required")
+		}
+		if l.Port < 1 {
+			errs.add(name+".Port", "required")
+		} else if addr := l.IP + ":" + strconv.Itoa(l.Port); addrs[addr] {
+			errs.add(name+".Port", "another Listener already uses "+addr)
+		} else {
+			addrs[addr] = true
+		}
+		if l.Path != "" && l.Path[0] != '/' {
+			errs.add(name+".Path", "must start with '/'")
+		}
+		if l.TLS {
+			if l.CertFile == "" {
+				errs.add(name+".CertFile", "required for TLS")
+			}
+			if l.PrivKeyFile == "" {
+				errs.add(name+".PrivKeyFile", "required for TLS")
+			}
+		}
+	}
+	if len(settings.Listeners) == 0 && !settings.DisableListeners && settings.Port > 0 {
+		addrs[settings.IP+":"+strconv.Itoa(settings.Port)] = true
+	}
+	for i, l := range settings.TCPListeners {
+		name := "TCPListeners[" + strconv.Itoa(i) + "]"
+		if l.IP == "" {
+			errs.add(name+".IP", "required")
+		}
+		if l.Port < 1 {
+			errs.add(name+".Port", "required")
+		} else if addr := l.IP + ":" + strconv.Itoa(l.Port); addrs[addr] {
+			errs.add(name+".Port", "another Listener already uses "+addr)
+		} else {
+			addrs[addr] = true
+		}
+		if l.Codec != "" && !containsString(helpers.CodecNames(), l.Codec) {
+			errs.add(name+".Codec", "the Codec '"+l.Codec+"' is not registered")
+		}
+		if l.MaxFrameSize < 0 {
+			errs.add(name+".MaxFrameSize", "cannot be negative")
+		}
+		if l.TLS {
+			if l.CertFile == "" {
+				errs.add(name+".CertFile", "required for TLS")
+			}
+			if l.PrivKeyFile == "" {
+				errs.add(name+".PrivKeyFile", "required for TLS")
+			}
+		}
+	}
+	if settings.PingInterval < 0 {
+		errs.add("PingInterval", "cannot be negative")
+	}
+	if settings.PongTimeout < 0 {
+		errs.add("PongTimeout", "cannot be negative")
+	}
+	if settings.ReadTimeout < 0 {
+		errs.add("ReadTimeout", "cannot be negative")
+	} else if settings.ReadTimeout > 0 && settings.ReadTimeout <= settings.PingInterval {
+		errs.add("ReadTimeout", "must be longer than PingInterval, or clients that only answer pings get dropped")
+	}
+	if settings.IdleTimeout < 0 {
+		errs.add("IdleTimeout", "cannot be negative")
+	}
+	if settings.UDPPort < 0 {
+		errs.add("UDPPort", "cannot be negative")
+	}
+	if settings.EnableSqlFeatures {
+		if settings.SqlIP == "" {
+			errs.add("SqlIP", "required for SQL features")
+		}
+		if settings.SqlPort < 1 {
+			errs.add("SqlPort", "required for SQL features")
+		}
+		if settings.SqlProtocol == "" {
+			errs.add("SqlProtocol", "required for SQL features")
+		}
+		if settings.SqlUser == "" {
+			errs.add("SqlUser", "required for SQL features")
+		}
+		if settings.SqlPassword == "" {
+			errs.add("SqlPassword", "required for SQL features")
+		}
+		if settings.SqlDatabase == "" {
+			errs.add("SqlDatabase", "required for SQL features")
+		}
+	}
+	switch settings.RecoveryStorage {
+	case "", RecoveryStorageFile, RecoveryStorageMemory:
+	case RecoveryStorageSql:
+		if settings.EnableRecovery && !settings.EnableSqlFeatures {
+			errs.add("RecoveryStorage", "SQL recovery storage needs EnableSqlFeatures")
+		}
+	default:
+		errs.add("RecoveryStorage", "must be \""+RecoveryStorageFile+"\", \""+RecoveryStorageSql+"\" or \""+RecoveryStorageMemory+"\"")
+	}
+	if settings.EnableRecovery && (settings.RecoveryStorage == "" || settings.RecoveryStorage == RecoveryStorageFile) {
+		if settings.RecoveryLocation == "" {
+			errs.add("RecoveryLocation", "required for server recovery")
+		} else if _, err := os.Stat(settings.RecoveryLocation); err != nil {
+			errs.add("RecoveryLocation", err.Error())
+		} else {
+			// Check if the folder can be written to
+			var d []byte
+			if err := ioutil.WriteFile(settings.RecoveryLocation+"/test.txt", d, 0644); err != nil {
+				errs.add("RecoveryLocation", err.Error())
+			} else {
+				os.Remove(settings.RecoveryLocation + "/test.txt")
+			}
+		}
+	}
+	if settings.RecoveryAutosave < 0 {
+		errs.add("RecoveryAutosave", "cannot be negative")
+	}
+	if settings.RecoveryKeep < 0 {
+		errs.add("RecoveryKeep", "cannot be negative")
+	}
+	if settings.RecoveryMaxAge < 0 {
+		errs.add("RecoveryMaxAge", "cannot be negative")
+	}
+	if settings.EnableMetrics {
+		if settings.MetricsPath != "" && settings.MetricsPath[0] != '/' {
+			errs.add("MetricsPath", "must start with '/'")
+		}
+		if settings.MetricsPort < 0 {
+			errs.add("MetricsPort", "cannot be negative")
+		}
+	}
+	if settings.EnableAdminAPI && settings.AdminAPIPath != "" && settings.AdminAPIPath[0] != '/' {
+		errs.add("AdminAPIPath", "must start with '/'")
+	}
+	if settings.EnableAdminDashboard && !settings.EnableAdminAPI {
+		errs.add("EnableAdminDashboard", "needs EnableAdminAPI")
+	}
+	if settings.AdminLogin == "" {
+		errs.add("AdminLogin", "required")
+	}
+	if settings.AdminPassword == "" {
+		errs.add("AdminPassword", "required")
+	}
+	return errs.errOrNil()
+}
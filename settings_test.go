@@ -0,0 +1,130 @@
+package gopher
+
+import (
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// settingsFields gets the invalid fields listed by a *SettingsError
+func settingsFields(err error) []string {
+	var settingsErr *SettingsError
+	if !errors.As(err, &settingsErr) {
+		return nil
+	}
+	fields := make([]string, 0, len(settingsErr.Fields))
+	for _, f := range settingsErr.Fields {
+		fields = append(fields, f.Field)
+	}
+	return fields
+}
+
+func TestLoadSettings(t *testing.T) {
+	dir := t.TempDir()
+	load := func(name string, data string) (*ServerSettings, error) {
+		file := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(file, []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return LoadSettings(file)
+	}
+	expected := Listener{IP: "localhost", Port: 9090, Path: "/play"}
+
+	// Snake case keys, with null leaving the default
+	settings, err := load("settings.json", `{"server_name": "!server!", "HostName": "localhost", "MaxConnections": null, "admin_login": "admin",
+		"AdminPassword": "password", "Listeners": [{"IP": "localhost", "Port": 9090, "Path": "/play"}]}`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if settings.ServerName != "!server!" || settings.AdminLogin != "admin" || settings.MaxConnections != 0 ||
+		!reflect.DeepEqual(settings.Listeners, []Listener{expected}) {
+		t.Errorf("Loaded settings.json as %+v", settings)
+	}
+
+	// Bad files
+	bad := map[string]string{
+		"broken.json":   `{"ServerName": "!server!"`,
+		"settings.yaml": `server_name: "!server!"`,
+		"settings.toml": `server_name = "!server!"`,
+	}
+	for name, data := range bad {
+		if _, err = load(name, data); err == nil {
+			t.Errorf("Loading %v should fail", name)
+		}
+	}
+	_, err = load("unknown.json", `{"ServerName": "!server!", "HostName": "localhost", "Port": 8080, "IP": "localhost", "AdminLogin": "admin",
+		"AdminPassword": "password", "NotASetting": true, "MaxConnections": "lots"}`)
+	if fields := settingsFields(err); !reflect.DeepEqual(fields, []string{"MaxConnections", "NotASetting"}) {
+		t.Errorf("Got invalid fields %v", fields)
+	}
+}
+
+func TestApplyEnv(t *testing.T) {
+	t.Setenv("GOPHER_SQL_PASSWORD", "secret")
+	t.Setenv("GOPHER_TLS", "true")
+	t.Setenv("GOPHER_MAX_CONNECTIONS", "5")
+	t.Setenv("GOPHER_LISTENERS", `[{"IP": "10.0.0.2", "Port": 8080}]`)
+	settings := ServerSettings{ServerName: "!server!", SqlPassword: "password"}
+	if err := settings.ApplyEnv(); err != nil {
+		t.Fatal(err)
+	}
+	if settings.ServerName != "!server!" || settings.SqlPassword != "secret" || !settings.TLS || settings.MaxConnections != 5 ||
+		!reflect.DeepEqual(settings.Listeners, []Listener{{IP: "10.0.0.2", Port: 8080}}) {
+		t.Errorf("Got settings %+v", settings)
+	}
+
+	// Every bad variable is listed
+	t.Setenv("GOPHER_TLS", "maybe")
+	t.Setenv("GOPHER_LISTENERS", "10.0.0.2:8080")
+	if fields := settingsFields(settings.ApplyEnv()); !reflect.DeepEqual(fields, []string{"Listeners", "TLS"}) {
+		t.Errorf("Got invalid fields %v", fields)
+	}
+}
+
+func TestEnvName(t *testing.T) {
+	names := map[string]string{
+		"SqlPassword":    "SQL_PASSWORD",
+		"MaxConnections": "MAX_CONNECTIONS",
+		"TLS":            "TLS",
+		"IP":             "IP",
+		"UDPPort":        "UDP_PORT",
+		"PrivKeyFile":    "PRIV_KEY_FILE",
+	}
+	for field, env := range names {
+		if name := envName(field); name != env {
+			t.Errorf("envName(%v) is %v, expected %v", field, name, env)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	valid := func() *ServerSettings {
+		return &ServerSettings{ServerName: "!server!", HostName: "localhost", IP: "localhost", Port: 8080, AdminLogin: "admin", AdminPassword: "password"}
+	}
+	if err := valid().Validate(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Every invalid field is listed
+	settings := valid()
+	settings.ServerName = ""
+	settings.MaxConnections = -1
+	settings.Listeners = []Listener{{IP: "localhost", Port: 8080}, {IP: "localhost", Port: 8080, Path: "wss", TLS: true}}
+	expected := []string{"ServerName", "MaxConnections", "Listeners[1].Port", "Listeners[1].Path", "Listeners[1].CertFile", "Listeners[1].PrivKeyFile"}
+	if fields := settingsFields(settings.Validate()); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Got invalid fields %v, expected %v", fields, expected)
+	}
+
+	// The Port is only needed without Listeners
+	settings = valid()
+	settings.Port = 0
+	if fields := settingsFields(settings.Validate()); !reflect.DeepEqual(fields, []string{"Port"}) {
+		t.Errorf("Got invalid fields %v", fields)
+	}
+	settings.DisableListeners = true
+	if err := settings.Validate(); err != nil {
+		t.Error(err)
+	}
+}
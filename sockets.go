@@ -4,18 +4,16 @@ import (
 	"github.com/gorilla/websocket"
 	"github.com/hewiefreeman/GopherGameServer/core"
 	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
-	"time"
-)
-
-var (
-	conns connections = connections{}
 )
 
 type connections struct {
 	conns    int
+	sockets  map[ClientTransport]*connState
 	connsMux sync.Mutex
 }
 
@@ -24,42 +22,108 @@ type clientAction struct {
 	P interface{} // parameters
 }
 
-func socketInitializer(w http.ResponseWriter, r *http.Request) {
-	//DECLINE CONNECTIONS COMING FROM OUTSIDE THE ORIGIN SERVER
-	if settings.OriginOnly {
-		origin := r.Header.Get("Origin") + ":" + strconv.Itoa(settings.Port)
-		host := settings.HostName + ":" + strconv.Itoa(settings.Port)
-		hostAlias := settings.HostAlias + ":" + strconv.Itoa(settings.Port)
-		if origin != host && (settings.HostAlias != "" && origin != hostAlias) {
-			http.Error(w, "Origin not allowed.", http.StatusForbidden)
+// SocketHandler gets an http.Handler that accepts client connections, so you can serve the game socket from your own HTTP server or router,
+// along with your own middleware and endpoints. Set DisableListeners in ServerSettings to run the Server without it's own Listeners, then
+// mount the handler on any path. Connections are refused with 503 (Service Unavailable) until the Server is running.
+func (s *Server) SocketHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isRunning() {
+			// Not started up yet, or shutting down
+			http.Error(w, "Server is not running.", http.StatusServiceUnavailable)
 			return
 		}
+		s.socketInitializer(w, r, r.URL.Path)
+	})
+}
+
+// SocketHandler gets the client connection http.Handler for the default server. See *Server.SocketHandler() for more details.
+func SocketHandler() http.Handler {
+	return defaultServer.SocketHandler()
+}
+
+// originAllowed checks that a request comes from HostName or HostAlias. The Origin's scheme, host and port must all match. When HostName
+// or HostAlias don't have a scheme or port, the ones the request came in on are expected, so the check is the same on a Listener and
+// through SocketHandler(). Requests without an Origin header are refused.
+func (s *Server) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return false
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	_, port, _ := net.SplitHostPort(r.Host)
+	origin = fullOrigin(origin, "", "")
+	settings := s.getSettings()
+	return origin == fullOrigin(settings.HostName, scheme, port) || (settings.HostAlias != "" && origin == fullOrigin(settings.HostAlias, scheme, port))
+}
+
+// fullOrigin turns an origin or host setting into "scheme://host:port", filling in a missing scheme or port with the given ones, or the
+// scheme's default port (ex: 'https://example.com' -> 'https://example.com:443', 'example.com' with "http" and "8080" -> 'http://example.com:8080')
+func fullOrigin(origin string, scheme string, port string) string {
+	if i := strings.Index(origin, "://"); i >= 0 {
+		scheme = strings.ToLower(origin[:i])
+		origin = origin[i+3:]
+	}
+	if i := strings.IndexByte(origin, '/'); i >= 0 {
+		origin = origin[:i]
+	}
+	host := origin
+	if h, p, err := net.SplitHostPort(origin); err == nil {
+		host, port = h, p
+	}
+	if port == "" {
+		if scheme == "https" {
+			port = "443"
+		} else {
+			port = "80"
+		}
+	}
+	return scheme + "://" + strings.ToLower(host) + ":" + port
+}
+
+func (s *Server) socketInitializer(w http.ResponseWriter, r *http.Request, path string) {
+	//DECLINE CONNECTIONS COMING FROM OUTSIDE THE ORIGIN SERVER
+	settings := s.getSettings()
+	if settings.OriginOnly && !s.originAllowed(r) {
+		http.Error(w, "Origin not allowed.", http.StatusForbidden)
+		return
 	}
 
 	//REJECT IF SERVER IS FULL
-	if !conns.add() {
+	if !s.conns.add(settings.MaxConnections) {
 		http.Error(w, "Server is full.", 413)
 		return
 	}
 
 	// CLIENT CONNECT CALLBACK
-	if clientConnectCallback != nil && !clientConnectCallback(&w, r) {
+	if s.clientConnectCallback != nil && !s.clientConnectCallback(&w, r) {
 		http.Error(w, "Could not establish a connection.", http.StatusForbidden)
 		return
 	}
 
-	//UPGRADE CONNECTION PING-PONG
-	conn, err := websocket.Upgrade(w, r, w.Header(), 1024, 1024)
+	//UPGRADE CONNECTION PING-PONG. THE CLIENT PICKS A Codec WITH THE SUBPROTOCOL
+	upgrader := websocket.Upgrader{
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+		Subprotocols:    helpers.CodecNames(),
+		CheckOrigin:     func(*http.Request) bool { return true },
+		Error:           func(http.ResponseWriter, *http.Request, int, error) {},
+	}
+	conn, err := upgrader.Upgrade(w, r, w.Header())
 	if err != nil {
 		http.Error(w, "Could not establish a connection.", http.StatusForbidden)
 		return
 	}
 
 	// START WEBSOCKET LOOP
-	go clientActionListener(conn)
+	client := newWebsocketConn(conn, path)
+	s.conns.track(client)
+	go s.clientActionListener(client)
 }
 
-func clientActionListener(conn *websocket.Conn) {
+func (s *Server) clientActionListener(conn ClientTransport) {
 	// CLIENT ACTION INPUT
 	var action clientAction
 
@@ -72,14 +136,23 @@ func clientActionListener(conn *websocket.Conn) {
 	var devicePass string
 	var deviceUserID int
 
-	if (*settings).RememberMe {
+	// THE CLIENT'S HEARTBEAT
+	state := s.conns.state(conn)
+	if state == nil {
+		state = newConnState()
+	}
+	if p, ok := conn.(PingTransport); ok {
+		p.OnPong(func() { s.pong(state) })
+	}
+
+	if s.getSettings().RememberMe {
 		//SEND TAG RETRIEVAL MESSAGE
 		tagMessage := map[string]interface{}{
 			helpers.ServerActionRequestDeviceTag: nil,
 		}
-		writeErr := conn.WriteJSON(tagMessage)
+		writeErr := s.writeMessage(conn, tagMessage)
 		if writeErr != nil {
-			closeSocket(conn)
+			s.closeSocket(conn)
 			return
 		}
 		//PARAMS
@@ -90,9 +163,9 @@ func clientActionListener(conn *websocket.Conn) {
 		//PING-PONG FOR TAGGING DEVICE - BREAKS WHEN THE DEVICE HAS BEEN PROPERLY TAGGED OR AUTHENTICATED.
 		for {
 			//READ INPUT BUFFER
-			readErr := conn.ReadJSON(&action)
+			readErr := s.receive(conn, state, &action)
 			if readErr != nil || action.A == "" {
-				closeSocket(conn)
+				s.closeSocket(conn)
 				return
 			}
 
@@ -101,16 +174,16 @@ func clientActionListener(conn *websocket.Conn) {
 				//NO DEVICE TAG. MAKE ONE AND SEND IT.
 				newDeviceTag, newDeviceTagErr := helpers.GenerateSecureString(32)
 				if newDeviceTagErr != nil {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				deviceTag = string(newDeviceTag)
 				tagMessage := map[string]interface{}{
 					helpers.ServerActionSetDeviceTag: deviceTag,
 				}
-				writeErr := conn.WriteJSON(tagMessage)
+				writeErr := s.writeMessage(conn, tagMessage)
 				if writeErr != nil {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 			} else if action.A == "1" {
@@ -118,20 +191,20 @@ func clientActionListener(conn *websocket.Conn) {
 				if sentDeviceTag, ohK := action.P.(string); ohK {
 					if len(deviceTag) > 0 && sentDeviceTag != deviceTag {
 						//CLIENT DIDN'T USE THE PROVIDED DEVICE CODE FROM THE SERVER
-						closeSocket(conn)
+						s.closeSocket(conn)
 						return
 					}
 					//SEND AUTO-LOG NOT FILED MESSAGE
 					notFiledMessage := map[string]interface{}{
 						helpers.ServerActionAutoLoginNotFiled: nil,
 					}
-					writeErr := conn.WriteJSON(notFiledMessage)
+					writeErr := s.writeMessage(conn, notFiledMessage)
 					if writeErr != nil {
-						closeSocket(conn)
+						s.closeSocket(conn)
 						return
 					}
 				} else {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 
@@ -143,55 +216,55 @@ func clientActionListener(conn *websocket.Conn) {
 				var pMap map[string]interface{}
 				devicePass, err = helpers.GenerateSecureString(32)
 				if err != nil {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				//GET PARAMS
 				if pMap, ok = action.P.(map[string]interface{}); !ok {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				if deviceTag, ok = pMap["dt"].(string); !ok {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				if oldPass, ok = pMap["da"].(string); !ok {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				var deviceUserIDStr string
 				if deviceUserIDStr, ok = pMap["di"].(string); !ok {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				//CONVERT di TO INT
 				deviceUserID, err = strconv.Atoi(deviceUserIDStr)
 				if err != nil {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				//CHANGE THE CLIENT'S PASS
 				newPassMessage := map[string]interface{}{
 					helpers.ServerActionSetAutoLoginPass: devicePass,
 				}
-				writeErr := conn.WriteJSON(newPassMessage)
+				writeErr := s.writeMessage(conn, newPassMessage)
 				if writeErr != nil {
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 			} else if action.A == "3" {
 				if deviceTag == "" || oldPass == "" || deviceUserID == 0 || devicePass == "" {
 					//IRRESPONSIBLE USAGE
-					closeSocket(conn)
+					s.closeSocket(conn)
 					return
 				}
 				//AUTO-LOG THE CLIENT
-				connID, gErr = core.AutoLogIn(deviceTag, oldPass, devicePass, deviceUserID, conn, &user, &clientMux)
+				connID, gErr = s.core.AutoLogIn(deviceTag, oldPass, devicePass, deviceUserID, conn, &user, &clientMux)
 				if gErr.ID != 0 {
 					//ERROR AUTO-LOGGING - RUN AUTOLOGCOMPLETE AND DELETE KEYS FOR CLIENT, AND SILENTLY CHANGE DEVICE TAG
 					newTag, newTagErr := helpers.GenerateSecureString(32)
 					if newTagErr != nil {
-						closeSocket(conn)
+						s.closeSocket(conn)
 						return
 					}
 					autologMessage := map[string]map[string]interface{}{
@@ -203,9 +276,9 @@ func clientActionListener(conn *websocket.Conn) {
 							},
 						},
 					}
-					writeErr := conn.WriteJSON(autologMessage)
+					writeErr := s.writeMessage(conn, autologMessage)
 					if writeErr != nil {
-						closeSocket(conn)
+						s.closeSocket(conn)
 						return
 					}
 					devicePass = ""
@@ -223,25 +296,30 @@ func clientActionListener(conn *websocket.Conn) {
 	//STANDARD CONNECTION LOOP
 	for {
 		//READ INPUT BUFFER
-		readErr := conn.ReadJSON(&action)
+		readErr := s.receive(conn, state, &action)
 		if readErr != nil || action.A == "" {
 			//DISCONNECT USER
 			clientMux.Lock()
 			sockedDropped(user, connID, &clientMux)
-			closeSocket(conn)
+			s.closeSocket(conn)
 			return
 		}
 
 		//TAKE ACTION
-		responseVal, respond, actionErr := clientActionHandler(action, &user, conn, &deviceTag, &devicePass, &deviceUserID, &connID, &clientMux)
+		responseVal, respond, actionErr := s.clientActionHandler(action, &user, conn, &deviceTag, &devicePass, &deviceUserID, &connID, &clientMux)
+		if actionErr.ID == helpers.ErrorGopherInvalidAction {
+			s.metrics.Inc(helpers.MetricClientActions, "action", "invalid", "error", strconv.Itoa(actionErr.ID))
+		} else {
+			s.metrics.Inc(helpers.MetricClientActions, "action", action.A, "error", strconv.Itoa(actionErr.ID))
+		}
 
 		if respond {
 			//SEND RESPONSE
-			if writeErr := conn.WriteJSON(helpers.MakeClientResponse(action.A, responseVal, actionErr)); writeErr != nil {
+			if writeErr := s.writeMessage(conn, helpers.MakeClientResponse(action.A, responseVal, actionErr)); writeErr != nil {
 				//DISCONNECT USER
 				clientMux.Lock()
 				sockedDropped(user, connID, &clientMux)
-				closeSocket(conn)
+				s.closeSocket(conn)
 				return
 			}
 		}
@@ -251,10 +329,19 @@ func clientActionListener(conn *websocket.Conn) {
 	}
 }
 
-func closeSocket(conn *websocket.Conn) {
-	conn.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(time.Second*1))
+// writeMessage sends a message to a client, and counts it in the Server's metrics.
+func (s *Server) writeMessage(conn core.ClientConn, message interface{}) error {
+	if err := conn.Send(message); err != nil {
+		return err
+	}
+	s.metrics.Inc(helpers.MetricMessagesSent, "type", helpers.MessageType(message))
+	return nil
+}
+
+func (s *Server) closeSocket(conn ClientTransport) {
+	s.closeUDPSession(conn)
 	conn.Close()
-	conns.subtract()
+	s.conns.subtract(conn)
 }
 
 func sockedDropped(user *core.User, connID string, clientMux *sync.Mutex) {
@@ -267,10 +354,10 @@ func sockedDropped(user *core.User, connID string, clientMux *sync.Mutex) {
 
 /////////////////////// HELPERS FOR connections
 
-func (c *connections) add() bool {
+func (c *connections) add(max int) bool {
 	c.connsMux.Lock()
 	//
-	if (*settings).MaxConnections != 0 && c.conns == (*settings).MaxConnections {
+	if max != 0 && c.conns >= max {
 		c.connsMux.Unlock()
 		return false
 	}
@@ -280,17 +367,59 @@ func (c *connections) add() bool {
 	return true
 }
 
-func (c *connections) subtract() {
+func (c *connections) subtract(conn ClientTransport) {
 	c.connsMux.Lock()
 	c.conns--
+	delete(c.sockets, conn)
+	c.connsMux.Unlock()
+}
+
+func (c *connections) track(conn ClientTransport) {
+	c.connsMux.Lock()
+	if c.sockets == nil {
+		c.sockets = make(map[ClientTransport]*connState)
+	}
+	c.sockets[conn] = newConnState()
+	c.connsMux.Unlock()
+}
+
+func (c *connections) list() []ClientTransport {
+	c.connsMux.Lock()
+	sockets := make([]ClientTransport, 0, len(c.sockets))
+	for conn := range c.sockets {
+		sockets = append(sockets, conn)
+	}
 	c.connsMux.Unlock()
+	return sockets
+}
+
+func (c *connections) state(conn ClientTransport) *connState {
+	c.connsMux.Lock()
+	state := c.sockets[conn]
+	c.connsMux.Unlock()
+	return state
+}
+
+func (c *connections) states() map[ClientTransport]*connState {
+	c.connsMux.Lock()
+	states := make(map[ClientTransport]*connState, len(c.sockets))
+	for conn, state := range c.sockets {
+		states[conn] = state
+	}
+	c.connsMux.Unlock()
+	return states
 }
 
 // ClientsConnected returns the number of clients connected to the server. Includes connections
 // not logged in as a User. To get the number of Users logged in, use the core.UserCount() function.
-func ClientsConnected() int {
-	conns.connsMux.Lock()
-	c := conns.conns
-	conns.connsMux.Unlock()
+func (s *Server) ClientsConnected() int {
+	s.conns.connsMux.Lock()
+	c := s.conns.conns
+	s.conns.connsMux.Unlock()
 	return c
 }
+
+// ClientsConnected returns the number of clients connected to the default server.
+func ClientsConnected() int {
+	return defaultServer.ClientsConnected()
+}
@@ -0,0 +1,111 @@
+package gopher
+
+import (
+	"github.com/gorilla/websocket"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEmbeddedServer(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+
+	mux := http.NewServeMux()
+	mux.Handle("/game", server.SocketHandler())
+	api := httptest.NewServer(mux)
+	defer api.Close()
+	runTestServer(t, server)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(api.URL, "http")+"/game", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+}
+
+func TestSocketOrigin(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "http://localhost",
+		DisableListeners: true,
+		OriginOnly:       true,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	addr := runTestServer(t, server)
+
+	// The Origin's port must be the one SocketHandler() is served on
+	_, port, _ := net.SplitHostPort(addr)
+	origins := map[string]bool{
+		"http://localhost:" + port:        true,
+		"http://LOCALHOST:" + port:        true,
+		"https://localhost:" + port:       false,
+		"http://localhost":                false,
+		"http://evil.example.com:" + port: false,
+		"":                                false,
+	}
+	for origin, ok := range origins {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, res, err := websocket.DefaultDialer.Dial("ws://"+addr, header)
+		if err == nil {
+			conn.Close()
+		}
+		if (err == nil) != ok || (!ok && (res == nil || res.StatusCode != http.StatusForbidden)) {
+			t.Errorf("Connecting from %q got error %v", origin, err)
+		}
+	}
+}
+
+func TestMessagePackClient(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	addr := runTestServer(t, server)
+
+	dialer := websocket.Dialer{Subprotocols: []string{helpers.CodecMessagePack}}
+	conn, _, err := dialer.Dial("ws://"+addr, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if conn.Subprotocol() != helpers.CodecMessagePack {
+		t.Fatalf("Server picked subprotocol %q", conn.Subprotocol())
+	}
+
+	// Log in as a guest
+	codec := helpers.MessagePackCodec{}
+	login, _ := codec.Marshal(map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bob", "g": true}})
+	if err = conn.WriteMessage(websocket.BinaryMessage, login); err != nil {
+		t.Fatal(err)
+	}
+	msgType, data, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]interface{}
+	if err = codec.Unmarshal(data, &response); err != nil || msgType != websocket.BinaryMessage {
+		t.Fatalf("Response type %v, error %v", msgType, err)
+	}
+	res, _ := response[helpers.ServerActionClientActionResponse].(map[string]interface{})
+	if res["a"] != helpers.ClientActionLogin || res["e"] != nil {
+		t.Errorf("Login response is %v", response)
+	}
+	if server.Core().UserCount() != 1 {
+		t.Error("User wasn't logged in")
+	}
+
+}
@@ -0,0 +1,185 @@
+package gopher
+
+import (
+	"bufio"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// TCPListener is an address the server accepts raw TCP client connections on, for native clients that would rather not use websockets.
+// TCP clients share the same Users and Rooms as websocket clients, count towards MaxConnections in ServerSettings, and send and receive
+// the same messages, so the client actions and the "Remember Me" device tagging work the same over both.
+//
+// Every message is sent as a frame: a 4-byte big-endian unsigned length, followed by that many bytes of the message encoded with the
+// TCPListener's Codec. The OriginOnly setting and the ClientConnectCallback don't apply to TCP clients, since they have no HTTP request.
+type TCPListener struct {
+	IP           string // The TCPListener's IP address. (Required)
+	Port         int    // The TCPListener's port. (Required)
+	Codec        string // The name of the helpers.Codec messages are encoded with (ex: "msgpack"). Defaults to helpers.CodecJSON.
+	MaxFrameSize int    // The largest frame in bytes a client can send before it's disconnected. Defaults to 1048576 (1 MiB).
+	TLS          bool   // Enables TLS/SSL connections.
+	CertFile     string // SSL/TLS certificate file location (starting from system's root folder). (Required for TLS)
+	PrivKeyFile  string // SSL/TLS private key file location (starting from system's root folder). (Required for TLS)
+}
+
+const (
+	defaultTCPMaxFrameSize = 1 << 20
+)
+
+var (
+	errorTCPFrameSize = errors.New("Frame is larger than MaxFrameSize")
+
+	// HOW LONG TO WAIT BEFORE ACCEPTING AGAIN AFTER AN ACCEPT ERROR, DOUBLING UP TO tcpMaxAcceptRetryDelay
+	tcpAcceptRetryDelay    time.Duration = time.Millisecond * 5
+	tcpMaxAcceptRetryDelay time.Duration = time.Second
+)
+
+// tcpListeners gets the TCPListeners to start, with their defaults filled in.
+func (settings *ServerSettings) tcpListeners() []TCPListener {
+	listeners := make([]TCPListener, len(settings.TCPListeners))
+	copy(listeners, settings.TCPListeners)
+	for i := range listeners {
+		if listeners[i].Codec == "" {
+			listeners[i].Codec = helpers.CodecJSON
+		}
+		if listeners[i].MaxFrameSize == 0 {
+			listeners[i].MaxFrameSize = defaultTCPMaxFrameSize
+		}
+	}
+	return listeners
+}
+
+// makeTCPServer starts accepting clients on a TCPListener.
+func (s *Server) makeTCPServer(l TCPListener, cert *certReloader, done chan struct{}) (net.Listener, error) {
+	listener, err := net.Listen("tcp", l.IP+":"+strconv.Itoa(l.Port))
+	if err != nil {
+		return nil, err
+	}
+	if l.TLS {
+		listener = tls.NewListener(listener, &tls.Config{GetCertificate: cert.getCertificate})
+		go cert.watch(done)
+	}
+	go s.tcpAcceptor(listener, l, done)
+	return listener, nil
+}
+
+// tcpAcceptor accepts clients until the listener is closed. Other Accept errors (like running out of file descriptors) are retried
+// after a delay that doubles up to a second, the same way http.Server does, so they don't take the whole server down.
+func (s *Server) tcpAcceptor(listener net.Listener, l TCPListener, done chan struct{}) {
+	codec := helpers.GetCodec(l.Codec)
+	var retryDelay time.Duration
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if errors.Is(err, net.ErrClosed) {
+				if !s.isStopping() {
+					s.endServer(err)
+				}
+				return
+			}
+			if retryDelay == 0 {
+				retryDelay = tcpAcceptRetryDelay
+			} else if retryDelay *= 2; retryDelay > tcpMaxAcceptRetryDelay {
+				retryDelay = tcpMaxAcceptRetryDelay
+			}
+			s.logger.Warn("Error accepting TCP client, retrying", "address", listener.Addr(), "error", err, "retryIn", retryDelay)
+			select {
+			case <-done:
+				return
+			case <-time.After(retryDelay):
+			}
+			continue
+		}
+		retryDelay = 0
+
+		//REJECT IF SERVER IS FULL
+		if !s.conns.add(s.getSettings().MaxConnections) {
+			conn.Close()
+			continue
+		}
+
+		// START TCP LOOP
+		client := &tcpConn{
+			conn:    conn,
+			reader:  bufio.NewReader(conn),
+			codec:   codec,
+			maxSize: l.MaxFrameSize,
+			meta:    map[string]string{"transport": "tcp", "codec": l.Codec, "tls": strconv.FormatBool(l.TLS)},
+		}
+		s.conns.track(client)
+		go s.clientActionListener(client)
+	}
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   TCP connections   ///////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// tcpConn is the ClientTransport for clients connected to a TCPListener.
+type tcpConn struct {
+	conn     net.Conn
+	reader   *bufio.Reader
+	codec    helpers.Codec
+	maxSize  int
+	meta     map[string]string
+	writeMux sync.Mutex // KEEPS FRAMES FROM SEVERAL GOROUTINES WHOLE
+}
+
+func (t *tcpConn) Send(message interface{}) error {
+	data, err := t.codec.Marshal(message)
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 4+len(data))
+	binary.BigEndian.PutUint32(frame, uint32(len(data)))
+	copy(frame[4:], data)
+	t.writeMux.Lock()
+	defer t.writeMux.Unlock()
+	t.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+	_, err = t.conn.Write(frame)
+	return err
+}
+
+func (t *tcpConn) Receive(v interface{}) error {
+	var header [4]byte
+	if _, err := io.ReadFull(t.reader, header[:]); err != nil {
+		return err
+	}
+	size := binary.BigEndian.Uint32(header[:])
+	if uint64(size) > uint64(t.maxSize) {
+		return errorTCPFrameSize
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(t.reader, data); err != nil {
+		return err
+	}
+	return t.codec.Unmarshal(data, v)
+}
+
+func (t *tcpConn) Close() error {
+	return t.conn.Close()
+}
+
+func (t *tcpConn) RemoteAddr() net.Addr {
+	return t.conn.RemoteAddr()
+}
+
+func (t *tcpConn) Metadata() map[string]string {
+	meta := make(map[string]string, len(t.meta))
+	for k, v := range t.meta {
+		meta[k] = v
+	}
+	return meta
+}
@@ -0,0 +1,118 @@
+package gopher
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestTCPClient(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		TCPListeners:     []TCPListener{{IP: "localhost"}},
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	runTestServer(t, server)
+
+	conn, err := net.Dial("tcp", "localhost:"+strconv.Itoa(server.getSettings().TCPListeners[0].Port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Log in as a guest
+	login, _ := json.Marshal(map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bob", "g": true}})
+	frame := make([]byte, 4+len(login))
+	binary.BigEndian.PutUint32(frame, uint32(len(login)))
+	copy(frame[4:], login)
+	if _, err = conn.Write(frame); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = io.ReadFull(conn, frame[:4]); err != nil {
+		t.Fatal(err)
+	}
+	data := make([]byte, binary.BigEndian.Uint32(frame[:4]))
+	if _, err = io.ReadFull(conn, data); err != nil {
+		t.Fatal(err)
+	}
+	var response map[string]map[string]interface{}
+	if err = json.Unmarshal(data, &response); err != nil {
+		t.Fatal(err)
+	}
+	res := response[helpers.ServerActionClientActionResponse]
+	if res["a"] != helpers.ClientActionLogin || res["e"] != nil {
+		t.Errorf("Login response is %v", response)
+	}
+	if server.Core().UserCount() != 1 || server.ClientsConnected() != 1 {
+		t.Error("Client wasn't logged in")
+	}
+
+}
+
+// acceptListener is a net.Listener that hands out the errors and connections sent on it's channel, and is closed when the channel is
+type acceptListener chan interface{}
+
+func (l acceptListener) Accept() (net.Conn, error) {
+	next, ok := <-l
+	if !ok {
+		return nil, net.ErrClosed
+	}
+	if err, isErr := next.(error); isErr {
+		return nil, err
+	}
+	return next.(net.Conn), nil
+}
+
+func (l acceptListener) Close() error   { return nil }
+func (l acceptListener) Addr() net.Addr { return &net.TCPAddr{} }
+
+func TestTCPAcceptErrors(t *testing.T) {
+	server := NewServer(&ServerSettings{ServerName: "!server!"})
+	listener := make(acceptListener)
+	returned := make(chan struct{})
+	go func() {
+		server.tcpAcceptor(listener, TCPListener{}, make(chan struct{}))
+		close(returned)
+	}()
+
+	// Running out of file descriptors is retried, and clients are still accepted after
+	for i := 0; i < 3; i++ {
+		listener <- &net.OpError{Op: "accept", Net: "tcp", Err: os.NewSyscallError("accept", syscall.EMFILE)}
+	}
+	client, conn := net.Pipe()
+	defer client.Close()
+	listener <- conn
+	deadline := time.Now().Add(time.Second * 5)
+	for server.ClientsConnected() != 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("Client wasn't accepted after the accept errors")
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+	select {
+	case err := <-server.serverEndChan:
+		t.Fatalf("Accept error ended the server: %v", err)
+	default:
+	}
+
+	// A closed listener ends the server
+	close(listener)
+	select {
+	case <-returned:
+	case <-time.After(time.Second * 5):
+		t.Fatal("Acceptor didn't stop when the listener closed")
+	}
+	if err := <-server.serverEndChan; !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Server ended with %v", err)
+	}
+}
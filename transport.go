@@ -0,0 +1,122 @@
+package gopher
+
+import (
+	"errors"
+	"github.com/gorilla/websocket"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"sync"
+	"time"
+)
+
+// ClientTransport is a core.ClientConn that the server can also read client actions from. The server makes one for every
+// websocket connection, and you can implement your own to run other transports, in-process test clients, or server-side bots
+// through the server's client actions with *Server.ServeClient().
+type ClientTransport interface {
+	core.ClientConn
+	// Receive reads the next client action into v. Receive is only called from one goroutine at a time, and the client is
+	// disconnected when it returns an error.
+	Receive(v interface{}) error
+}
+
+// How long sending a message to a client can take before it's connection is treated as dead
+const clientWriteTimeout = time.Second * 10
+
+// ServeClient runs a ClientTransport through the Server's client actions, just like a client connected to one of it's Listeners.
+// The client counts towards MaxConnections in ServerSettings, and is closed when the Server shuts down. ServeClient blocks until
+// the client disconnects, or the ClientTransport's Receive returns an error.
+func (s *Server) ServeClient(conn ClientTransport) error {
+	if !s.isRunning() {
+		return errors.New("Server is not running")
+	} else if conn == nil {
+		return errors.New("ServeClient() requires a ClientTransport")
+	} else if !s.conns.add(s.getSettings().MaxConnections) {
+		return errors.New("Server is full")
+	}
+	s.conns.track(conn)
+	s.clientActionListener(conn)
+	return nil
+}
+
+// ServeClient runs a ClientTransport through the default server's client actions. See *Server.ServeClient() for more details.
+func ServeClient(conn ClientTransport) error {
+	return defaultServer.ServeClient(conn)
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   Websocket   /////////////////////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+// websocketConn is the ClientTransport for clients connected to a Listener. Messages are encoded with the Codec the client
+// picked with the websocket subprotocol.
+type websocketConn struct {
+	conn     *websocket.Conn
+	codec    helpers.Codec
+	meta     map[string]string
+	writeMux sync.Mutex // websocket.Conn SUPPORTS ONE WRITER AT A TIME
+}
+
+func newWebsocketConn(conn *websocket.Conn, path string) *websocketConn {
+	codecName := conn.Subprotocol()
+	if codecName == "" {
+		codecName = helpers.CodecJSON
+	}
+	return &websocketConn{
+		conn:  conn,
+		codec: helpers.GetCodec(codecName),
+		meta:  map[string]string{"transport": "websocket", "codec": codecName, "path": path},
+	}
+}
+
+func (w *websocketConn) Send(message interface{}) error {
+	data, err := w.codec.Marshal(message)
+	if err != nil {
+		return err
+	}
+	messageType := websocket.TextMessage
+	if w.codec.Binary() {
+		messageType = websocket.BinaryMessage
+	}
+	w.writeMux.Lock()
+	defer w.writeMux.Unlock()
+	w.conn.SetWriteDeadline(time.Now().Add(clientWriteTimeout))
+	return w.conn.WriteMessage(messageType, data)
+}
+
+func (w *websocketConn) Receive(v interface{}) error {
+	_, data, err := w.conn.ReadMessage()
+	if err != nil {
+		return err
+	}
+	return w.codec.Unmarshal(data, v)
+}
+
+func (w *websocketConn) Ping() error {
+	return w.conn.WriteControl(websocket.PingMessage, []byte{}, time.Now().Add(time.Second*1))
+}
+
+func (w *websocketConn) OnPong(pong func()) {
+	w.conn.SetPongHandler(func(string) error {
+		pong()
+		return nil
+	})
+}
+
+// Close doesn't wait for writeMux, so it can still close a connection that is stuck sending a message
+func (w *websocketConn) Close() error {
+	w.conn.WriteControl(websocket.CloseMessage, []byte{}, time.Now().Add(time.Second*1))
+	return w.conn.Close()
+}
+
+func (w *websocketConn) RemoteAddr() net.Addr {
+	return w.conn.RemoteAddr()
+}
+
+func (w *websocketConn) Metadata() map[string]string {
+	meta := make(map[string]string, len(w.meta))
+	for k, v := range w.meta {
+		meta[k] = v
+	}
+	return meta
+}
@@ -0,0 +1,83 @@
+package gopher
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"sync"
+	"testing"
+)
+
+// testConn is an in-process ClientTransport
+type testConn struct {
+	in     chan interface{}
+	out    chan interface{}
+	closed chan struct{}
+	once   sync.Once
+}
+
+func (c *testConn) Send(message interface{}) error {
+	select {
+	case c.out <- message:
+		return nil
+	case <-c.closed:
+		return errors.New("Connection closed")
+	}
+}
+
+func (c *testConn) Receive(v interface{}) error {
+	select {
+	case message := <-c.in:
+		j, _ := json.Marshal(message)
+		return json.Unmarshal(j, v)
+	case <-c.closed:
+		return errors.New("Connection closed")
+	}
+}
+
+func (c *testConn) Close() error {
+	c.once.Do(func() { close(c.closed) })
+	return nil
+}
+
+func (c *testConn) RemoteAddr() net.Addr        { return nil }
+func (c *testConn) Metadata() map[string]string { return map[string]string{"transport": "test"} }
+
+func TestServeClient(t *testing.T) {
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+
+	conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 1), closed: make(chan struct{})}
+	if err := server.ServeClient(conn); err == nil {
+		t.Fatal("ServeClient() should fail before the server is running")
+	}
+	runTestServer(t, server)
+
+	served := make(chan error, 1)
+	go func() { served <- server.ServeClient(conn) }()
+
+	// Log in as a guest
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bot", "g": true}}
+	response, _ := (<-conn.out).(map[string]map[string]interface{})
+	res := response[helpers.ServerActionClientActionResponse]
+	if res["a"] != helpers.ClientActionLogin || res["e"] != nil {
+		t.Errorf("Login response is %v", response)
+	}
+	if server.Core().UserCount() != 1 || server.ClientsConnected() != 1 {
+		t.Error("Client wasn't logged in")
+	}
+
+	conn.Close()
+	if err := <-served; err != nil {
+		t.Error(err)
+	}
+	if server.ClientsConnected() != 0 {
+		t.Error("Client is still connected")
+	}
+
+}
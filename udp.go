@@ -0,0 +1,233 @@
+package gopher
+
+import (
+	"encoding/binary"
+	"github.com/hewiefreeman/GopherGameServer/core"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"strconv"
+	"sync"
+)
+
+// The UDP channel is an optional, unreliable side-channel for realtime state (ex: movement updates) that would rather be dropped than
+// arrive late. It's enabled with UDPPort in ServerSettings. A logged in client asks for a token with the helpers.ClientActionUDPToken
+// client action over it's normal connection, and gets back the token ("t") and the server's UDP port ("p").
+//
+// Every datagram from the client starts with the token, followed by a 4-byte big-endian sequence number, then an optional message
+// encoded with the same Codec as the client's normal connection. The client's address is bound to the token by the first datagram
+// with a valid token, so clients should send an empty one (just the token and sequence number) right after getting their token,
+// and every so often to keep NAT mappings open. Datagrams from any other address are dropped, so a client that changes address
+// has to ask for a new token. Datagrams that are not newer than the last one received are also dropped. Every other datagram
+// counts as the client being heard from for ReadTimeout in ServerSettings. The only message clients can send over the UDP channel
+// is a helpers.ClientActionCustomAction, which also counts for IdleTimeout, and it's response is sent over the normal connection.
+//
+// Every datagram from the server starts with it's own 4-byte big-endian sequence number, followed by the message. Clients should
+// also drop datagrams that are not newer than the last one they received. The server sends *User.UnreliableDataMessage() and
+// *Room.UnreliableDataMessage() messages over the UDP channel, and everything else over the normal connection.
+const (
+	udpTokenLength   = 32   // LENGTH OF helpers.GenerateSecureString(24)
+	udpHeaderLength  = 4    // SEQUENCE NUMBER
+	udpMaxMessageLen = 1200 // LARGER MESSAGES ARE SENT OVER THE NORMAL CONNECTION TO AVOID IP FRAGMENTATION
+	udpReadBuffer    = 65535
+)
+
+type udpChannel struct {
+	conn     net.PacketConn
+	sessions map[string]*udpSession          // token -> session
+	conns    map[core.ClientConn]*udpSession // client connection -> session
+	mux      sync.Mutex
+}
+
+type udpSession struct {
+	token string
+	conn  core.ClientConn
+	state *connState // THE CLIENT CONNECTION'S HEARTBEAT, OR nil IF IT HAS NONE
+	codec helpers.Codec
+
+	// THE CLIENT'S STATE IN clientActionListener. MUST LOCK clientMux WHEN USING user AND connID
+	clientMux *sync.Mutex
+	user      **core.User
+	connID    *string
+
+	// MUST LOCK mux WHEN USING BELOW ITEMS
+	addr   net.Addr
+	inSeq  uint32
+	inAny  bool // A DATAGRAM HAS BEEN RECEIVED
+	outSeq uint32
+	mux    sync.Mutex
+}
+
+// startUDP starts the UDP channel.
+func (s *Server) startUDP(done chan struct{}) error {
+	settings := s.getSettings()
+	conn, err := net.ListenPacket("udp", settings.UDPIP+":"+strconv.Itoa(settings.UDPPort))
+	if err != nil {
+		return err
+	}
+	u := &udpChannel{conn: conn, sessions: make(map[string]*udpSession), conns: make(map[core.ClientConn]*udpSession)}
+	s.stateMux.Lock()
+	s.udp = u
+	s.stateMux.Unlock()
+	go s.udpListener(u, done)
+	return nil
+}
+
+// getUDP gets the Server's UDP channel, or nil when it isn't running.
+func (s *Server) getUDP() *udpChannel {
+	s.stateMux.Lock()
+	defer s.stateMux.Unlock()
+	return s.udp
+}
+
+func (s *Server) udpListener(u *udpChannel, done chan struct{}) {
+	buf := make([]byte, udpReadBuffer)
+	for {
+		n, addr, err := u.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			if !s.isStopping() {
+				s.endServer(err)
+			}
+			return
+		}
+		if n < udpTokenLength+udpHeaderLength {
+			continue
+		}
+
+		// FIND THE SESSION
+		u.mux.Lock()
+		session := u.sessions[string(buf[:udpTokenLength])]
+		u.mux.Unlock()
+		if session == nil {
+			continue
+		}
+
+		// DROP OLD DATAGRAMS AND DATAGRAMS FROM OTHER ADDRESSES, AND BIND THE CLIENT'S ADDRESS
+		seq := binary.BigEndian.Uint32(buf[udpTokenLength:])
+		session.mux.Lock()
+		if session.inAny && (int32(seq-session.inSeq) <= 0 || addr.String() != session.addr.String()) {
+			session.mux.Unlock()
+			continue
+		}
+		session.inSeq = seq
+		session.inAny = true
+		session.addr = addr
+		session.mux.Unlock()
+
+		if n == udpTokenLength+udpHeaderLength {
+			if session.state != nil {
+				session.state.heard()
+			}
+			continue
+		}
+		var action clientAction
+		if session.codec.Unmarshal(buf[udpTokenLength+udpHeaderLength:n], &action) != nil || action.A != helpers.ClientActionCustomAction {
+			continue
+		}
+		session.clientMux.Lock()
+		loggedIn := *session.user != nil
+		connID := *session.connID
+		session.clientMux.Unlock()
+		if !loggedIn {
+			continue
+		}
+		if session.state != nil {
+			session.state.read()
+		}
+		s.metrics.Inc(helpers.MetricClientActions, "action", action.A, "error", "0")
+		go s.clientCustomAction(action.P, session.user, session.conn, connID, session.clientMux)
+	}
+}
+
+// sendUnreliable sends a message over a client's UDP channel. Returns false when the client has no bound UDP channel, or the message
+// can't be sent over it, so the message can be sent over the client's normal connection instead.
+func (s *Server) sendUnreliable(conn core.ClientConn, message interface{}) bool {
+	u := s.getUDP()
+	if u == nil {
+		return false
+	}
+	u.mux.Lock()
+	session := u.conns[conn]
+	u.mux.Unlock()
+	if session == nil {
+		return false
+	}
+	data, err := session.codec.Marshal(message)
+	if err != nil || len(data) > udpMaxMessageLen {
+		return false
+	}
+	session.mux.Lock()
+	addr := session.addr
+	session.outSeq++
+	seq := session.outSeq
+	session.mux.Unlock()
+	if addr == nil {
+		return false
+	}
+	datagram := make([]byte, udpHeaderLength+len(data))
+	binary.BigEndian.PutUint32(datagram, seq)
+	copy(datagram[udpHeaderLength:], data)
+	if _, err = u.conn.WriteTo(datagram, addr); err != nil {
+		s.logger.Warn("Error sending UDP message to client", "address", addr, "error", err)
+		return false
+	}
+	return true
+}
+
+// closeUDPSession removes a client connection's UDP session, if it has one.
+func (s *Server) closeUDPSession(conn core.ClientConn) {
+	u := s.getUDP()
+	if u == nil {
+		return
+	}
+	u.mux.Lock()
+	if session, ok := u.conns[conn]; ok {
+		delete(u.sessions, session.token)
+		delete(u.conns, conn)
+	}
+	u.mux.Unlock()
+}
+
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+//   UDP TOKEN CLIENT ACTION   ///////////////////////////////////////////////////////////////////////
+//////////////////////////////////////////////////////////////////////////////////////////////////////
+
+func (s *Server) clientActionUDPToken(user **core.User, conn core.ClientConn, connID *string, clientMux *sync.Mutex) (interface{}, bool, helpers.GopherError) {
+	u := s.getUDP()
+	if u == nil {
+		return nil, true, helpers.NewError(errorFeatureDisabled, helpers.ErrorGopherFeatureDisabled)
+	}
+	(*clientMux).Lock()
+	loggedIn := *user != nil
+	(*clientMux).Unlock()
+	if !loggedIn {
+		return nil, true, helpers.NewError(errorNotLoggedIn, helpers.ErrorGopherNotLoggedIn)
+	}
+	token, err := helpers.GenerateSecureString(24)
+	if err != nil {
+		return nil, true, helpers.NewError("Could not make a UDP token", helpers.ErrorAuthUnexpected)
+	}
+
+	// A NEW TOKEN REPLACES THE CONNECTION'S OLD ONE
+	session := &udpSession{token: token, conn: conn, codec: helpers.GetCodec(conn.Metadata()["codec"]), clientMux: clientMux, user: user, connID: connID}
+	if transport, ok := conn.(ClientTransport); ok {
+		session.state = s.conns.state(transport)
+	}
+	u.mux.Lock()
+	if old, ok := u.conns[conn]; ok {
+		delete(u.sessions, old.token)
+	}
+	u.sessions[token] = session
+	u.conns[conn] = session
+	u.mux.Unlock()
+
+	port := s.getSettings().UDPPort
+	if addr, ok := u.conn.LocalAddr().(*net.UDPAddr); ok {
+		port = addr.Port
+	}
+	return map[string]interface{}{"t": token, "p": port}, true, helpers.NoError()
+}
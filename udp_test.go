@@ -0,0 +1,113 @@
+package gopher
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"github.com/hewiefreeman/GopherGameServer/actions"
+	"github.com/hewiefreeman/GopherGameServer/helpers"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestUDPChannel(t *testing.T) {
+	udpPort := freePort(t, "udp")
+	server := NewServer(&ServerSettings{
+		ServerName:       "!server!",
+		HostName:         "localhost",
+		DisableListeners: true,
+		UDPIP:            "localhost",
+		UDPPort:          udpPort,
+		IdleTimeout:      1,
+		AdminLogin:       "admin",
+		AdminPassword:    "password"})
+	moves := make(chan interface{}, 16)
+	server.Actions().New("move", actions.DataTypeMap, func(data interface{}, client *actions.Client) {
+		moves <- data
+	})
+	runTestServer(t, server)
+
+	conn := &testConn{in: make(chan interface{}, 1), out: make(chan interface{}, 1), closed: make(chan struct{})}
+	go server.ServeClient(conn)
+	defer conn.Close()
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionLogin, "P": map[string]interface{}{"n": "bot", "g": true}}
+	<-conn.out
+
+	// Get a token, and bind the UDP address
+	conn.in <- map[string]interface{}{"A": helpers.ClientActionUDPToken}
+	response, _ := (<-conn.out).(map[string]map[string]interface{})
+	res, _ := response[helpers.ServerActionClientActionResponse]["r"].(map[string]interface{})
+	token, _ := res["t"].(string)
+	if len(token) != udpTokenLength || res["p"] != udpPort {
+		t.Fatalf("Token response is %v", response)
+	}
+	udp, err := net.Dial("udp", "localhost:"+strconv.Itoa(udpPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer udp.Close()
+	datagram := func(seq uint32, message interface{}) []byte {
+		d := append([]byte(token), 0, 0, 0, 0)
+		binary.BigEndian.PutUint32(d[udpTokenLength:], seq)
+		if message != nil {
+			j, _ := json.Marshal(message)
+			d = append(d, j...)
+		}
+		return d
+	}
+	udp.Write(datagram(1, nil))
+	time.Sleep(time.Millisecond * 100)
+
+	// Unreliable data messages go over UDP
+	user, _ := server.Core().GetUser("bot")
+	user.UnreliableDataMessage("state", "")
+	buf := make([]byte, 1500)
+	udp.SetReadDeadline(time.Now().Add(time.Second * 2))
+	n, err := udp.Read(buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if binary.BigEndian.Uint32(buf) != 1 || string(buf[4:n]) != `{"d":"state"}` {
+		t.Errorf("Got datagram %q", buf[:n])
+	}
+
+	// Custom actions come in over UDP, and old datagrams are dropped
+	move := map[string]interface{}{"A": helpers.ClientActionCustomAction, "P": map[string]interface{}{"a": "move", "d": map[string]interface{}{"x": 1}}}
+	udp.Write(datagram(3, move))
+	udp.Write(datagram(2, move))
+	time.Sleep(time.Millisecond * 200)
+	if len(moves) != 1 {
+		t.Errorf("Got %v custom actions", len(moves))
+	}
+	<-moves
+
+	// Another address with the token can't take over the binding
+	other, err := net.Dial("udp", "localhost:"+strconv.Itoa(udpPort))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer other.Close()
+	other.Write(datagram(10, move))
+	time.Sleep(time.Millisecond * 100)
+	if len(moves) != 0 {
+		t.Error("Got a custom action from another address")
+	}
+	user.UnreliableDataMessage("state", "")
+	udp.SetReadDeadline(time.Now().Add(time.Second * 2))
+	if _, err = udp.Read(buf); err != nil {
+		t.Error("Binding moved to another address")
+	}
+
+	// Custom actions over UDP keep the client from being dropped as idle
+	for i := uint32(0); i < 10; i++ {
+		udp.Write(datagram(4+i, move))
+		time.Sleep(time.Millisecond * 250)
+	}
+	select {
+	case <-conn.closed:
+		t.Error("Client sending custom actions over UDP was dropped as idle")
+	default:
+	}
+
+}